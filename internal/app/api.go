@@ -0,0 +1,185 @@
+package app
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"os"
+	"strconv"
+)
+
+const (
+	apiDefaultLimit = 100
+	apiMaxLimit     = 500
+)
+
+type apiObjectPage struct {
+	Items []map[string]any `json:"items"`
+	Next  string           `json:"next,omitempty"`
+}
+
+type apiTypeSummary struct {
+	Name       string `json:"name"`
+	Count      int    `json:"count"`
+	DirtyCount int    `json:"dirtyCount"`
+}
+
+type apiError struct {
+	Error string `json:"error"`
+}
+
+func (s *webServer) handleAPI(w http.ResponseWriter, r *http.Request) {
+	parts := splitPath(r.URL.Path)
+	if len(parts) < 3 || parts[0] != "api" || parts[1] != "w" {
+		writeJSONError(w, http.StatusNotFound, "not found")
+		return
+	}
+	s.serveAPI(w, r, parts[2], parts[3:])
+}
+
+// serveAPI handles read-only JSON routes for both /api/w/<workspace>/... and
+// /w/<workspace>/api/....
+func (s *webServer) serveAPI(w http.ResponseWriter, r *http.Request, workspace string, tail []string) {
+	if r.Method != http.MethodGet {
+		writeJSONError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	switch {
+	case len(tail) == 1 && tail[0] == "types":
+		s.handleAPITypes(w, workspace)
+	case len(tail) == 2 && tail[0] == "types":
+		s.handleAPIObjects(w, r, workspace, tail[1])
+	case len(tail) == 3 && tail[0] == "types" && tail[2] == "form":
+		s.handleAPIForm(w, workspace, tail[1])
+	case len(tail) == 3 && tail[0] == "types" && tail[2] == "objects":
+		s.handleAPIObjects(w, r, workspace, tail[1])
+	case len(tail) == 4 && tail[0] == "types" && tail[2] == "objects":
+		s.handleAPIObject(w, workspace, tail[1], tail[3])
+	default:
+		writeJSONError(w, http.StatusNotFound, "not found")
+	}
+}
+
+func (s *webServer) handleAPITypes(w http.ResponseWriter, workspace string) {
+	ctx, err := s.loadContext(workspace)
+	if err != nil {
+		writeJSONError(w, http.StatusNotFound, err.Error())
+		return
+	}
+	types := sortedKeys(ctx.Schemas)
+
+	summaries := make([]apiTypeSummary, 0, len(types))
+	for _, t := range types {
+		objs, err := s.repo.ListObjectsForType(ctx.RepoPath, t)
+		if err != nil {
+			writeJSONError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		summaries = append(summaries, apiTypeSummary{Name: t, Count: len(objs), DirtyCount: len(ctx.DirtyByType[t])})
+	}
+	writeJSON(w, http.StatusOK, summaries)
+}
+
+func (s *webServer) handleAPIObject(w http.ResponseWriter, workspace, typeName, id string) {
+	ctx, err := s.loadContext(workspace)
+	if err != nil {
+		writeJSONError(w, http.StatusNotFound, err.Error())
+		return
+	}
+	if _, ok := ctx.Schemas[typeName]; !ok {
+		writeJSONError(w, http.StatusNotFound, "unknown type "+typeName)
+		return
+	}
+	if !uuidPattern.MatchString(id) {
+		writeJSONError(w, http.StatusNotFound, "unknown object "+id)
+		return
+	}
+	obj, err := s.repo.ReadObject(ctx.RepoPath, typeName, id)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			writeJSONError(w, http.StatusNotFound, "unknown object "+id)
+			return
+		}
+		writeJSONError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, obj.Data)
+}
+
+func (s *webServer) handleAPIForm(w http.ResponseWriter, workspace, typeName string) {
+	ctx, err := s.loadContext(workspace)
+	if err != nil {
+		writeJSONError(w, http.StatusNotFound, err.Error())
+		return
+	}
+	schema, ok := ctx.Schemas[typeName]
+	if !ok {
+		writeJSONError(w, http.StatusNotFound, "unknown type "+typeName)
+		return
+	}
+	fields := schemaToFieldData(schema, ctx.UI.Types[typeName].HiddenFields)
+	s.enrichForeignKeys(&ctx, typeName, fields)
+	writeJSON(w, http.StatusOK, buildFormDescriptor(typeName, schema, fields))
+}
+
+func (s *webServer) handleAPIObjects(w http.ResponseWriter, r *http.Request, workspace, typeName string) {
+	repoPath, _, err := s.resolveWorkspacePath(workspace)
+	if err != nil {
+		writeJSONError(w, http.StatusNotFound, err.Error())
+		return
+	}
+	schemas, _, _, err := s.configs.load(repoPath)
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if _, ok := schemas[typeName]; !ok {
+		writeJSONError(w, http.StatusNotFound, "unknown type "+typeName)
+		return
+	}
+
+	limit := apiDefaultLimit
+	if raw := r.URL.Query().Get("limit"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 1 {
+			writeJSONError(w, http.StatusBadRequest, "limit must be a positive integer")
+			return
+		}
+		limit = min(n, apiMaxLimit)
+	}
+	after := r.URL.Query().Get("after")
+
+	objects, err := s.repo.ListObjectsForType(repoPath, typeName)
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	page := apiObjectPage{Items: make([]map[string]any, 0, limit)}
+	lastID := ""
+	for _, obj := range objects {
+		if after != "" && obj.ID <= after {
+			continue
+		}
+		if len(page.Items) == limit {
+			page.Next = lastID
+			break
+		}
+		page.Items = append(page.Items, obj.Data)
+		lastID = obj.ID
+	}
+	writeJSON(w, http.StatusOK, page)
+}
+
+func writeJSON(w http.ResponseWriter, status int, value any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	_ = enc.Encode(value)
+}
+
+func writeJSONError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, apiError{Error: message})
+}
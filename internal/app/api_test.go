@@ -0,0 +1,120 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestAPIObjectsPagesWithCursor(t *testing.T) {
+	repo := newTestRepository(t)
+	want := []string{testTeamID}
+	for i := 3; i <= 5; i++ {
+		id := testObjectID(i)
+		writeTestFile(t, filepath.Join(repo.Root, "data", "team", id+".yaml"),
+			fmt.Sprintf("_id: %s\n_type: team\ncode: T%d\nname: Team %d\n", id, i, i))
+		want = append(want, id)
+	}
+	h := newTestHandler(t, repo)
+
+	var first, second apiObjectPage
+	if err := json.Unmarshal(getTestPage(t, h, "/w/main/api/types/team/objects?limit=2"), &first); err != nil {
+		t.Fatal(err)
+	}
+	if len(first.Items) != 2 || first.Next == "" {
+		t.Fatalf("first page = %d item(s), next %q; want 2 items and a cursor", len(first.Items), first.Next)
+	}
+	if err := json.Unmarshal(getTestPage(t, h, "/w/main/api/types/team/objects?limit=2&after="+first.Next), &second); err != nil {
+		t.Fatal(err)
+	}
+	if second.Next != "" {
+		t.Errorf("second page next = %q, want none after the last object", second.Next)
+	}
+
+	got := make([]string, 0, len(want))
+	for _, item := range append(first.Items, second.Items...) {
+		got = append(got, item["_id"].(string))
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("paged ids = %v, want each object once in id order %v", got, want)
+	}
+
+	for _, limit := range []string{"0", "-1", "x"} {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/w/main/api/types/team/objects?limit="+limit, nil))
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("limit=%s: status %d, want 400", limit, rec.Code)
+		}
+	}
+}
+
+func TestAPIListGetAndErrors(t *testing.T) {
+	repo := newTestRepository(t)
+	ws := newTestWorkspace(t, repo, "draft")
+	replaceTestFile(t, filepath.Join(ws, "data", "service", testServiceID+".yaml"), "tier: edge", "tier: core")
+	h := newTestHandler(t, repo)
+
+	var types []apiTypeSummary
+	if err := json.Unmarshal(getTestPage(t, h, "/api/w/draft/types"), &types); err != nil {
+		t.Fatal(err)
+	}
+	wantTypes := []apiTypeSummary{{Name: "service", Count: 1, DirtyCount: 1}, {Name: "team", Count: 1}}
+	if !reflect.DeepEqual(types, wantTypes) {
+		t.Errorf("types = %+v, want %+v", types, wantTypes)
+	}
+
+	// Both route forms serve the object as stored in the workspace.
+	for _, path := range []string{"/api/w/draft/types/service/objects/" + testServiceID, "/w/draft/api/types/service/objects/" + testServiceID} {
+		var obj map[string]any
+		if err := json.Unmarshal(getTestPage(t, h, path), &obj); err != nil {
+			t.Fatal(err)
+		}
+		if obj["_id"] != testServiceID || obj["tier"] != "core" || obj["name"] != "edge-gateway" {
+			t.Errorf("GET %s = %v, want the draft service", path, obj)
+		}
+	}
+
+	var list apiObjectPage
+	if err := json.Unmarshal(getTestPage(t, h, "/api/w/main/types/service/objects"), &list); err != nil {
+		t.Fatal(err)
+	}
+	if len(list.Items) != 1 || list.Items[0]["tier"] != "edge" || list.Next != "" {
+		t.Errorf("main services = %+v, want the main version only", list)
+	}
+
+	for path, want := range map[string]int{
+		"/api/w/draft/types/widget/objects":                     http.StatusNotFound,
+		"/api/w/draft/types/widget/objects/" + testServiceID:    http.StatusNotFound,
+		"/api/w/draft/types/service/objects/" + testObjectID(9): http.StatusNotFound,
+		"/api/w/draft/types/service/objects/not-a-uuid":         http.StatusNotFound,
+		"/api/w/missing/types":                                  http.StatusNotFound,
+		"/api/w/draft/settings":                                 http.StatusNotFound,
+		"/api/types":                                            http.StatusNotFound,
+	} {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		var body apiError
+		if rec.Code != want || json.Unmarshal(rec.Body.Bytes(), &body) != nil || body.Error == "" {
+			t.Errorf("GET %s: status %d body %s, want %d with a JSON error", path, rec.Code, rec.Body.String(), want)
+		}
+	}
+
+	// The API is read-only: writes are refused and change nothing.
+	for _, method := range []string{http.MethodPost, http.MethodPut, http.MethodDelete} {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(method, "/api/w/draft/types/service/objects/"+testServiceID, strings.NewReader(`{"tier":"batch"}`)))
+		if rec.Code != http.StatusMethodNotAllowed {
+			t.Errorf("%s: status %d, want 405", method, rec.Code)
+		}
+	}
+	if obj, err := repo.ReadObject(ws, "service", testServiceID); err != nil {
+		t.Fatal(err)
+	} else if obj.Data["tier"] != "core" {
+		t.Errorf("tier = %v after refused writes, want core", obj.Data["tier"])
+	}
+}
@@ -0,0 +1,114 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Changelog lists the objects that differ between two commits, with the same
+// field-level entries as DiffWorkspace: "main" values are read at from and
+// "workspace" values at to. Objects whose fields are unchanged are omitted.
+// Results are sorted by type, then id.
+func (r *Repository) Changelog(from, to string) ([]ObjectDiff, error) {
+	for _, ref := range []string{from, to} {
+		if strings.HasPrefix(ref, "-") {
+			return nil, fmt.Errorf("invalid ref %q", ref)
+		}
+		if _, err := r.runGit(r.Root, "rev-parse", "--verify", "--quiet", ref+"^{commit}"); err != nil {
+			return nil, fmt.Errorf("unknown commit %q", ref)
+		}
+	}
+	out, err := r.runGit(r.Root, "diff", "--name-only", "--no-renames", from, to, "--", "data")
+	if err != nil {
+		return nil, err
+	}
+
+	diffs := make([]ObjectDiff, 0)
+	for _, line := range strings.Split(strings.TrimSpace(out), "\n") {
+		rel := filepath.ToSlash(strings.TrimSpace(line))
+		typeName, id, ok := parseDataObjectPath(rel)
+		if !ok {
+			continue
+		}
+		before, inFrom := r.readObjectAtRef(from, rel)
+		after, inTo := r.readObjectAtRef(to, rel)
+		if !inFrom && !inTo {
+			continue
+		}
+		diff := ObjectDiff{Type: typeName, ID: id, Status: "modified", Fields: computeDiffs(before, after)}
+		switch {
+		case !inFrom:
+			diff.Status = "added"
+		case !inTo:
+			diff.Status = "deleted"
+		}
+		if len(diff.Fields) == 0 && diff.Status == "modified" {
+			continue
+		}
+		diffs = append(diffs, diff)
+	}
+	sort.Slice(diffs, func(i, j int) bool {
+		if diffs[i].Type == diffs[j].Type {
+			return diffs[i].ID < diffs[j].ID
+		}
+		return diffs[i].Type < diffs[j].Type
+	})
+	return diffs, nil
+}
+
+// WriteChangelogText prints one section per type with its counts, followed by
+// the objects and changed fields in the format of WriteDiffText.
+func WriteChangelogText(w io.Writer, from, to string, diffs []ObjectDiff) error {
+	if len(diffs) == 0 {
+		_, err := fmt.Fprintf(w, "no object changes between %s and %s\n", from, to)
+		return err
+	}
+	if _, err := fmt.Fprintf(w, "changes from %s to %s\n", from, to); err != nil {
+		return err
+	}
+	for start := 0; start < len(diffs); {
+		end := start
+		counts := map[string]int{}
+		for end < len(diffs) && diffs[end].Type == diffs[start].Type {
+			counts[diffs[end].Status]++
+			end++
+		}
+		if _, err := fmt.Fprintf(w, "\n%s: %d added, %d modified, %d deleted\n", diffs[start].Type, counts["added"], counts["modified"], counts["deleted"]); err != nil {
+			return err
+		}
+		if err := WriteDiffText(w, diffs[start:end]); err != nil {
+			return err
+		}
+		start = end
+	}
+	return nil
+}
+
+// changelogDocument is the --json form of a changelog, grouped by type, then
+// id, like the export --diff-json document.
+type changelogDocument struct {
+	From  string                                  `json:"from"`
+	To    string                                  `json:"to"`
+	Types map[string]map[string]diffDocumentEntry `json:"types"`
+}
+
+func WriteChangelogJSON(w io.Writer, from, to string, diffs []ObjectDiff) error {
+	doc := changelogDocument{From: from, To: to, Types: map[string]map[string]diffDocumentEntry{}}
+	for _, d := range diffs {
+		if doc.Types[d.Type] == nil {
+			doc.Types[d.Type] = map[string]diffDocumentEntry{}
+		}
+		doc.Types[d.Type][d.ID] = diffDocumentEntry{Status: d.Status, Fields: d.Fields}
+	}
+	b, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return err
+	}
+	b = append(b, '\n')
+	_, err = w.Write(b)
+	return err
+}
@@ -0,0 +1,55 @@
+package app
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestChangelogAcrossCommits(t *testing.T) {
+	repo := newTestRepository(t)
+	start, err := repo.runGit(repo.Root, "rev-parse", "HEAD")
+	if err != nil {
+		t.Fatal(err)
+	}
+	from := strings.TrimSpace(start)
+
+	team := testObjectID(3)
+	writeTestFile(t, filepath.Join(repo.Root, "data", "team", team+".yaml"), "_id: "+team+"\n_type: team\ncode: DATA\nname: Data\n")
+	commitTestMain(t, repo, "add data team")
+	replaceTestFile(t, filepath.Join(repo.Root, "data", "service", testServiceID+".yaml"), "tier: edge", "tier: core")
+	commitTestMain(t, repo, "move gateway to core")
+
+	diffs, err := repo.Changelog(from, "HEAD")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(diffs) != 2 {
+		t.Fatalf("changelog = %+v, want the service and the new team", diffs)
+	}
+	service, added := diffs[0], diffs[1]
+	if service.Type != "service" || service.ID != testServiceID || service.Status != "modified" ||
+		len(service.Fields) != 1 || service.Fields[0] != (fieldDiff{Field: "tier", Main: "edge", Workspace: "core", Status: "modified"}) {
+		t.Errorf("service entry = %+v, want tier edge -> core", service)
+	}
+	if added.Type != "team" || added.ID != team || added.Status != "added" {
+		t.Errorf("team entry = %+v, want the added team", added)
+	}
+
+	var out strings.Builder
+	if err := WriteChangelogText(&out, from[:7], "HEAD", diffs); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"service: 0 added, 1 modified, 0 deleted", "team: 1 added, 0 modified, 0 deleted"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("changelog text =\n%s\nwant %q", out.String(), want)
+		}
+	}
+
+	if diffs, err := repo.Changelog("HEAD", "HEAD"); err != nil || len(diffs) != 0 {
+		t.Errorf("changelog of one commit = %+v, %v; want nothing", diffs, err)
+	}
+	if _, err := repo.Changelog("--all", "HEAD"); err == nil {
+		t.Error("changelog accepted an option as a ref")
+	}
+}
@@ -1,20 +1,44 @@
 package app
 
 import (
+	"bufio"
 	"context"
+	"encoding/json"
 	"errors"
 	"flag"
 	"fmt"
 	"io"
+	"net"
 	"os"
 	"path/filepath"
+	"runtime"
+	"runtime/debug"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+)
+
+const (
+	defaultWebAddr       = "127.0.0.1:8080"
+	defaultRemoteWebAddr = ":8080"
 )
 
 type commandConfig struct {
-	repository    string
-	workspaceRoot string
-	addr          string
-	outputDir     string
+	repository     string
+	workspaceRoot  string
+	addr           string
+	outputDir      string
+	strict         bool
+	exportSchemas  bool
+	workspace      string
+	mergeWebhook   string
+	readOnly       bool
+	exportPrune    bool
+	exportManifest bool
+	baseWorkspace  string
+	format         string
+	baseBranch     string
 }
 
 func Run(ctx context.Context, args []string, version string) error {
@@ -28,8 +52,7 @@ func Run(ctx context.Context, args []string, version string) error {
 		printRootHelp(os.Stdout)
 		return nil
 	case "version", "--version", "-v":
-		fmt.Println(version)
-		return nil
+		return runVersion(args[1:], version)
 	case "init":
 		return runInit(args[1:])
 	case "validate":
@@ -38,6 +61,28 @@ func Run(ctx context.Context, args []string, version string) error {
 		return runExport(args[1:])
 	case "web":
 		return runWeb(ctx, args[1:])
+	case "config":
+		return runConfig(args[1:])
+	case "graph":
+		return runGraph(args[1:])
+	case "import":
+		return runImport(args[1:])
+	case "create":
+		return runCreate(args[1:])
+	case "migrate":
+		return runMigrate(args[1:])
+	case "diff":
+		return runDiff(args[1:])
+	case "changelog":
+		return runChangelog(args[1:])
+	case "stats":
+		return runStats(args[1:])
+	case "snapshot":
+		return runSnapshot(args[1:])
+	case "prune":
+		return runPrune(args[1:])
+	case "merge":
+		return runMerge(args[1:])
 	default:
 		return fmt.Errorf("unknown command %q", args[0])
 	}
@@ -51,20 +96,41 @@ func defaultConfig() commandConfig {
 	}
 	addr := os.Getenv("WORKTREEFOUNDRY_ADDR")
 	if addr == "" {
-		addr = ":8080"
+		addr = defaultWebAddr
 	}
 	out := os.Getenv("WORKTREEFOUNDRY_OUT")
 	if out == "" {
 		out = "output"
 	}
 	return commandConfig{
-		repository:    repo,
-		workspaceRoot: workspaceRoot,
-		addr:          addr,
-		outputDir:     out,
+		repository:     repo,
+		workspaceRoot:  workspaceRoot,
+		addr:           addr,
+		outputDir:      out,
+		strict:         envBool("WORKTREEFOUNDRY_STRICT"),
+		exportSchemas:  envBool("WORKTREEFOUNDRY_EXPORT_SCHEMAS"),
+		workspace:      os.Getenv("WORKTREEFOUNDRY_WORKSPACE"),
+		mergeWebhook:   os.Getenv("WORKTREEFOUNDRY_MERGE_WEBHOOK"),
+		readOnly:       envBool("WORKTREEFOUNDRY_READ_ONLY"),
+		exportPrune:    envBool("WORKTREEFOUNDRY_EXPORT_PRUNE"),
+		exportManifest: envBool("WORKTREEFOUNDRY_EXPORT_MANIFEST"),
+		baseWorkspace:  os.Getenv("WORKTREEFOUNDRY_BASE_WORKSPACE"),
+		baseBranch:     os.Getenv("WORKTREEFOUNDRY_BASE_BRANCH"),
 	}
 }
 
+// formatEnv returns the default --format of command from
+// WORKTREEFOUNDRY_<COMMAND>_FORMAT, or fallback when it is unset. Commands
+// accept different formats, so each has its own variable.
+func formatEnv(command, fallback string) string {
+	return firstNonEmpty(os.Getenv("WORKTREEFOUNDRY_"+strings.ToUpper(command)+"_FORMAT"), fallback)
+}
+
+func envBool(name string) bool {
+	v, _ := strconv.ParseBool(os.Getenv(name))
+	return v
+}
+
 func runInit(args []string) error {
 	cfg := defaultConfig()
 	fs := flag.NewFlagSet("init", flag.ContinueOnError)
@@ -86,24 +152,114 @@ func runValidate(args []string) error {
 	fs := flag.NewFlagSet("validate", flag.ContinueOnError)
 	fs.SetOutput(io.Discard)
 	fs.StringVar(&cfg.repository, "repository", cfg.repository, "path to repository")
+	fs.StringVar(&cfg.baseBranch, "base-branch", cfg.baseBranch, "branch the main workspace tracks (overrides settings baseBranch)")
+	fs.StringVar(&cfg.format, "format", formatEnv("validate", "lines"), "output format: lines, table, or json")
+	allWorkspaces := fs.Bool("all-workspaces", envBool("WORKTREEFOUNDRY_VALIDATE_ALL_WORKSPACES"), "validate the merge preview of every workspace against main")
+	file := fs.String("file", os.Getenv("WORKTREEFOUNDRY_VALIDATE_FILE"), "validate a single object file against its type schema")
+	lint := fs.Bool("lint", envBool("WORKTREEFOUNDRY_VALIDATE_LINT"), "also report schema lint warnings")
+	typeName := fs.String("type", os.Getenv("WORKTREEFOUNDRY_VALIDATE_TYPE"), "validate only objects of this type")
+	objectID := fs.String("id", os.Getenv("WORKTREEFOUNDRY_VALIDATE_ID"), "with --type, validate only this object")
+	orphans := fs.Bool("orphans", envBool("WORKTREEFOUNDRY_VALIDATE_ORPHANS"), "list object files whose type has no schema")
+	index := fs.Bool("index", envBool("WORKTREEFOUNDRY_VALIDATE_INDEX"), "compare files tracked under data/ with the working tree")
+	exitOnFirst := fs.Bool("exit-on-first", envBool("WORKTREEFOUNDRY_VALIDATE_EXIT_ON_FIRST"), "stop at the first issue when validating the whole repository")
 	if err := fs.Parse(args); err != nil {
 		return usageError("validate", err)
 	}
 	if cfg.repository == "" {
 		return errors.New("--repository is required (or WORKTREEFOUNDRY_REPOSITORY)")
 	}
+	switch cfg.format {
+	case "", "lines", "table", "json":
+	default:
+		return usageError("validate", fmt.Errorf("unknown format %q", cfg.format))
+	}
 
-	repo, err := OpenRepository(cfg.repository, cfg.workspaceRoot)
+	repo, err := OpenRepository(cfg.repository, cfg.workspaceRoot, cfg.baseBranch)
 	if err != nil {
 		return err
 	}
-	result, err := ValidateRepository(repo.Root)
+	scopes := 0
+	for _, set := range []bool{*allWorkspaces, *file != "", *typeName != "", *orphans, *index} {
+		if set {
+			scopes++
+		}
+	}
+	if scopes > 1 {
+		return usageError("validate", errors.New("--all-workspaces, --file, --type, --orphans, and --index cannot be combined"))
+	}
+	if *objectID != "" && *typeName == "" {
+		return usageError("validate", errors.New("--id requires --type"))
+	}
+	if *exitOnFirst && scopes > 0 {
+		return usageError("validate", errors.New("--exit-on-first applies only to whole-repository validation"))
+	}
+	if *allWorkspaces {
+		return validateAllWorkspaces(os.Stdout, repo, cfg.format)
+	}
+	if *orphans {
+		found, err := repo.FindOrphans(repo.Root)
+		if err != nil {
+			return err
+		}
+		if err := writeOrphans(os.Stdout, found, cfg.format); err != nil {
+			return err
+		}
+		if len(found) > 0 {
+			return fmt.Errorf("found %d orphaned object(s)", len(found))
+		}
+		return nil
+	}
+	if *index {
+		found, err := repo.FindIndexDiscrepancies(repo.Root)
+		if err != nil {
+			return err
+		}
+		if err := writeIndexDiscrepancies(os.Stdout, found, cfg.format); err != nil {
+			return err
+		}
+		if len(found) > 0 {
+			return fmt.Errorf("found %d index discrepancy(ies)", len(found))
+		}
+		return nil
+	}
+	var result ValidationResult
+	switch {
+	case *file != "":
+		result, err = ValidateObjectFile(repo.Root, *file, repo.YAMLOptions)
+	case *typeName != "":
+		result, err = ValidateScope(repo.Root, *typeName, *objectID, repo.YAMLOptions)
+	default:
+		result, err = ValidateRepositoryWithOptions(repo.Root, ValidateOptions{ExitOnFirst: *exitOnFirst, YAML: repo.YAMLOptions})
+	}
 	if err != nil {
 		return err
 	}
+	if *lint {
+		if schemas, err := LoadSchemas(repo.Root); err == nil {
+			for _, warning := range LintSchemas(schemas) {
+				result.AddWarning(warning)
+			}
+		}
+	}
+	if cfg.format == "json" {
+		if err := writeValidationJSON(os.Stdout, newValidationReport(result)); err != nil {
+			return err
+		}
+		if !result.OK() {
+			return fmt.Errorf("validation failed with %d issue(s)", len(result.Issues))
+		}
+		return nil
+	}
+	for _, warning := range result.Warnings {
+		fmt.Println("warning: " + warning.String())
+	}
 	if !result.OK() {
-		for _, issue := range result.Issues {
-			fmt.Println(issue.String())
+		if cfg.format == "table" {
+			writeIssueTable(os.Stdout, result.Issues)
+		} else {
+			for _, issue := range result.Issues {
+				fmt.Println(issue.String())
+			}
 		}
 		return fmt.Errorf("validation failed with %d issue(s)", len(result.Issues))
 	}
@@ -111,52 +267,845 @@ func runValidate(args []string) error {
 	return nil
 }
 
+type workspaceReport struct {
+	Workspace string `json:"workspace"`
+	validationReport
+	Error     string          `json:"error,omitempty"`
+	Conflicts []conflictEntry `json:"conflicts,omitempty"`
+}
+
+type conflictEntry struct {
+	File  string `json:"file"`
+	Field string `json:"field"`
+}
+
+func validateAllWorkspaces(w io.Writer, repo *Repository, format string) error {
+	workspaces, err := repo.ListWorkspaces()
+	if err != nil {
+		return err
+	}
+	reports := make([]workspaceReport, 0, len(workspaces))
+	failed := 0
+	for _, ws := range workspaces {
+		report := workspaceReport{Workspace: ws.Name}
+		preview, err := repo.ValidateMergePreview(ws.Name)
+		if err != nil {
+			report.Error = err.Error()
+		} else {
+			report.validationReport = newValidationReport(preview.Validation)
+			for _, c := range preview.Conflicts {
+				report.Conflicts = append(report.Conflicts, conflictEntry{File: c.File, Field: c.Field})
+			}
+		}
+		report.OK = err == nil && preview.OK()
+		if !report.OK {
+			failed++
+		}
+		reports = append(reports, report)
+	}
+
+	if format == "json" {
+		if err := writeValidationJSON(w, struct {
+			OK         bool              `json:"ok"`
+			Workspaces []workspaceReport `json:"workspaces"`
+		}{OK: failed == 0, Workspaces: reports}); err != nil {
+			return err
+		}
+	} else {
+		writeWorkspaceReports(w, reports, format)
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d workspace(s) failed validation", failed, len(workspaces))
+	}
+	return nil
+}
+
+func writeWorkspaceReports(w io.Writer, reports []workspaceReport, format string) {
+	if len(reports) == 0 {
+		fmt.Fprintln(w, "no workspaces to validate")
+		return
+	}
+	for _, report := range reports {
+		switch {
+		case report.Error != "":
+			fmt.Fprintf(w, "FAIL %s: %s\n", report.Workspace, report.Error)
+		case len(report.Conflicts) > 0:
+			fmt.Fprintf(w, "FAIL %s: %d merge conflict(s)\n", report.Workspace, len(report.Conflicts))
+			for _, c := range report.Conflicts {
+				fmt.Fprintf(w, "  conflict %s field=%s\n", c.File, c.Field)
+			}
+		case !report.OK:
+			fmt.Fprintf(w, "FAIL %s: %d issue(s)\n", report.Workspace, report.IssueCount)
+			if format == "table" {
+				writeIssueTable(w, report.Issues)
+			} else {
+				for _, issue := range report.Issues {
+					fmt.Fprintf(w, "  %s\n", issue.String())
+				}
+			}
+		default:
+			fmt.Fprintf(w, "ok   %s\n", report.Workspace)
+		}
+	}
+	passed := 0
+	for _, report := range reports {
+		if report.OK {
+			passed++
+		}
+	}
+	if passed == len(reports) {
+		fmt.Fprintf(w, "all %d workspace(s) passed\n", len(reports))
+	}
+}
+
+type validationReport struct {
+	OK         bool              `json:"ok"`
+	IssueCount int               `json:"issueCount"`
+	Issues     []ValidationIssue `json:"issues"`
+	Warnings   []ValidationIssue `json:"warnings,omitempty"`
+}
+
+func newValidationReport(result ValidationResult) validationReport {
+	issues := result.Issues
+	if issues == nil {
+		issues = []ValidationIssue{}
+	}
+	return validationReport{OK: result.OK(), IssueCount: len(issues), Issues: issues, Warnings: result.Warnings}
+}
+
+func writeValidationJSON(w io.Writer, report any) error {
+	b, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return err
+	}
+	b = append(b, '\n')
+	_, err = w.Write(b)
+	return err
+}
+
+var issueStageOrder = map[string]int{"layout": 0, "parse": 1, "schema": 2, "config": 3, "constraints": 4}
+
+func writeIssueTable(w io.Writer, issues []ValidationIssue) {
+	sorted := append([]ValidationIssue(nil), issues...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return stageRank(sorted[i].Stage) < stageRank(sorted[j].Stage)
+	})
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "STAGE\tPATH\tFIELD\tMESSAGE")
+	for _, issue := range sorted {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", issue.Stage, firstNonEmpty(issue.Path, "-"), firstNonEmpty(issue.Field, "-"), issue.Message)
+	}
+	tw.Flush()
+}
+
+func stageRank(stage string) int {
+	if rank, ok := issueStageOrder[stage]; ok {
+		return rank
+	}
+	return len(issueStageOrder)
+}
+
 func runExport(args []string) error {
 	cfg := defaultConfig()
 	fs := flag.NewFlagSet("export", flag.ContinueOnError)
 	fs.SetOutput(io.Discard)
 	fs.StringVar(&cfg.repository, "repository", cfg.repository, "path to repository")
+	fs.StringVar(&cfg.baseBranch, "base-branch", cfg.baseBranch, "branch the main workspace tracks (overrides settings baseBranch)")
 	fs.StringVar(&cfg.outputDir, "out", cfg.outputDir, "output path (absolute or relative to repository)")
+	fs.StringVar(&cfg.workspaceRoot, "workspace-root", cfg.workspaceRoot, "workspace worktree root path (absolute or relative to repository)")
+	fs.StringVar(&cfg.workspace, "workspace", cfg.workspace, "export a workspace draft, applying its config/overrides.json")
+	fs.BoolVar(&cfg.exportSchemas, "schemas", cfg.exportSchemas, "also write JSON Schema artifacts under schemas/")
+	fs.BoolVar(&cfg.exportPrune, "prune", cfg.exportPrune, "remove exported JSON files for types that no longer exist")
+	fs.BoolVar(&cfg.exportManifest, "manifest", cfg.exportManifest, "write manifest.json with SHA-256 checksums of exported files")
+	var fieldSpecs []string
+	fs.Func("fields", "restrict a type's exported fields (type:field1,field2; repeatable)", func(v string) error {
+		fieldSpecs = append(fieldSpecs, v)
+		return nil
+	})
+	withIDs := fs.Bool("with-ids", false, "include _id in exported rows")
+	diffJSON := fs.Bool("diff-json", envBool("WORKTREEFOUNDRY_EXPORT_DIFF_JSON"), "write diff.json describing the workspace's object changes against main instead of exporting data")
+	fs.StringVar(&cfg.format, "format", formatEnv("export", ExportFormatJSON), "output format: json or csv")
+	layout := fs.String("layout", firstNonEmpty(os.Getenv("WORKTREEFOUNDRY_EXPORT_LAYOUT"), ExportLayoutTypes), "output layout: types (one array per type) or objects (one file per object)")
+	separator := fs.String("csv-separator", firstNonEmpty(os.Getenv("WORKTREEFOUNDRY_CSV_SEPARATOR"), defaultCSVSeparator), "separator joining array items in CSV cells")
 	if err := fs.Parse(args); err != nil {
 		return usageError("export", err)
 	}
+	switch cfg.format {
+	case ExportFormatJSON, ExportFormatCSV:
+	default:
+		return usageError("export", fmt.Errorf("unknown format %q", cfg.format))
+	}
+	switch *layout {
+	case ExportLayoutTypes:
+	case ExportLayoutObjects:
+		if cfg.format != ExportFormatJSON {
+			return usageError("export", errors.New("--layout objects requires --format json"))
+		}
+		if cfg.exportPrune {
+			return usageError("export", errors.New("--prune is not supported with --layout objects"))
+		}
+	default:
+		return usageError("export", fmt.Errorf("unknown layout %q", *layout))
+	}
 	if cfg.repository == "" {
 		return errors.New("--repository is required (or WORKTREEFOUNDRY_REPOSITORY)")
 	}
 
-	repo, err := OpenRepository(cfg.repository, cfg.workspaceRoot)
+	if len(fieldSpecs) == 0 {
+		// The variable holds every list the repeated flag would, separated
+		// by semicolons: service:name,tier;team:code.
+		for _, spec := range strings.Split(os.Getenv("WORKTREEFOUNDRY_EXPORT_FIELDS"), ";") {
+			if spec = strings.TrimSpace(spec); spec != "" {
+				fieldSpecs = append(fieldSpecs, spec)
+			}
+		}
+	}
+	fields, err := ParseExportFields(fieldSpecs)
+	if err != nil {
+		return usageError("export", err)
+	}
+	repo, err := OpenRepository(cfg.repository, cfg.workspaceRoot, cfg.baseBranch)
 	if err != nil {
 		return err
 	}
+	opts := ExportOptions{Fields: fields, WithIDs: *withIDs, Format: cfg.format, Separator: *separator, Layout: *layout, Files: repo.FileOptions}
 	outDir := cfg.outputDir
 	if !filepath.IsAbs(outDir) {
 		outDir = filepath.Join(repo.Root, outDir)
 	}
-	if err := ExportRepository(repo.Root, outDir); err != nil {
+	if *diffJSON {
+		if cfg.workspace == "" || cfg.workspace == "main" {
+			return usageError("export", errors.New("--diff-json requires --workspace other than main"))
+		}
+		path, err := ExportWorkspaceDiff(repo, cfg.workspace, outDir)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("diff export complete: %s\n", path)
+		return nil
+	}
+	source := repo.Root
+	if cfg.workspace != "" && cfg.workspace != "main" {
+		if !repo.WorkspaceExists(cfg.workspace) {
+			return fmt.Errorf("workspace %q not found", cfg.workspace)
+		}
+		source = repo.WorkspacePath(cfg.workspace)
+	}
+	if *layout == ExportLayoutObjects && cfg.exportSchemas {
+		if schemas, err := LoadSchemas(source); err == nil {
+			if _, ok := schemas["schemas"]; ok {
+				return fmt.Errorf("cannot export schemas: %s/ collides with the objects of type %q", filepath.Join(outDir, "schemas"), "schemas")
+			}
+		}
+	}
+	if source != repo.Root {
+		err = ExportWorkspace(source, outDir, opts)
+	} else {
+		err = ExportRepository(source, outDir, opts)
+	}
+	if err != nil {
 		return err
 	}
+	if cfg.exportSchemas {
+		if err := ExportSchemas(source, filepath.Join(outDir, "schemas"), repo.FileOptions); err != nil {
+			return err
+		}
+	}
+	if cfg.exportPrune {
+		type pruneTarget struct{ dir, ext string }
+		targets := []pruneTarget{{outDir, exportExtension(cfg.format)}}
+		if cfg.exportSchemas {
+			targets = append(targets, pruneTarget{filepath.Join(outDir, "schemas"), ".json"})
+		}
+		for _, target := range targets {
+			removed, err := PruneExport(source, target.dir, target.ext)
+			if err != nil {
+				return err
+			}
+			for _, path := range removed {
+				fmt.Printf("pruned: %s\n", path)
+			}
+		}
+	}
+	if cfg.exportManifest {
+		if err := WriteExportManifest(source, outDir, opts, cfg.exportSchemas); err != nil {
+			return err
+		}
+	}
 	fmt.Printf("export complete: %s\n", outDir)
 	return nil
 }
 
+func runConfig(args []string) error {
+	if len(args) == 0 {
+		return errors.New(commandUsage("config"))
+	}
+	switch args[0] {
+	case "reset-ui":
+		return runConfigResetUI(args[1:])
+	default:
+		return fmt.Errorf("unknown config command %q\n\n%s", args[0], commandUsage("config"))
+	}
+}
+
+func runConfigResetUI(args []string) error {
+	cfg := defaultConfig()
+	fs := flag.NewFlagSet("config reset-ui", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	fs.StringVar(&cfg.repository, "repository", cfg.repository, "path to repository")
+	fs.StringVar(&cfg.baseBranch, "base-branch", cfg.baseBranch, "branch the main workspace tracks (overrides settings baseBranch)")
+	fs.StringVar(&cfg.workspaceRoot, "workspace-root", cfg.workspaceRoot, "workspace worktree root path (absolute or relative to repository)")
+	fs.StringVar(&cfg.workspace, "workspace", cfg.workspace, "workspace draft to update instead of the repository root")
+	yes := fs.Bool("yes", false, "overwrite without confirmation")
+	if err := fs.Parse(args); err != nil {
+		return usageError("config", err)
+	}
+	if cfg.repository == "" {
+		return errors.New("--repository is required (or WORKTREEFOUNDRY_REPOSITORY)")
+	}
+
+	repo, err := OpenRepository(cfg.repository, cfg.workspaceRoot, cfg.baseBranch)
+	if err != nil {
+		return err
+	}
+	target, err := resolveTargetPath(repo, cfg.workspace)
+	if err != nil {
+		return err
+	}
+	schemas, err := LoadSchemas(target)
+	if err != nil {
+		return err
+	}
+
+	path := filepath.Join(target, "config", "ui.json")
+	if _, err := os.Stat(path); err == nil && !*yes {
+		fmt.Printf("Overwrite %s with defaults? [y/N] ", path)
+		answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+		answer = strings.ToLower(strings.TrimSpace(answer))
+		if answer != "y" && answer != "yes" {
+			return errors.New("reset cancelled")
+		}
+	}
+	if err := repo.SaveUIConfig(target, DefaultUIConfig(repo.Root, schemas)); err != nil {
+		return err
+	}
+	fmt.Printf("ui config reset: %s\n", path)
+	return nil
+}
+
+func runGraph(args []string) error {
+	cfg := defaultConfig()
+	fs := flag.NewFlagSet("graph", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	fs.StringVar(&cfg.repository, "repository", cfg.repository, "path to repository")
+	fs.StringVar(&cfg.baseBranch, "base-branch", cfg.baseBranch, "branch the main workspace tracks (overrides settings baseBranch)")
+	fs.StringVar(&cfg.format, "format", formatEnv("graph", "dot"), "output format: dot or json")
+	if err := fs.Parse(args); err != nil {
+		return usageError("graph", err)
+	}
+	if cfg.repository == "" {
+		return errors.New("--repository is required (or WORKTREEFOUNDRY_REPOSITORY)")
+	}
+
+	repo, err := OpenRepository(cfg.repository, cfg.workspaceRoot, cfg.baseBranch)
+	if err != nil {
+		return err
+	}
+	g, err := BuildRelationshipGraph(repo.Root)
+	if err != nil {
+		return err
+	}
+	switch cfg.format {
+	case "dot":
+		return WriteGraphDOT(os.Stdout, g)
+	case "json":
+		return WriteGraphJSON(os.Stdout, g)
+	default:
+		return usageError("graph", fmt.Errorf("unknown format %q", cfg.format))
+	}
+}
+
+type versionInfo struct {
+	Version   string `json:"version"`
+	GoVersion string `json:"goVersion"`
+	Commit    string `json:"commit,omitempty"`
+}
+
+func runVersion(args []string, version string) error {
+	fs := flag.NewFlagSet("version", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	asJSON := fs.Bool("json", false, "print version, Go version, and commit as JSON")
+	if err := fs.Parse(args); err != nil {
+		return usageError("version", err)
+	}
+	return writeVersion(os.Stdout, version, *asJSON)
+}
+
+// writeVersion prints version, or with asJSON the version, Go version, and
+// VCS commit from the build info.
+func writeVersion(w io.Writer, version string, asJSON bool) error {
+	if !asJSON {
+		_, err := fmt.Fprintln(w, version)
+		return err
+	}
+	info := versionInfo{Version: version, GoVersion: runtime.Version()}
+	if build, ok := debug.ReadBuildInfo(); ok {
+		info.GoVersion = build.GoVersion
+		for _, setting := range build.Settings {
+			if setting.Key == "vcs.revision" {
+				info.Commit = setting.Value
+			}
+		}
+	}
+	b, err := json.MarshalIndent(info, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(w, string(b))
+	return err
+}
+
+func runDiff(args []string) error {
+	cfg := defaultConfig()
+	fs := flag.NewFlagSet("diff", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	fs.StringVar(&cfg.repository, "repository", cfg.repository, "path to repository")
+	fs.StringVar(&cfg.baseBranch, "base-branch", cfg.baseBranch, "branch the main workspace tracks (overrides settings baseBranch)")
+	fs.StringVar(&cfg.workspaceRoot, "workspace-root", cfg.workspaceRoot, "workspace worktree root path (absolute or relative to repository)")
+	fs.StringVar(&cfg.workspace, "workspace", cfg.workspace, "workspace to compare against main")
+	fs.StringVar(&cfg.format, "format", formatEnv("diff", "lines"), "output format: lines or json")
+	if err := fs.Parse(args); err != nil {
+		return usageError("diff", err)
+	}
+	if cfg.repository == "" {
+		return errors.New("--repository is required (or WORKTREEFOUNDRY_REPOSITORY)")
+	}
+	if cfg.workspace == "" || cfg.workspace == "main" {
+		return usageError("diff", errors.New("--workspace is required"))
+	}
+	if cfg.format != "lines" && cfg.format != "json" {
+		return usageError("diff", fmt.Errorf("unknown format %q", cfg.format))
+	}
+
+	repo, err := OpenRepository(cfg.repository, cfg.workspaceRoot, cfg.baseBranch)
+	if err != nil {
+		return err
+	}
+	diffs, err := repo.DiffWorkspace(cfg.workspace)
+	if err != nil {
+		return err
+	}
+	if cfg.format == "json" {
+		return WriteDiffJSON(os.Stdout, diffs)
+	}
+	return WriteDiffText(os.Stdout, diffs)
+}
+
+func runChangelog(args []string) error {
+	cfg := defaultConfig()
+	fs := flag.NewFlagSet("changelog", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	fs.StringVar(&cfg.repository, "repository", cfg.repository, "path to repository")
+	fs.StringVar(&cfg.baseBranch, "base-branch", cfg.baseBranch, "branch the main workspace tracks (overrides settings baseBranch)")
+	from := fs.String("from", "", "commit or ref to compare from")
+	to := fs.String("to", "", "commit or ref to compare to")
+	asJSON := fs.Bool("json", false, "print the changelog as JSON")
+	if err := fs.Parse(args); err != nil {
+		return usageError("changelog", err)
+	}
+	if cfg.repository == "" {
+		return errors.New("--repository is required (or WORKTREEFOUNDRY_REPOSITORY)")
+	}
+	if *from == "" || *to == "" {
+		return usageError("changelog", errors.New("--from and --to are required"))
+	}
+
+	repo, err := OpenRepository(cfg.repository, cfg.workspaceRoot, cfg.baseBranch)
+	if err != nil {
+		return err
+	}
+	diffs, err := repo.Changelog(*from, *to)
+	if err != nil {
+		return err
+	}
+	if *asJSON {
+		return WriteChangelogJSON(os.Stdout, *from, *to, diffs)
+	}
+	return WriteChangelogText(os.Stdout, *from, *to, diffs)
+}
+
+func runStats(args []string) error {
+	cfg := defaultConfig()
+	fs := flag.NewFlagSet("stats", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	fs.StringVar(&cfg.repository, "repository", cfg.repository, "path to repository")
+	fs.StringVar(&cfg.baseBranch, "base-branch", cfg.baseBranch, "branch the main workspace tracks (overrides settings baseBranch)")
+	fs.StringVar(&cfg.workspaceRoot, "workspace-root", cfg.workspaceRoot, "workspace worktree root path (absolute or relative to repository)")
+	fs.StringVar(&cfg.format, "format", formatEnv("stats", "table"), "output format: table or json")
+	if err := fs.Parse(args); err != nil {
+		return usageError("stats", err)
+	}
+	if cfg.repository == "" {
+		return errors.New("--repository is required (or WORKTREEFOUNDRY_REPOSITORY)")
+	}
+	if cfg.format != "table" && cfg.format != "json" {
+		return usageError("stats", fmt.Errorf("unknown format %q", cfg.format))
+	}
+
+	repo, err := OpenRepository(cfg.repository, cfg.workspaceRoot, cfg.baseBranch)
+	if err != nil {
+		return err
+	}
+	stats, err := repo.CollectStats()
+	if err != nil {
+		return err
+	}
+	if cfg.format == "json" {
+		if err := WriteStatsJSON(os.Stdout, stats); err != nil {
+			return err
+		}
+	} else {
+		WriteStatsTable(os.Stdout, stats)
+	}
+	if stats.Issues.Total > 0 {
+		return fmt.Errorf("found %d validation issue(s)", stats.Issues.Total)
+	}
+	return nil
+}
+
+func runSnapshot(args []string) error {
+	cfg := defaultConfig()
+	fs := flag.NewFlagSet("snapshot", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	fs.StringVar(&cfg.repository, "repository", cfg.repository, "path to repository")
+	fs.StringVar(&cfg.baseBranch, "base-branch", cfg.baseBranch, "branch the main workspace tracks (overrides settings baseBranch)")
+	list := fs.Bool("list", false, "list snapshots, newest first")
+	restore := fs.String("restore", "", "reset main to this snapshot tag")
+	if err := fs.Parse(args); err != nil {
+		return usageError("snapshot", err)
+	}
+	if cfg.repository == "" {
+		return errors.New("--repository is required (or WORKTREEFOUNDRY_REPOSITORY)")
+	}
+	if *list && *restore != "" {
+		return usageError("snapshot", errors.New("--list and --restore cannot be combined"))
+	}
+
+	repo, err := OpenRepository(cfg.repository, cfg.workspaceRoot, cfg.baseBranch)
+	if err != nil {
+		return err
+	}
+	switch {
+	case *list:
+		snapshots, err := repo.ListSnapshots()
+		if err != nil {
+			return err
+		}
+		if len(snapshots) == 0 {
+			fmt.Println("no snapshots")
+			return nil
+		}
+		tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		for _, s := range snapshots {
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", s.Tag, s.Commit, s.Date, s.Subject)
+		}
+		return tw.Flush()
+	case *restore != "":
+		previous, err := repo.RestoreSnapshot(*restore)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("restored %s to %s (previous state saved as %s)\n", repo.BaseBranch, *restore, previous)
+		return nil
+	default:
+		tag, err := repo.CreateSnapshot()
+		if err != nil {
+			return err
+		}
+		fmt.Println(tag)
+		return nil
+	}
+}
+
+func runMerge(args []string) error {
+	cfg := defaultConfig()
+	fs := flag.NewFlagSet("merge", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	fs.StringVar(&cfg.repository, "repository", cfg.repository, "path to repository")
+	fs.StringVar(&cfg.baseBranch, "base-branch", cfg.baseBranch, "branch the main workspace tracks (overrides settings baseBranch)")
+	fs.StringVar(&cfg.workspaceRoot, "workspace-root", cfg.workspaceRoot, "workspace worktree root path (absolute or relative to repository)")
+	fs.StringVar(&cfg.workspace, "workspace", cfg.workspace, "workspace to merge into main")
+	fs.StringVar(&cfg.mergeWebhook, "merge-webhook", cfg.mergeWebhook, "URL notified with a JSON POST after a successful merge")
+	push := fs.Bool("push", envBool("WORKTREEFOUNDRY_MERGE_PUSH"), "push main to origin after merging; the workspace is kept if the push fails")
+	sync := fs.Bool("sync", envBool("WORKTREEFOUNDRY_MERGE_SYNC"), "fast-forward main from origin before merging")
+	dryRun := fs.Bool("dry-run", envBool("WORKTREEFOUNDRY_MERGE_DRY_RUN"), "report changed files, conflicts, and validation issues without merging")
+	fs.StringVar(&cfg.format, "format", formatEnv("merge", "lines"), "dry-run output format: lines or json")
+	exportConflicts := fs.String("export-conflicts", "", "write the conflicts of a blocked merge to this JSON file")
+	applyResolutions := fs.String("apply-resolutions", "", "merge with the resolutions chosen in an edited conflict file")
+	if err := fs.Parse(args); err != nil {
+		return usageError("merge", err)
+	}
+	if cfg.repository == "" {
+		return errors.New("--repository is required (or WORKTREEFOUNDRY_REPOSITORY)")
+	}
+	if cfg.workspace == "" || cfg.workspace == "main" {
+		return usageError("merge", errors.New("--workspace is required"))
+	}
+	if *dryRun && (*push || *sync) {
+		return usageError("merge", errors.New("--dry-run cannot be combined with --push or --sync"))
+	}
+	if cfg.format != "lines" && cfg.format != "json" {
+		return usageError("merge", fmt.Errorf("unknown format %q", cfg.format))
+	}
+
+	repo, err := OpenRepository(cfg.repository, cfg.workspaceRoot, cfg.baseBranch)
+	if err != nil {
+		return err
+	}
+	var resolutions, manual map[string]string
+	if *applyResolutions != "" {
+		resolutions, manual, err = ReadConflictFile(*applyResolutions, cfg.workspace)
+		if err != nil {
+			return err
+		}
+	}
+	if *dryRun {
+		return mergeDryRun(os.Stdout, repo, cfg.workspace, cfg.format, resolutions, manual, *exportConflicts)
+	}
+	if cfg.mergeWebhook != "" {
+		repo.MergeWebhook = cfg.mergeWebhook
+	}
+	result, err := repo.MergeWorkspace(cfg.workspace, resolutions, manual, MergeOptions{Push: *push, Sync: *sync})
+	if result.SyncOutput != "" {
+		fmt.Println(result.SyncOutput)
+	}
+	if result.PushOutput != "" {
+		fmt.Println(result.PushOutput)
+	}
+	if err != nil {
+		return err
+	}
+	if len(result.Conflicts) > 0 {
+		for _, c := range result.Conflicts {
+			fmt.Printf("conflict: %s (%s)\n", c.File, c.Field)
+		}
+		if *exportConflicts != "" {
+			if err := repo.WriteConflictFile(*exportConflicts, cfg.workspace, result.Conflicts); err != nil {
+				return err
+			}
+			return fmt.Errorf("merge blocked by %d conflict(s); choose resolutions in %s and rerun with --apply-resolutions", len(result.Conflicts), *exportConflicts)
+		}
+		return fmt.Errorf("merge blocked by %d conflict(s); resolve them in the web UI or with --export-conflicts", len(result.Conflicts))
+	}
+	if !result.Merged {
+		fmt.Println(result.Message)
+		return nil
+	}
+	for _, rel := range result.Changed {
+		fmt.Println("merged: " + rel)
+	}
+	fmt.Printf("%s: %d file(s)\n", result.Message, result.MergedFiles)
+	return nil
+}
+
+type mergeDryRunReport struct {
+	Workspace string             `json:"workspace"`
+	OK        bool               `json:"ok"`
+	Changed   []string           `json:"changed"`
+	Conflicts []mergeDryConflict `json:"conflicts"`
+	validationReport
+}
+
+// mergeDryConflict is one conflict of a dry run. Key is the name that
+// resolutions use, as in the web form's resolve.<key> fields.
+type mergeDryConflict struct {
+	File      string `json:"file"`
+	Field     string `json:"field"`
+	Key       string `json:"key"`
+	Base      any    `json:"base"`
+	Main      any    `json:"main"`
+	Workspace any    `json:"workspace"`
+}
+
+// mergeDryRun merges the workspace into a temporary copy of main, applying
+// any resolutions, and reports the outcome without touching main or the
+// workspace. It fails when conflicts remain or the merged result has
+// validation issues; remaining conflicts are written to exportPath if set.
+func mergeDryRun(w io.Writer, repo *Repository, workspace, format string, resolutions, manual map[string]string, exportPath string) error {
+	preview, err := repo.ValidateMergeResult(workspace, resolutions, manual)
+	if err != nil {
+		return err
+	}
+	if exportPath != "" && len(preview.Conflicts) > 0 {
+		if err := repo.WriteConflictFile(exportPath, workspace, preview.Conflicts); err != nil {
+			return err
+		}
+	}
+	report := mergeDryRunReport{
+		Workspace:        workspace,
+		OK:               preview.OK(),
+		Changed:          preview.Changed,
+		Conflicts:        make([]mergeDryConflict, 0, len(preview.Conflicts)),
+		validationReport: newValidationReport(preview.Validation),
+	}
+	if report.Changed == nil {
+		report.Changed = []string{}
+	}
+	for _, c := range preview.Conflicts {
+		report.Conflicts = append(report.Conflicts, mergeDryConflict{File: c.File, Field: c.Field, Key: c.Key, Base: c.Base, Main: c.Main, Workspace: c.Workspace})
+	}
+
+	if format == "json" {
+		if err := writeValidationJSON(w, report); err != nil {
+			return err
+		}
+	} else {
+		for _, rel := range report.Changed {
+			fmt.Fprintln(w, "changed: "+rel)
+		}
+		for _, c := range report.Conflicts {
+			if c.Field == wholeFileField {
+				fmt.Fprintf(w, "conflict: %s (entire file)\n", c.File)
+				continue
+			}
+			fmt.Fprintf(w, "conflict: %s (%s)\n  base: %s\n  main: %s\n  workspace: %s\n", c.File, c.Field, valueToText(c.Base), valueToText(c.Main), valueToText(c.Workspace))
+		}
+		for _, issue := range report.Issues {
+			fmt.Fprintln(w, issue.String())
+		}
+	}
+	switch {
+	case len(report.Conflicts) > 0:
+		return fmt.Errorf("dry run: merge would be blocked by %d conflict(s)", len(report.Conflicts))
+	case report.IssueCount > 0:
+		return fmt.Errorf("dry run: merged result would have %d validation issue(s)", report.IssueCount)
+	}
+	if format != "json" {
+		fmt.Fprintf(w, "dry run: %d file(s) would merge cleanly\n", len(report.Changed))
+	}
+	return nil
+}
+
+func runPrune(args []string) error {
+	cfg := defaultConfig()
+	fs := flag.NewFlagSet("prune", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	fs.StringVar(&cfg.repository, "repository", cfg.repository, "path to repository")
+	fs.StringVar(&cfg.baseBranch, "base-branch", cfg.baseBranch, "branch the main workspace tracks (overrides settings baseBranch)")
+	fs.StringVar(&cfg.workspaceRoot, "workspace-root", cfg.workspaceRoot, "workspace worktree root path (absolute or relative to repository)")
+	fs.StringVar(&cfg.workspace, "workspace", cfg.workspace, "workspace draft to prune")
+	confirm := fs.Bool("confirm", false, "delete the orphaned object files")
+	if err := fs.Parse(args); err != nil {
+		return usageError("prune", err)
+	}
+	if cfg.repository == "" {
+		return errors.New("--repository is required (or WORKTREEFOUNDRY_REPOSITORY)")
+	}
+	if cfg.workspace == "" || cfg.workspace == "main" {
+		return usageError("prune", errors.New("--workspace is required; prune never writes to main (use validate --orphans to list orphans there)"))
+	}
+
+	repo, err := OpenRepository(cfg.repository, cfg.workspaceRoot, cfg.baseBranch)
+	if err != nil {
+		return err
+	}
+	target, err := resolveTargetPath(repo, cfg.workspace)
+	if err != nil {
+		return err
+	}
+	orphans, err := repo.FindOrphans(target)
+	if err != nil {
+		return err
+	}
+	if len(orphans) == 0 {
+		fmt.Println("no orphaned objects")
+		return nil
+	}
+	if !*confirm {
+		if err := writeOrphans(os.Stdout, orphans, "lines"); err != nil {
+			return err
+		}
+		return fmt.Errorf("%d orphaned object(s) found; re-run with --confirm to delete them", len(orphans))
+	}
+	for _, o := range orphans {
+		if err := DeleteObject(target, o.Type, o.ID); err != nil {
+			return err
+		}
+		fmt.Printf("removed: %s\n", o.Path)
+	}
+	return nil
+}
+
 func runWeb(ctx context.Context, args []string) error {
 	cfg := defaultConfig()
 	fs := flag.NewFlagSet("web", flag.ContinueOnError)
 	fs.SetOutput(io.Discard)
 	fs.StringVar(&cfg.repository, "repository", cfg.repository, "path to repository")
+	fs.StringVar(&cfg.baseBranch, "base-branch", cfg.baseBranch, "branch the main workspace tracks (overrides settings baseBranch)")
 	fs.StringVar(&cfg.workspaceRoot, "workspace-root", cfg.workspaceRoot, "workspace worktree root path (absolute or relative to repository)")
 	fs.StringVar(&cfg.addr, "addr", cfg.addr, "bind address")
+	fs.BoolVar(&cfg.strict, "strict", cfg.strict, "fail to start when main has no schemas")
+	fs.StringVar(&cfg.mergeWebhook, "merge-webhook", cfg.mergeWebhook, "URL notified with a JSON POST after a successful merge")
+	fs.BoolVar(&cfg.readOnly, "read-only", cfg.readOnly, "disable all writes in every workspace")
+	fs.StringVar(&cfg.baseWorkspace, "base-workspace", cfg.baseWorkspace, "workspace opened at / (falls back to main when missing)")
+	allowRemote := fs.Bool("allow-remote", envBool("WORKTREEFOUNDRY_ALLOW_REMOTE"), "bind all interfaces when --addr is not set")
 	if err := fs.Parse(args); err != nil {
 		return usageError("web", err)
 	}
 	if cfg.repository == "" {
 		return errors.New("--repository is required (or WORKTREEFOUNDRY_REPOSITORY)")
 	}
-	repo, err := OpenRepository(cfg.repository, cfg.workspaceRoot)
+	if *allowRemote && cfg.addr == defaultWebAddr {
+		cfg.addr = defaultRemoteWebAddr
+	}
+	warnRemoteBind(os.Stderr, cfg.addr)
+	repo, err := OpenRepository(cfg.repository, cfg.workspaceRoot, cfg.baseBranch)
 	if err != nil {
 		return err
 	}
-	return StartWebServer(ctx, repo, cfg.addr)
+	if cfg.mergeWebhook != "" {
+		repo.MergeWebhook = cfg.mergeWebhook
+	}
+	if err := checkWebSchemas(os.Stderr, repo.Root, cfg.strict); err != nil {
+		return err
+	}
+	if cfg.baseWorkspace != "" && cfg.baseWorkspace != "main" && !repo.WorkspaceExists(cfg.baseWorkspace) {
+		fmt.Fprintf(os.Stderr, "warning: base workspace %q not found; / opens main until it exists\n", cfg.baseWorkspace)
+	}
+	return StartWebServer(ctx, repo, cfg.addr, WebOptions{ReadOnly: cfg.readOnly, BaseWorkspace: cfg.baseWorkspace})
+}
+
+// checkWebSchemas reports a missing or empty schema directory on main before
+// the server starts. With strict enabled the condition is fatal.
+func checkWebSchemas(w io.Writer, root string, strict bool) error {
+	if _, err := LoadSchemas(root); err != nil {
+		if strict {
+			return fmt.Errorf("%w (run worktreefoundry init to create sample schemas)", err)
+		}
+		fmt.Fprintf(w, "warning: %v (run worktreefoundry init to create sample schemas)\n", err)
+	}
+	return nil
+}
+
+// warnRemoteBind prints a warning when addr listens beyond the loopback
+// interface, since the UI has no authentication.
+func warnRemoteBind(w io.Writer, addr string) {
+	if isLoopbackAddr(addr) {
+		return
+	}
+	fmt.Fprintf(w, "warning: listening on %s exposes the UI beyond this machine; it has no authentication\n", addr)
+}
+
+func isLoopbackAddr(addr string) bool {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return false
+	}
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
 }
 
 func usageError(command string, err error) error {
@@ -170,30 +1119,351 @@ Usage:
   worktreefoundry <command> [flags]
 
 Commands:
-  init      Initialize a repository with sample schema/data
-  validate  Validate repository layout, objects, schema, and constraints
-  export    Export deterministic JSON artifacts under output/
-  web       Run the local web UI
-  version   Print version
+  init       Initialize a repository with sample schema/data
+  validate   Validate repository layout, objects, schema, and constraints
+  export     Export deterministic JSON or CSV artifacts under output/
+  web        Run the local web UI
+  config     Manage repository configuration (reset-ui)
+  graph      Print type relationships from foreign keys (dot or json)
+  import     Import objects of one type from a JSON array or CSV file
+  create     Create one object from --set key=value pairs
+  migrate    Migrate schema fields (field-type)
+  diff       Show field-level changes of a workspace compared to main
+  changelog  Summarize object changes between two commits
+  stats      Print object, workspace, constraint, and validation issue counts
+  snapshot   Tag main as a snapshot, list snapshots, or restore one
+  prune      Delete object files whose type has no schema
+  merge      Merge a workspace into main, optionally syncing from and pushing to origin
+  version    Print version (--json adds Go version and commit)
 
 Environment variables:
   WORKTREEFOUNDRY_REPOSITORY
   WORKTREEFOUNDRY_WORKSPACE_ROOT
   WORKTREEFOUNDRY_ADDR
   WORKTREEFOUNDRY_OUT
+  WORKTREEFOUNDRY_STRICT
+  WORKTREEFOUNDRY_EXPORT_SCHEMAS
+  WORKTREEFOUNDRY_WORKSPACE
+  WORKTREEFOUNDRY_MERGE_WEBHOOK
+  WORKTREEFOUNDRY_READ_ONLY
+  WORKTREEFOUNDRY_EXPORT_PRUNE
+  WORKTREEFOUNDRY_EXPORT_MANIFEST
+  WORKTREEFOUNDRY_EXPORT_FIELDS
+  WORKTREEFOUNDRY_EXPORT_DIFF_JSON
+  WORKTREEFOUNDRY_EXPORT_LAYOUT
+  WORKTREEFOUNDRY_VALIDATE_EXIT_ON_FIRST
+  WORKTREEFOUNDRY_VALIDATE_ALL_WORKSPACES
+  WORKTREEFOUNDRY_VALIDATE_FILE
+  WORKTREEFOUNDRY_VALIDATE_TYPE
+  WORKTREEFOUNDRY_VALIDATE_ID
+  WORKTREEFOUNDRY_VALIDATE_LINT
+  WORKTREEFOUNDRY_VALIDATE_ORPHANS
+  WORKTREEFOUNDRY_VALIDATE_INDEX
+  WORKTREEFOUNDRY_MERGE_PUSH
+  WORKTREEFOUNDRY_MERGE_SYNC
+  WORKTREEFOUNDRY_MERGE_DRY_RUN
+  WORKTREEFOUNDRY_BASE_WORKSPACE
+  WORKTREEFOUNDRY_VALIDATE_FORMAT
+  WORKTREEFOUNDRY_EXPORT_FORMAT
+  WORKTREEFOUNDRY_GRAPH_FORMAT
+  WORKTREEFOUNDRY_DIFF_FORMAT
+  WORKTREEFOUNDRY_STATS_FORMAT
+  WORKTREEFOUNDRY_MERGE_FORMAT
+  WORKTREEFOUNDRY_FILE_MODE
+  WORKTREEFOUNDRY_DIR_MODE
+  WORKTREEFOUNDRY_CSV_SEPARATOR
+  WORKTREEFOUNDRY_ALLOW_REMOTE
+  WORKTREEFOUNDRY_YAML_COMMENTS
+  WORKTREEFOUNDRY_YAML_QUOTES
+  WORKTREEFOUNDRY_BASE_BRANCH
 `)
 }
 
+func runImport(args []string) error {
+	cfg := defaultConfig()
+	fs := flag.NewFlagSet("import", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	fs.StringVar(&cfg.repository, "repository", cfg.repository, "path to repository")
+	fs.StringVar(&cfg.baseBranch, "base-branch", cfg.baseBranch, "branch the main workspace tracks (overrides settings baseBranch)")
+	fs.StringVar(&cfg.workspaceRoot, "workspace-root", cfg.workspaceRoot, "workspace worktree root path (absolute or relative to repository)")
+	fs.StringVar(&cfg.workspace, "workspace", cfg.workspace, "workspace draft to import into")
+	typeName := fs.String("type", "", "object type to import")
+	file := fs.String("file", "", "JSON array or CSV file of objects to import (- for stdin)")
+	format := fs.String("format", "", "input format: json or csv (default from the file extension)")
+	upsert := fs.Bool("upsert", false, "update objects whose _id already exists instead of rejecting the row")
+	strict := fs.Bool("strict", false, "abort the whole import if any row is rejected")
+	if err := fs.Parse(args); err != nil {
+		return usageError("import", err)
+	}
+	if cfg.repository == "" {
+		return errors.New("--repository is required (or WORKTREEFOUNDRY_REPOSITORY)")
+	}
+	if *typeName == "" || *file == "" {
+		return usageError("import", errors.New("--type and --file are required"))
+	}
+	if cfg.workspace == "" || cfg.workspace == "main" {
+		return usageError("import", errors.New("--workspace is required; imports never write to main"))
+	}
+	if *format != "" && *format != ImportFormatJSON && *format != ImportFormatCSV {
+		return usageError("import", fmt.Errorf("unknown format %q", *format))
+	}
+
+	repo, err := OpenRepository(cfg.repository, cfg.workspaceRoot, cfg.baseBranch)
+	if err != nil {
+		return err
+	}
+	target, err := resolveTargetPath(repo, cfg.workspace)
+	if err != nil {
+		return err
+	}
+	var b []byte
+	if *file == "-" {
+		b, err = io.ReadAll(os.Stdin)
+	} else {
+		b, err = os.ReadFile(*file)
+	}
+	if err != nil {
+		return err
+	}
+	rows, err := ParseImportRows(*file, *format, b)
+	if err != nil {
+		return err
+	}
+
+	result, issues, err := repo.ImportObjects(target, *typeName, rows, ImportOptions{Upsert: *upsert, Strict: *strict})
+	for _, issue := range issues.Issues {
+		fmt.Println(issue.String())
+	}
+	if err != nil {
+		return err
+	}
+	for _, rejected := range result.Rejected {
+		fmt.Println("rejected " + rejected.String())
+	}
+	fmt.Printf("import complete: %d created, %d updated, %d rejected\n", len(result.Created), len(result.Updated), len(result.Rejected))
+	return nil
+}
+
+func runMigrate(args []string) error {
+	if len(args) == 0 {
+		return errors.New(commandUsage("migrate"))
+	}
+	switch args[0] {
+	case "field-type":
+		return runMigrateFieldType(args[1:])
+	default:
+		return fmt.Errorf("unknown migrate command %q\n\n%s", args[0], commandUsage("migrate"))
+	}
+}
+
+func runMigrateFieldType(args []string) error {
+	cfg := defaultConfig()
+	fs := flag.NewFlagSet("migrate field-type", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	fs.StringVar(&cfg.repository, "repository", cfg.repository, "path to repository")
+	fs.StringVar(&cfg.baseBranch, "base-branch", cfg.baseBranch, "branch the main workspace tracks (overrides settings baseBranch)")
+	fs.StringVar(&cfg.workspaceRoot, "workspace-root", cfg.workspaceRoot, "workspace worktree root path (absolute or relative to repository)")
+	fs.StringVar(&cfg.workspace, "workspace", cfg.workspace, "workspace draft to migrate")
+	typeName := fs.String("type", "", "object type whose schema changes")
+	field := fs.String("field", "", "field to migrate")
+	property := fs.String("property", "", "new JSON schema property for the field")
+	force := fs.Bool("force", false, "apply the schema change even if objects would break")
+	if err := fs.Parse(args); err != nil {
+		return usageError("migrate", err)
+	}
+	if cfg.repository == "" {
+		return errors.New("--repository is required (or WORKTREEFOUNDRY_REPOSITORY)")
+	}
+	if *typeName == "" || *field == "" || *property == "" {
+		return usageError("migrate", errors.New("--type, --field, and --property are required"))
+	}
+	if cfg.workspace == "" || cfg.workspace == "main" {
+		return usageError("migrate", errors.New("--workspace is required; migrations never write to main"))
+	}
+
+	repo, err := OpenRepository(cfg.repository, cfg.workspaceRoot, cfg.baseBranch)
+	if err != nil {
+		return err
+	}
+	target, err := resolveTargetPath(repo, cfg.workspace)
+	if err != nil {
+		return err
+	}
+	migration, err := repo.MigrateFieldType(target, *typeName, *field, json.RawMessage(*property), *force)
+	if err != nil {
+		return err
+	}
+	for _, issue := range migration.Breaking {
+		fmt.Println(issue.String())
+	}
+	if !migration.Applied {
+		return fmt.Errorf("migration blocked: %d of %d object(s) would break (use --force to apply anyway)", len(migration.Breaking), migration.Checked)
+	}
+	fmt.Printf("migrated %s.%s: %d object(s) checked, %d breaking\n", migration.Type, migration.Field, migration.Checked, len(migration.Breaking))
+	return nil
+}
+
+func runCreate(args []string) error {
+	cfg := defaultConfig()
+	fs := flag.NewFlagSet("create", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	fs.StringVar(&cfg.repository, "repository", cfg.repository, "path to repository")
+	fs.StringVar(&cfg.baseBranch, "base-branch", cfg.baseBranch, "branch the main workspace tracks (overrides settings baseBranch)")
+	fs.StringVar(&cfg.workspaceRoot, "workspace-root", cfg.workspaceRoot, "workspace worktree root path (absolute or relative to repository)")
+	fs.StringVar(&cfg.workspace, "workspace", cfg.workspace, "workspace draft to create the object in")
+	typeName := fs.String("type", "", "object type to create")
+	var sets []string
+	fs.Func("set", "field value as key=value (repeatable)", func(v string) error {
+		sets = append(sets, v)
+		return nil
+	})
+	if err := fs.Parse(args); err != nil {
+		return usageError("create", err)
+	}
+	if cfg.repository == "" {
+		return errors.New("--repository is required (or WORKTREEFOUNDRY_REPOSITORY)")
+	}
+	if *typeName == "" {
+		return usageError("create", errors.New("--type is required"))
+	}
+	if cfg.workspace == "" || cfg.workspace == "main" {
+		return usageError("create", errors.New("--workspace is required; create never writes to main"))
+	}
+
+	repo, err := OpenRepository(cfg.repository, cfg.workspaceRoot, cfg.baseBranch)
+	if err != nil {
+		return err
+	}
+	target, err := resolveTargetPath(repo, cfg.workspace)
+	if err != nil {
+		return err
+	}
+	schemas, err := LoadSchemas(target)
+	if err != nil {
+		return err
+	}
+	schemas, issues := ResolveEnumRefs(target, schemas)
+	if len(issues) > 0 {
+		return fmt.Errorf("cannot create: %s", issues[0].String())
+	}
+	schema, ok := schemas[*typeName]
+	if !ok {
+		return fmt.Errorf("unknown type %q", *typeName)
+	}
+
+	id, err := NewUUID()
+	if err != nil {
+		return err
+	}
+	data := map[string]any{"_id": id, "_type": *typeName}
+	setKeys := make(map[string]bool, len(sets))
+	for _, set := range sets {
+		key, raw, ok := strings.Cut(set, "=")
+		if !ok || key == "" {
+			return usageError("create", fmt.Errorf("invalid --set %q (expected key=value)", set))
+		}
+		parent, child, isNested := strings.Cut(key, ".")
+		if field, ok := schema.Aliases[parent]; ok {
+			key = field + strings.TrimPrefix(key, parent)
+			parent = field
+		}
+		setKeys[key] = true
+		prop, ok := schema.Properties[parent]
+		if ok && isNested {
+			prop, ok = prop.Properties[child]
+		}
+		if !ok || prop.Type == "object" {
+			return fmt.Errorf("field %q is not defined in %s schema", key, *typeName)
+		}
+		v, err := parseFormField(raw, prop)
+		if err != nil {
+			return fmt.Errorf("field %s: %w", key, err)
+		}
+		if !isNested {
+			data[key] = v
+			continue
+		}
+		nested, _ := data[parent].(map[string]any)
+		if nested == nil {
+			nested = map[string]any{}
+			data[parent] = nested
+		}
+		nested[child] = v
+	}
+	applySchemaDefaults(data, schema, func(field string) bool { return setKeys[field] })
+	obj := Object{ID: id, Type: *typeName, Data: data, Path: filepath.ToSlash(filepath.Join("data", *typeName, id+".yaml"))}
+	result := ValidationResult{}
+	validateObjectSchema(obj, schema, &result)
+	constraints, err := LoadConstraints(target)
+	if err != nil {
+		return err
+	}
+	references, err := CheckForeignKeyReferences(obj, constraints, schemas, func(t string) ([]Object, error) {
+		return repo.ListObjectsForType(target, t)
+	})
+	if err != nil {
+		return err
+	}
+	for _, issue := range references {
+		result.Add(issue)
+	}
+	if !result.OK() {
+		for _, issue := range result.Issues {
+			fmt.Println(issue.String())
+		}
+		return fmt.Errorf("create blocked by %d validation issue(s)", len(result.Issues))
+	}
+	if err := repo.WriteObject(target, obj); err != nil {
+		return err
+	}
+	fmt.Println(id)
+	return nil
+}
+
+func resolveTargetPath(repo *Repository, workspace string) (string, error) {
+	if workspace == "" || workspace == "main" {
+		return repo.Root, nil
+	}
+	if !repo.WorkspaceExists(workspace) {
+		return "", fmt.Errorf("workspace %q not found", workspace)
+	}
+	return repo.WorkspacePath(workspace), nil
+}
+
 func commandUsage(command string) string {
 	switch command {
 	case "init":
 		return "Usage: worktreefoundry init --repository /path/to/repo [--force]"
 	case "validate":
-		return "Usage: worktreefoundry validate --repository /path/to/repo"
+		return "Usage: worktreefoundry validate --repository /path/to/repo [--format lines|table|json] [--lint] [--exit-on-first | --all-workspaces | --file data/<type>/<id>.yaml | --type name [--id uuid] | --orphans | --index]"
 	case "export":
-		return "Usage: worktreefoundry export --repository /path/to/repo [--out output]"
+		return "Usage: worktreefoundry export --repository /path/to/repo [--out output] [--workspace name] [--format json|csv] [--csv-separator ;] [--fields type:field1,field2] [--layout types|objects] [--with-ids] [--schemas] [--prune] [--manifest] [--diff-json]"
 	case "web":
-		return "Usage: worktreefoundry web --repository /path/to/repo [--addr :8080] [--workspace-root .worktreefoundry/workspaces]"
+		return "Usage: worktreefoundry web --repository /path/to/repo [--addr 127.0.0.1:8080 | --allow-remote] [--workspace-root .worktreefoundry/workspaces] [--strict] [--merge-webhook url] [--read-only] [--base-workspace name]"
+	case "migrate":
+		return "Usage: worktreefoundry migrate field-type --repository /path/to/repo --workspace name --type name --field name --property '{\"type\":\"string\"}' [--force]"
+	case "create":
+		return "Usage: worktreefoundry create --repository /path/to/repo --workspace name --type name --set key=value [--set key=value ...]"
+	case "version":
+		return "Usage: worktreefoundry version [--json]"
+	case "merge":
+		return "Usage: worktreefoundry merge --repository /path/to/repo --workspace name [--sync] [--push] [--merge-webhook url] [--dry-run [--format lines|json]] [--export-conflicts file.json] [--apply-resolutions file.json]"
+	case "import":
+		return "Usage: worktreefoundry import --repository /path/to/repo --workspace name --type name --file objects.json|objects.csv [--format json|csv] [--upsert] [--strict]"
+	case "prune":
+		return "Usage: worktreefoundry prune --repository /path/to/repo --workspace name [--confirm]"
+	case "diff":
+		return "Usage: worktreefoundry diff --repository /path/to/repo --workspace name [--format lines|json]"
+	case "snapshot":
+		return "Usage: worktreefoundry snapshot --repository /path/to/repo [--list | --restore snapshot/<timestamp>]"
+	case "stats":
+		return "Usage: worktreefoundry stats --repository /path/to/repo [--format table|json]"
+	case "changelog":
+		return "Usage: worktreefoundry changelog --repository /path/to/repo --from ref --to ref [--json]"
+	case "graph":
+		return "Usage: worktreefoundry graph --repository /path/to/repo [--format dot|json]"
+	case "config":
+		return "Usage: worktreefoundry config reset-ui --repository /path/to/repo [--workspace name] [--yes]"
 	default:
 		return ""
 	}
@@ -0,0 +1,300 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFormatEnvIsPerCommand(t *testing.T) {
+	t.Setenv("WORKTREEFOUNDRY_VALIDATE_FORMAT", "table")
+	t.Setenv("WORKTREEFOUNDRY_EXPORT_FORMAT", "")
+	if got := formatEnv("validate", "lines"); got != "table" {
+		t.Errorf("validate format = %q, want table", got)
+	}
+	if got := formatEnv("export", ExportFormatJSON); got != ExportFormatJSON {
+		t.Errorf("export format = %q, want the %s default", got, ExportFormatJSON)
+	}
+	if got := formatEnv("graph", "dot"); got != "dot" {
+		t.Errorf("graph format = %q, want the dot default", got)
+	}
+}
+
+func TestWriteIssueTableAlignsColumnsAndListsEveryIssue(t *testing.T) {
+	issues := []ValidationIssue{
+		{Stage: "constraints", Path: "data/service/a.yaml", Field: "teamId", Message: "reference does not exist in team._id"},
+		{Stage: "layout", Path: "config/extra.txt", Message: "file is not allowed under config/"},
+		{Stage: "schema", Path: "data/team/b.yaml", Field: "code", Message: "length must be >= 2"},
+	}
+	var b strings.Builder
+	writeIssueTable(&b, issues)
+	lines := strings.Split(strings.TrimRight(b.String(), "\n"), "\n")
+	if len(lines) != len(issues)+1 {
+		t.Fatalf("got %d lines, want a header and %d issues:\n%s", len(lines), len(issues), b.String())
+	}
+	header := lines[0]
+	columns := []string{"STAGE", "PATH", "FIELD", "MESSAGE"}
+	for _, column := range columns {
+		if !strings.Contains(header, column) {
+			t.Fatalf("header %q lacks %s", header, column)
+		}
+	}
+	// Every row starts its columns where the header's do.
+	for _, column := range columns[1:] {
+		start := strings.Index(header, column)
+		for _, line := range lines[1:] {
+			if len(line) <= start || line[start-1] != ' ' || line[start] == ' ' {
+				t.Errorf("column %s (offset %d) is not aligned in %q", column, start, line)
+			}
+		}
+	}
+	// Rows are grouped by stage in validation order.
+	for i, stage := range []string{"layout", "schema", "constraints"} {
+		if !strings.HasPrefix(lines[i+1], stage+" ") {
+			t.Errorf("row %d = %q, want stage %s", i+1, lines[i+1], stage)
+		}
+	}
+	for _, issue := range issues {
+		if !strings.Contains(b.String(), issue.Message) {
+			t.Errorf("table lacks issue %q", issue.Message)
+		}
+	}
+}
+
+func TestWritingCommandsRequireWorkspace(t *testing.T) {
+	repo := newTestRepository(t)
+	t.Setenv("WORKTREEFOUNDRY_WORKSPACE", "")
+	commands := map[string][]string{
+		"create":             {"create", "--repository", repo.Root, "--type", "team", "--set", "name=Data", "--set", "code=DATA"},
+		"migrate field-type": {"migrate", "field-type", "--repository", repo.Root, "--type", "team", "--field", "name", "--property", `{"type":"string"}`},
+		"prune":              {"prune", "--repository", repo.Root, "--confirm"},
+	}
+	for name, args := range commands {
+		for _, workspace := range [][]string{nil, {"--workspace", "main"}} {
+			err := Run(context.Background(), append(append([]string(nil), args...), workspace...), "test")
+			if err == nil || !strings.Contains(err.Error(), "--workspace is required") {
+				t.Errorf("%s %v: error = %v, want --workspace is required", name, workspace, err)
+			}
+		}
+	}
+	if changed, err := repo.ChangedFiles(repo.Root); err != nil {
+		t.Fatal(err)
+	} else if len(changed) != 0 {
+		t.Errorf("main changed: %v", changed)
+	}
+
+	ws := newTestWorkspace(t, repo, "draft")
+	if err := Run(context.Background(), append(commands["create"], "--workspace", "draft"), "test"); err != nil {
+		t.Fatal(err)
+	}
+	if changed, err := repo.ChangedFiles(ws); err != nil {
+		t.Fatal(err)
+	} else if len(changed) != 1 {
+		t.Errorf("workspace changes = %v, want the created object", changed)
+	}
+}
+
+func TestCheckWebSchemasWarnsOnEmptyRepository(t *testing.T) {
+	empty := t.TempDir()
+	var out strings.Builder
+	if err := checkWebSchemas(&out, empty, false); err != nil {
+		t.Fatalf("non-strict check failed: %v", err)
+	}
+	if !strings.HasPrefix(out.String(), "warning: ") || !strings.Contains(out.String(), "worktreefoundry init") {
+		t.Errorf("warning = %q, want one pointing at init", out.String())
+	}
+
+	out.Reset()
+	if err := checkWebSchemas(&out, empty, true); err == nil || !strings.Contains(err.Error(), "worktreefoundry init") {
+		t.Errorf("strict check error = %v, want one pointing at init", err)
+	}
+	if out.Len() != 0 {
+		t.Errorf("strict check also warned: %q", out.String())
+	}
+
+	repo := newTestRepository(t)
+	if err := checkWebSchemas(&out, repo.Root, true); err != nil || out.Len() != 0 {
+		t.Errorf("sample repository: error %v, output %q", err, out.String())
+	}
+}
+
+func TestConfigResetUIRestoresDefaults(t *testing.T) {
+	repo := newTestRepository(t)
+	t.Setenv("WORKTREEFOUNDRY_WORKSPACE", "")
+	ws := newTestWorkspace(t, repo, "draft")
+	editTestJSON(t, filepath.Join(ws, "config", "ui.json"), func(doc map[string]any) {
+		doc["repoName"] = "Edited"
+		doc["types"].(map[string]any)["service"] = map[string]any{"displayField": "name", "fields": []any{"tier"}, "color": "#123456"}
+	})
+	mainUI, err := os.ReadFile(filepath.Join(repo.Root, "config", "ui.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := Run(context.Background(), []string{"config", "reset-ui", "--repository", repo.Root, "--workspace", "draft", "--yes"}, "test"); err != nil {
+		t.Fatal(err)
+	}
+	schemas, err := LoadSchemas(ws)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := os.ReadFile(filepath.Join(ws, "config", "ui.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defaults := t.TempDir()
+	if err := repo.SaveUIConfig(defaults, DefaultUIConfig(repo.Root, schemas)); err != nil {
+		t.Fatal(err)
+	}
+	if want, err := os.ReadFile(filepath.Join(defaults, "config", "ui.json")); err != nil {
+		t.Fatal(err)
+	} else if string(got) != string(want) {
+		t.Errorf("reset ui config =\n%s\nwant defaults\n%s", got, want)
+	}
+	if after, err := os.ReadFile(filepath.Join(repo.Root, "config", "ui.json")); err != nil {
+		t.Fatal(err)
+	} else if string(after) != string(mainUI) {
+		t.Error("resetting the workspace changed the repository root ui config")
+	}
+}
+
+func TestValidateAllWorkspacesReportsEachWorkspace(t *testing.T) {
+	repo := newTestRepository(t)
+	service := func(root, id, name string) {
+		writeTestFile(t, filepath.Join(root, "data", "service", id+".yaml"),
+			"_id: "+id+"\n_type: service\nname: "+name+"\nteamId: "+testTeamID+"\ntier: batch\n")
+	}
+	clean := newTestWorkspace(t, repo, "clean")
+	service(clean, testObjectID(3), "reports")
+	saveTestWorkspace(t, repo, "clean")
+	stale := newTestWorkspace(t, repo, "stale")
+	service(stale, testObjectID(4), "billing")
+	saveTestWorkspace(t, repo, "stale")
+
+	// Main takes the name the stale draft uses.
+	service(repo.Root, testObjectID(5), "billing")
+	commitTestMain(t, repo, "add billing")
+
+	var out strings.Builder
+	err := validateAllWorkspaces(&out, repo, "lines")
+	if err == nil || err.Error() != "1 of 2 workspace(s) failed validation" {
+		t.Errorf("error = %v, want one of two workspaces failed", err)
+	}
+	if !strings.Contains(out.String(), "ok   clean") {
+		t.Errorf("output does not pass the clean workspace:\n%s", out.String())
+	}
+	if !strings.Contains(out.String(), "FAIL stale: 1 issue(s)") || !strings.Contains(out.String(), "duplicate value") {
+		t.Errorf("output does not fail the stale workspace on the duplicate name:\n%s", out.String())
+	}
+}
+
+func TestWebDefaultsToLoopbackAndWarnsOnRemoteBind(t *testing.T) {
+	t.Setenv("WORKTREEFOUNDRY_ADDR", "")
+	if addr := defaultConfig().addr; addr != "127.0.0.1:8080" || !isLoopbackAddr(addr) {
+		t.Errorf("default addr = %q, want loopback 127.0.0.1:8080", addr)
+	}
+
+	for addr, warn := range map[string]bool{
+		"127.0.0.1:8080":  false,
+		"localhost:8080":  false,
+		"[::1]:8080":      false,
+		"0.0.0.0:8080":    true,
+		":8080":           true,
+		"192.0.2.10:8080": true,
+	} {
+		var out strings.Builder
+		warnRemoteBind(&out, addr)
+		if got := strings.HasPrefix(out.String(), "warning: listening on "+addr); got != warn {
+			t.Errorf("warnRemoteBind(%q) = %q, want warning %v", addr, out.String(), warn)
+		}
+	}
+}
+
+func TestWriteVersionJSON(t *testing.T) {
+	var out strings.Builder
+	if err := writeVersion(&out, "1.2.3", false); err != nil {
+		t.Fatal(err)
+	}
+	if out.String() != "1.2.3\n" {
+		t.Errorf("plain version = %q, want the bare version", out.String())
+	}
+
+	out.Reset()
+	if err := writeVersion(&out, "1.2.3", true); err != nil {
+		t.Fatal(err)
+	}
+	var info map[string]any
+	if err := json.Unmarshal([]byte(out.String()), &info); err != nil {
+		t.Fatalf("parse %q: %v", out.String(), err)
+	}
+	if info["version"] != "1.2.3" {
+		t.Errorf("version = %v, want 1.2.3", info["version"])
+	}
+	if goVersion, _ := info["goVersion"].(string); !strings.HasPrefix(goVersion, "go") {
+		t.Errorf("goVersion = %v, want a Go release", info["goVersion"])
+	}
+}
+
+func TestMigrateFieldTypeBlocksBreakingSchema(t *testing.T) {
+	repo := newTestRepository(t)
+	t.Setenv("WORKTREEFOUNDRY_WORKSPACE", "")
+	ws := newTestWorkspace(t, repo, "draft")
+	schemaPath := filepath.Join(ws, "config", "schemas", "service.schema.json")
+	before, err := os.ReadFile(schemaPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The sample service is an edge service, so dropping edge breaks it.
+	args := []string{"migrate", "field-type", "--repository", repo.Root, "--workspace", "draft",
+		"--type", "service", "--field", "tier", "--property", `{"type":"string","enum":["batch","core"]}`}
+	err = Run(context.Background(), args, "test")
+	if err == nil || !strings.Contains(err.Error(), "migration blocked: 1 of 1 object(s) would break") {
+		t.Fatalf("migrate error = %v, want the stricter schema blocked", err)
+	}
+	if after, err := os.ReadFile(schemaPath); err != nil {
+		t.Fatal(err)
+	} else if string(after) != string(before) {
+		t.Errorf("blocked migration rewrote the schema:\n%s", after)
+	}
+
+	if err := Run(context.Background(), append(args, "--force"), "test"); err != nil {
+		t.Fatalf("forced migrate: %v", err)
+	}
+	if after, err := os.ReadFile(schemaPath); err != nil {
+		t.Fatal(err)
+	} else if string(after) == string(before) {
+		t.Error("forced migration left the schema unchanged")
+	}
+}
+
+func TestCreateRefusesDanglingForeignKey(t *testing.T) {
+	repo := newTestRepository(t)
+	t.Setenv("WORKTREEFOUNDRY_WORKSPACE", "")
+	ws := newTestWorkspace(t, repo, "draft")
+	create := func(teamID string) error {
+		return Run(context.Background(), []string{"create", "--repository", repo.Root, "--workspace", "draft",
+			"--type", "service", "--set", "name=ingest-" + teamID[:1], "--set", "teamId=" + teamID, "--set", "tier=batch"}, "test")
+	}
+
+	if err := create(testObjectID(9)); err == nil {
+		t.Error("create accepted a teamId no team holds")
+	}
+	if changed, err := repo.ChangedFiles(ws); err != nil {
+		t.Fatal(err)
+	} else if len(changed) != 0 {
+		t.Errorf("refused create wrote %v", changed)
+	}
+
+	if err := create(testTeamID); err != nil {
+		t.Fatalf("create with an existing team: %v", err)
+	}
+	if changed, err := repo.ChangedFiles(ws); err != nil {
+		t.Fatal(err)
+	} else if len(changed) != 1 {
+		t.Errorf("workspace changes = %v, want the created service", changed)
+	}
+}
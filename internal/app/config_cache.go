@@ -0,0 +1,156 @@
+package app
+
+import (
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// configCache keeps the parsed schemas, constraints, and UI settings of each
+// checkout the web server reads, so pages do not re-parse unchanged JSON.
+// Entries are keyed by repository path, so main and every workspace have
+// their own, and an entry is reused only while the modification times and
+// sizes of the files it was parsed from are unchanged. Entries of checkouts
+// that no longer exist, such as deleted or renamed workspaces, are evicted
+// whenever a new entry is stored.
+type configCache struct {
+	mu      sync.Mutex
+	entries map[string]configCacheEntry
+}
+
+type configCacheEntry struct {
+	stamp       string
+	schemas     map[string]Schema
+	constraints Constraints
+	ui          UIConfig
+}
+
+// load returns the configuration of repoPath, parsing it again when any of
+// its files changed. Every call returns its own copy of the schemas,
+// constraints, and UI type map, so callers may edit what they get.
+func (c *configCache) load(repoPath string) (map[string]Schema, Constraints, UIConfig, error) {
+	stamp := configStamp(repoPath)
+	c.mu.Lock()
+	entry, ok := c.entries[repoPath]
+	c.mu.Unlock()
+	if !ok || entry.stamp != stamp {
+		schemas, err := LoadSchemas(repoPath)
+		if err != nil {
+			return nil, Constraints{}, UIConfig{}, err
+		}
+		schemas, issues := ResolveEnumRefs(repoPath, schemas)
+		if len(issues) > 0 {
+			return nil, Constraints{}, UIConfig{}, fmt.Errorf("cannot load schemas: %s", issues[0].String())
+		}
+		constraints, err := LoadConstraints(repoPath)
+		if err != nil {
+			return nil, Constraints{}, UIConfig{}, err
+		}
+		ui, err := LoadUIConfig(repoPath, schemas)
+		if err != nil {
+			return nil, Constraints{}, UIConfig{}, err
+		}
+		entry = configCacheEntry{stamp: stamp, schemas: schemas, constraints: constraints, ui: ui}
+		c.store(repoPath, entry)
+	}
+	constraints := Constraints{
+		Unique:      slices.Clone(entry.constraints.Unique),
+		ForeignKeys: slices.Clone(entry.constraints.ForeignKeys),
+	}
+	ui := entry.ui
+	ui.Types = maps.Clone(entry.ui.Types)
+	return cloneSchemas(entry.schemas), constraints, ui, nil
+}
+
+// store saves entry for repoPath and drops the entries of paths that are
+// gone.
+func (c *configCache) store(repoPath string, entry configCacheEntry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.entries == nil {
+		c.entries = map[string]configCacheEntry{}
+	}
+	for path := range c.entries {
+		if _, err := os.Stat(path); err != nil {
+			delete(c.entries, path)
+		}
+	}
+	c.entries[repoPath] = entry
+}
+
+// cloneSchemas copies the maps and slices of schemas so edits to the copy do
+// not reach the cache. Bound pointers such as MinLength are shared; nothing
+// writes through them.
+func cloneSchemas(schemas map[string]Schema) map[string]Schema {
+	out := make(map[string]Schema, len(schemas))
+	for name, schema := range schemas {
+		schema.Required = maps.Clone(schema.Required)
+		schema.Properties = cloneSchemaProperties(schema.Properties)
+		schema.OneOfRequired = cloneFieldGroups(schema.OneOfRequired)
+		schema.MutuallyExclusive = cloneFieldGroups(schema.MutuallyExclusive)
+		schema.Aliases = maps.Clone(schema.Aliases)
+		out[name] = schema
+	}
+	return out
+}
+
+func cloneSchemaProperties(props map[string]SchemaProperty) map[string]SchemaProperty {
+	if props == nil {
+		return nil
+	}
+	out := make(map[string]SchemaProperty, len(props))
+	for name, prop := range props {
+		prop.Enum = slices.Clone(prop.Enum)
+		prop.EnumNumbers = slices.Clone(prop.EnumNumbers)
+		prop.Aliases = slices.Clone(prop.Aliases)
+		prop.Default = cloneDefault(prop.Default)
+		prop.Properties = cloneSchemaProperties(prop.Properties)
+		prop.Required = maps.Clone(prop.Required)
+		out[name] = prop
+	}
+	return out
+}
+
+func cloneFieldGroups(groups [][]string) [][]string {
+	if groups == nil {
+		return nil
+	}
+	out := make([][]string, len(groups))
+	for i, group := range groups {
+		out[i] = slices.Clone(group)
+	}
+	return out
+}
+
+// configStamp summarizes the files the configuration is parsed from. The
+// schema, shared definition, and enum directories are included so added or
+// removed files change the stamp too.
+func configStamp(repoPath string) string {
+	configDir := filepath.Join(repoPath, "config")
+	paths := []string{
+		filepath.Join(configDir, "constraints.json"),
+		filepath.Join(configDir, "ui.json"),
+		filepath.Join(configDir, "schemas"),
+		filepath.Join(configDir, "schemas", schemaDefsDir),
+		filepath.Join(configDir, "enums"),
+	}
+	for _, dir := range []string{"schemas", filepath.Join("schemas", schemaDefsDir), "enums"} {
+		entries, _ := os.ReadDir(filepath.Join(configDir, dir))
+		for _, e := range entries {
+			paths = append(paths, filepath.Join(configDir, dir, e.Name()))
+		}
+	}
+	var b strings.Builder
+	for _, p := range paths {
+		if st, err := os.Stat(p); err == nil {
+			fmt.Fprintf(&b, "%s:%d:%d;", p, st.ModTime().UnixNano(), st.Size())
+		} else {
+			fmt.Fprintf(&b, "%s:-;", p)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,100 @@
+package app
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestConfigCacheReusesUntilConfigChanges(t *testing.T) {
+	repo := newTestRepository(t)
+	var cache configCache
+	cached := func() map[string]SchemaProperty {
+		t.Helper()
+		return cache.entries[repo.Root].schemas["service"].Properties
+	}
+
+	schemas, _, _, err := cache.load(repo.Root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	first := cached()
+	delete(schemas["service"].Properties, "name")
+	schemas["team"].Properties["code"] = SchemaProperty{Type: "integer"}
+
+	again, _, _, err := cache.load(repo.Root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reflect.ValueOf(cached()).Pointer() != reflect.ValueOf(first).Pointer() {
+		t.Error("unchanged configuration was parsed again")
+	}
+	if _, ok := again["service"].Properties["name"]; !ok || again["team"].Properties["code"].Type != "string" {
+		t.Error("edits to a returned schema reached the cache")
+	}
+
+	addTestSchemaField(t, repo.Root, "service", "owner")
+	edited, _, _, err := cache.load(repo.Root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := edited["service"].Properties["owner"]; !ok {
+		t.Error("edited schema file was not parsed again")
+	}
+	if reflect.ValueOf(cached()).Pointer() == reflect.ValueOf(first).Pointer() {
+		t.Error("entry kept after its schema file changed")
+	}
+}
+
+func TestConfigCacheRejectsBrokenEnumRefs(t *testing.T) {
+	repo := newTestRepository(t)
+	editTestJSON(t, filepath.Join(repo.Root, "config", "schemas", "service.schema.json"), func(doc map[string]any) {
+		doc["properties"].(map[string]any)["region"] = map[string]any{"type": "string", "enumRef": "regions"}
+	})
+	var cache configCache
+	if _, _, _, err := cache.load(repo.Root); err == nil || !strings.HasPrefix(err.Error(), "cannot load schemas: ") {
+		t.Errorf("load with a missing enum file: error = %v", err)
+	}
+	if _, ok := cache.entries[repo.Root]; ok {
+		t.Error("configuration with a broken enumRef was cached")
+	}
+
+	writeTestFile(t, filepath.Join(repo.Root, "config", "enums", "regions.json"), `["us-east", "eu-west"]`+"\n")
+	schemas, _, _, err := cache.load(repo.Root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := schemas["service"].Properties["region"].Enum; !reflect.DeepEqual(got, []string{"eu-west", "us-east"}) {
+		t.Errorf("region enum = %v, want the referenced values", got)
+	}
+}
+
+func TestConfigCacheEvictsRemovedCheckouts(t *testing.T) {
+	repo := newTestRepository(t)
+	ws := newTestWorkspace(t, repo, "draft")
+	var cache configCache
+	for _, path := range []string{ws, repo.Root} {
+		if _, _, _, err := cache.load(path); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := repo.DeleteWorkspace("draft"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(ws); !os.IsNotExist(err) {
+		t.Fatalf("workspace checkout still present: %v", err)
+	}
+
+	addTestSchemaField(t, repo.Root, "team", "owner")
+	if _, _, _, err := cache.load(repo.Root); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := cache.entries[ws]; ok {
+		t.Error("entry of a deleted workspace kept")
+	}
+	if _, ok := cache.entries[repo.Root]; !ok {
+		t.Error("entry of main evicted")
+	}
+}
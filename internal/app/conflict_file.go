@@ -0,0 +1,82 @@
+package app
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// ConflictFile is the offline form of a merge's conflicts: merge
+// --export-conflicts writes it with empty resolutions, and merge
+// --apply-resolutions reads the edited file back.
+type ConflictFile struct {
+	Workspace string              `json:"workspace"`
+	Conflicts []ConflictFileEntry `json:"conflicts"`
+}
+
+// ConflictFileEntry is one conflict. Resolution is main, workspace, union
+// (arrays only), or manual; Manual holds the manual value in the web form's
+// text format, with array items separated by commas.
+type ConflictFileEntry struct {
+	File       string `json:"file"`
+	Field      string `json:"field"`
+	Key        string `json:"key"`
+	Base       any    `json:"base"`
+	Main       any    `json:"main"`
+	Workspace  any    `json:"workspace"`
+	Resolution string `json:"resolution"`
+	Manual     string `json:"manual,omitempty"`
+}
+
+func (o FileOptions) WriteConflictFile(path, workspace string, conflicts []FieldConflict) error {
+	doc := ConflictFile{Workspace: workspace, Conflicts: make([]ConflictFileEntry, 0, len(conflicts))}
+	for _, c := range conflicts {
+		doc.Conflicts = append(doc.Conflicts, ConflictFileEntry{File: c.File, Field: c.Field, Key: c.Key, Base: c.Base, Main: c.Main, Workspace: c.Workspace})
+	}
+	b, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return err
+	}
+	b = append(b, '\n')
+	return o.writeFile(path, b)
+}
+
+// ReadConflictFile loads the resolutions of a conflict file for workspace as
+// the resolution and manual value maps MergeWorkspace takes. Entries left
+// without a resolution are skipped, so they remain conflicts.
+func ReadConflictFile(path, workspace string) (map[string]string, map[string]string, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	var doc ConflictFile
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&doc); err != nil {
+		return nil, nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+	if doc.Workspace != "" && doc.Workspace != workspace {
+		return nil, nil, fmt.Errorf("%s holds conflicts of workspace %q, not %q", path, doc.Workspace, workspace)
+	}
+	resolutions := map[string]string{}
+	manual := map[string]string{}
+	for i, entry := range doc.Conflicts {
+		key := entry.Key
+		if key == "" {
+			key = conflictKey(entry.File, entry.Field)
+		}
+		switch entry.Resolution {
+		case "":
+			continue
+		case "main", "workspace", "union", "manual":
+		default:
+			return nil, nil, fmt.Errorf("%s: conflicts[%d] (%s): resolution must be main, workspace, union, or manual", path, i, key)
+		}
+		resolutions[key] = entry.Resolution
+		if entry.Resolution == "manual" {
+			manual[key] = entry.Manual
+		}
+	}
+	return resolutions, manual, nil
+}
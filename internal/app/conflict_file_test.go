@@ -0,0 +1,85 @@
+package app
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestConflictFileRoundTrip(t *testing.T) {
+	repo := newTestRepository(t)
+	teamRel := filepath.Join("data", "team", testTeamID+".yaml")
+	ws := newTestWorkspace(t, repo, "rename")
+	writeTestFile(t, filepath.Join(ws, teamRel), "_id: "+testTeamID+"\n_type: team\ncode: WSC\nname: Workspace\n")
+	saveTestWorkspace(t, repo, "rename")
+	writeTestFile(t, filepath.Join(repo.Root, teamRel), "_id: "+testTeamID+"\n_type: team\ncode: MNC\nname: Main\n")
+	commitTestMain(t, repo, "rename on main")
+
+	result, err := repo.MergeWorkspace("rename", nil, nil, MergeOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Merged || len(result.Conflicts) != 2 {
+		t.Fatalf("result = %+v, want conflicts on code and name", result)
+	}
+	path := filepath.Join(t.TempDir(), "conflicts.json")
+	if err := repo.WriteConflictFile(path, "rename", result.Conflicts); err != nil {
+		t.Fatal(err)
+	}
+
+	// Unresolved entries stay conflicts.
+	resolutions, manual, err := ReadConflictFile(path, "rename")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resolutions) != 0 || len(manual) != 0 {
+		t.Errorf("unedited file resolves %v %v, want nothing", resolutions, manual)
+	}
+	if _, _, err := ReadConflictFile(path, "other"); err == nil {
+		t.Error("read the conflict file for a different workspace")
+	}
+
+	editTestJSON(t, path, func(doc map[string]any) {
+		for _, entry := range doc["conflicts"].([]any) {
+			entry := entry.(map[string]any)
+			switch entry["field"] {
+			case "code":
+				if entry["main"] != "MNC" || entry["workspace"] != "WSC" || entry["base"] != "PLAT" {
+					t.Errorf("code entry = %v, want base, main, and workspace values", entry)
+				}
+				entry["resolution"] = "manual"
+				entry["manual"] = "MIX"
+			case "name":
+				entry["resolution"] = "workspace"
+			}
+		}
+	})
+	resolutions, manual, err = ReadConflictFile(path, "rename")
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err = repo.MergeWorkspace("rename", resolutions, manual, MergeOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.Merged {
+		t.Fatalf("result = %+v, want the resolved merge", result)
+	}
+	b, err := os.ReadFile(filepath.Join(repo.Root, teamRel))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"code: MIX", "name: Workspace"} {
+		if !strings.Contains(string(b), want) {
+			t.Errorf("merged team =\n%s\nwant %q", b, want)
+		}
+	}
+
+	editTestJSON(t, path, func(doc map[string]any) {
+		doc["conflicts"].([]any)[0].(map[string]any)["resolution"] = "theirs"
+	})
+	if _, _, err := ReadConflictFile(path, "rename"); err == nil || !strings.Contains(err.Error(), "resolution must be") {
+		t.Errorf("unknown resolution: error = %v, want it rejected", err)
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 )
 
 func LoadConstraints(root string) (Constraints, error) {
@@ -21,5 +22,153 @@ func LoadConstraints(root string) (Constraints, error) {
 	if err := json.Unmarshal(b, &c); err != nil {
 		return Constraints{}, fmt.Errorf("parse constraints: %w", err)
 	}
+	for i, fk := range c.ForeignKeys {
+		switch fk.OnDelete {
+		case "", "restrict", "cascade":
+		default:
+			return Constraints{}, fmt.Errorf("foreignKeys[%d]: onDelete must be restrict or cascade", i)
+		}
+	}
 	return c, nil
 }
+
+// ValidateConstraintFields reports constraints declared on array or object
+// fields. Constraint values are compared whole, so such a constraint would
+// otherwise only surface as a per-object "scalar" issue once data exists.
+// Types or fields missing from the schemas are left to object validation.
+func ValidateConstraintFields(constraints Constraints, schemas map[string]Schema) []ValidationIssue {
+	issues := make([]ValidationIssue, 0)
+	check := func(key, kind, typeName, field string) {
+		prop, ok := schemas[typeName].Properties[field]
+		if !ok || (prop.Type != "array" && prop.Type != "object") {
+			return
+		}
+		issues = append(issues, ValidationIssue{Stage: "constraints", Path: "config/constraints.json", Field: key, Message: fmt.Sprintf("%s requires a scalar field, but %s.%s is an %s; constrain a string, number, integer, or boolean field instead", kind, typeName, field, prop.Type)})
+	}
+	for i, c := range constraints.Unique {
+		check(fmt.Sprintf("unique[%d].field", i), "unique constraint", c.Type, c.Field)
+	}
+	for i, fk := range constraints.ForeignKeys {
+		check(fmt.Sprintf("foreignKeys[%d].fromField", i), "foreign key", fk.FromType, fk.FromField)
+		check(fmt.Sprintf("foreignKeys[%d].toField", i), "foreign key", fk.ToType, fk.ToField)
+	}
+	return issues
+}
+
+// DeleteObjectWithReferences deletes an object after applying the onDelete
+// rule of every foreign key that points at it. Referencing objects block the
+// delete under restrict (the default) and are deleted too under cascade. It
+// returns every deleted object, the requested one first.
+func (y YAMLOptions) DeleteObjectWithReferences(repoRoot, typeName, id string, constraints Constraints) ([]Object, error) {
+	root, err := y.ReadObject(repoRoot, typeName, id)
+	if err != nil {
+		return nil, err
+	}
+	byType := map[string][]Object{}
+	load := func(t string) ([]Object, error) {
+		if objs, ok := byType[t]; ok {
+			return objs, nil
+		}
+		objs, err := y.ListObjectsForType(repoRoot, t)
+		if err != nil {
+			return nil, err
+		}
+		byType[t] = objs
+		return objs, nil
+	}
+
+	planned := map[string]bool{}
+	plan := make([]Object, 0)
+	type reference struct{ key, label string }
+	restricted := make([]reference, 0)
+	var visit func(obj Object) error
+	visit = func(obj Object) error {
+		planned[obj.Type+"/"+obj.ID] = true
+		plan = append(plan, obj)
+		for _, fk := range constraints.ForeignKeys {
+			if fk.ToType != obj.Type {
+				continue
+			}
+			key := constraintValueKey(obj.Data[fk.ToField])
+			if key == "" {
+				continue
+			}
+			sources, err := load(fk.FromType)
+			if err != nil {
+				return err
+			}
+			for _, src := range sources {
+				if planned[src.Type+"/"+src.ID] || constraintValueKey(src.Data[fk.FromField]) != key {
+					continue
+				}
+				if fk.OnDelete == "cascade" {
+					if err := visit(src); err != nil {
+						return err
+					}
+					continue
+				}
+				restricted = append(restricted, reference{key: src.Type + "/" + src.ID, label: fmt.Sprintf("%s/%s (%s)", src.Type, src.ID, fk.FromField)})
+			}
+		}
+		return nil
+	}
+	if err := visit(root); err != nil {
+		return nil, err
+	}
+
+	blocking := make([]string, 0, len(restricted))
+	for _, ref := range restricted {
+		if !planned[ref.key] {
+			blocking = append(blocking, ref.label)
+		}
+	}
+	if len(blocking) > 0 {
+		return nil, fmt.Errorf("cannot delete %s/%s: still referenced by %s", typeName, id, strings.Join(blocking, ", "))
+	}
+	for _, obj := range plan {
+		if err := DeleteObject(repoRoot, obj.Type, obj.ID); err != nil {
+			return nil, err
+		}
+	}
+	return plan, nil
+}
+
+// CheckForeignKeyReferences reports the foreign key fields of obj whose value
+// no object of the target type holds, so a dangling reference is caught when
+// the object is written rather than at the next validation. load returns the
+// objects of a type; schemas map target aliases to their fields.
+func CheckForeignKeyReferences(obj Object, constraints Constraints, schemas map[string]Schema, load func(typeName string) ([]Object, error)) ([]ValidationIssue, error) {
+	issues := make([]ValidationIssue, 0)
+	for _, fk := range constraints.ForeignKeys {
+		if fk.FromType != obj.Type {
+			continue
+		}
+		v, ok := obj.Data[fk.FromField]
+		if !ok || v == nil {
+			continue
+		}
+		key := constraintValueKey(v)
+		if key == "" {
+			continue
+		}
+		targets, err := load(fk.ToType)
+		if err != nil {
+			return nil, err
+		}
+		found := false
+		for _, target := range targets {
+			data := target.Data
+			if schema, ok := schemas[fk.ToType]; ok {
+				data, _ = canonicalAliasData(data, schema)
+			}
+			if constraintValueKey(data[fk.ToField]) == key {
+				found = true
+				break
+			}
+		}
+		if !found {
+			issues = append(issues, ValidationIssue{Stage: "constraints", Path: obj.Path, Field: fk.FromField, Message: fmt.Sprintf("reference %q does not exist in %s.%s", valueToText(v), fk.ToType, fk.ToField)})
+		}
+	}
+	return issues, nil
+}
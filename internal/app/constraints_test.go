@@ -0,0 +1,104 @@
+package app
+
+import (
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDeleteObjectWithReferencesRestrictAndCascade(t *testing.T) {
+	repo := newTestRepository(t)
+	ws := newTestWorkspace(t, repo, "draft")
+	endpoint := testObjectID(3)
+	writeTestFile(t, filepath.Join(ws, "config", "schemas", "endpoint.schema.json"), `{
+  "type": "object",
+  "required": ["path", "serviceId"],
+  "properties": {
+    "path": {"type": "string"},
+    "serviceId": {"type": "string"}
+  }
+}
+`)
+	writeTestFile(t, filepath.Join(ws, "data", "endpoint", endpoint+".yaml"),
+		"_id: "+endpoint+"\n_type: endpoint\npath: /health\nserviceId: "+testServiceID+"\n")
+	setOnDelete := func(service, endpoint string) Constraints {
+		t.Helper()
+		editTestJSON(t, filepath.Join(ws, "config", "constraints.json"), func(doc map[string]any) {
+			doc["foreignKeys"] = []any{
+				map[string]any{"fromType": "service", "fromField": "teamId", "toType": "team", "toField": "_id", "onDelete": service},
+				map[string]any{"fromType": "endpoint", "fromField": "serviceId", "toType": "service", "toField": "_id", "onDelete": endpoint},
+			}
+		})
+		constraints, err := LoadConstraints(ws)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return constraints
+	}
+	exists := func(typeName, id string) bool {
+		_, err := os.Stat(filepath.Join(ws, "data", typeName, id+".yaml"))
+		return err == nil
+	}
+
+	// restrict is the default and names the referencing object.
+	_, err := repo.DeleteObjectWithReferences(ws, "team", testTeamID, setOnDelete("", ""))
+	if err == nil || !strings.Contains(err.Error(), "still referenced by service/"+testServiceID+" (teamId)") {
+		t.Errorf("restrict: error = %v, want the service listed", err)
+	}
+
+	// A restrict further down a cascade still blocks the whole delete.
+	_, err = repo.DeleteObjectWithReferences(ws, "team", testTeamID, setOnDelete("cascade", "restrict"))
+	if err == nil || !strings.Contains(err.Error(), "still referenced by endpoint/"+endpoint+" (serviceId)") {
+		t.Errorf("cascade then restrict: error = %v, want the endpoint listed", err)
+	}
+	if !exists("team", testTeamID) || !exists("service", testServiceID) || !exists("endpoint", endpoint) {
+		t.Fatal("a blocked delete removed objects")
+	}
+
+	// Through the web handler, a two-level cascade removes all three.
+	setOnDelete("cascade", "cascade")
+	rec := postTestForm(t, newTestHandler(t, repo), "/w/draft/types/team/objects/"+testTeamID+"/delete", nil)
+	loc, err := url.Parse(rec.Header().Get("Location"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loc.Query().Get("error") == "1" {
+		t.Fatalf("cascade delete failed: %s", loc.Query().Get("flash"))
+	}
+	if exists("team", testTeamID) || exists("service", testServiceID) || exists("endpoint", endpoint) {
+		t.Error("cascade left a dependent object behind")
+	}
+	if flash := loc.Query().Get("flash"); flash != "Object and 2 dependent object(s) deleted in draft" {
+		t.Errorf("flash = %q, want both dependents counted", flash)
+	}
+}
+
+func TestValidateRepositoryFlagsUniqueConstraintOnArrayField(t *testing.T) {
+	repo := newTestRepository(t)
+	editTestJSON(t, filepath.Join(repo.Root, "config", "constraints.json"), func(doc map[string]any) {
+		doc["unique"] = append(doc["unique"].([]any), map[string]any{"type": "service", "field": "ports"})
+	})
+
+	result, err := ValidateRepository(repo.Root, repo.YAMLOptions)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var found []ValidationIssue
+	for _, issue := range result.Issues {
+		if issue.Path == "config/constraints.json" {
+			found = append(found, issue)
+		}
+	}
+	if len(found) != 1 || found[0].Field != "unique[2].field" || !strings.Contains(found[0].Message, "service.ports is an array") {
+		t.Errorf("constraint issues = %v, want unique[2].field flagged as an array", found)
+	}
+
+	// Scalar fields, including the sample constraints, are not flagged.
+	if issues := ValidateConstraintFields(Constraints{Unique: []UniqueConstraint{{Type: "service", Field: "name"}}}, map[string]Schema{
+		"service": {Properties: map[string]SchemaProperty{"name": {Type: "string"}}},
+	}); len(issues) != 0 {
+		t.Errorf("scalar unique constraint flagged: %v", issues)
+	}
+}
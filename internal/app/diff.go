@@ -0,0 +1,161 @@
+package app
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// ObjectDiff is the field-level difference of one object between main and a
+// workspace.
+type ObjectDiff struct {
+	Type   string      `json:"type"`
+	ID     string      `json:"id"`
+	Status string      `json:"status"`
+	Fields []fieldDiff `json:"fields"`
+}
+
+// DiffWorkspace compares every data file the workspace changed, saved or
+// draft, against main. Objects whose fields are unchanged (for example
+// formatting-only edits) are omitted. Results are sorted by type, then id.
+func (r *Repository) DiffWorkspace(name string) ([]ObjectDiff, error) {
+	if !r.WorkspaceExists(name) {
+		return nil, fmt.Errorf("workspace %q not found", name)
+	}
+	path := r.WorkspacePath(name)
+	committed, err := r.diffWorkspaceDataFiles(r.BranchForWorkspace(name))
+	if err != nil {
+		return nil, err
+	}
+	drafts, err := r.ChangedFiles(path)
+	if err != nil {
+		return nil, err
+	}
+	files := map[string]struct{}{}
+	for _, rel := range append(committed, drafts...) {
+		if strings.HasPrefix(rel, "data/") && strings.HasSuffix(rel, ".yaml") {
+			files[rel] = struct{}{}
+		}
+	}
+
+	diffs := make([]ObjectDiff, 0, len(files))
+	for rel := range files {
+		typeName, id, ok := parseDataObjectPath(rel)
+		if !ok {
+			continue
+		}
+		mainData, onMain := r.readObjectAtRef(r.BaseBranch, rel)
+		var wsData map[string]any
+		obj, err := r.ReadObject(path, typeName, id)
+		switch {
+		case err == nil:
+			wsData = obj.Data
+		case !errors.Is(err, os.ErrNotExist):
+			return nil, fmt.Errorf("%s: %w", rel, err)
+		}
+		if !onMain && wsData == nil {
+			continue
+		}
+		diff := ObjectDiff{Type: typeName, ID: id, Status: "modified", Fields: computeDiffs(mainData, wsData)}
+		switch {
+		case !onMain:
+			diff.Status = "added"
+		case wsData == nil:
+			diff.Status = "deleted"
+		}
+		if len(diff.Fields) == 0 && diff.Status == "modified" {
+			continue
+		}
+		diffs = append(diffs, diff)
+	}
+	sort.Slice(diffs, func(i, j int) bool {
+		if diffs[i].Type == diffs[j].Type {
+			return diffs[i].ID < diffs[j].ID
+		}
+		return diffs[i].Type < diffs[j].Type
+	})
+	return diffs, nil
+}
+
+// WriteDiffText prints one header line per object followed by its changed
+// fields: "+" added, "-" removed, "~" modified (main -> workspace).
+func WriteDiffText(w io.Writer, diffs []ObjectDiff) error {
+	if len(diffs) == 0 {
+		_, err := fmt.Fprintln(w, "no differences from main")
+		return err
+	}
+	for _, d := range diffs {
+		if _, err := fmt.Fprintf(w, "%s/%s (%s)\n", d.Type, d.ID, d.Status); err != nil {
+			return err
+		}
+		for _, f := range d.Fields {
+			var err error
+			switch f.Status {
+			case "added":
+				_, err = fmt.Fprintf(w, "  + %s: %s\n", f.Field, f.Workspace)
+			case "removed":
+				_, err = fmt.Fprintf(w, "  - %s: %s\n", f.Field, f.Main)
+			default:
+				_, err = fmt.Fprintf(w, "  ~ %s: %s -> %s\n", f.Field, f.Main, f.Workspace)
+			}
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func WriteDiffJSON(w io.Writer, diffs []ObjectDiff) error {
+	b, err := json.MarshalIndent(diffs, "", "  ")
+	if err != nil {
+		return err
+	}
+	b = append(b, '\n')
+	_, err = w.Write(b)
+	return err
+}
+
+// diffFileName is the document written by export --diff-json.
+const diffFileName = "diff.json"
+
+// diffDocument groups a workspace's object diffs by type, then id, for review
+// tooling.
+type diffDocument struct {
+	Workspace string                                  `json:"workspace"`
+	Base      string                                  `json:"base"`
+	Types     map[string]map[string]diffDocumentEntry `json:"types"`
+}
+
+type diffDocumentEntry struct {
+	Status string      `json:"status"`
+	Fields []fieldDiff `json:"fields"`
+}
+
+// ExportWorkspaceDiff writes the DiffWorkspace result for name to
+// <outDir>/diff.json and returns the written path.
+func ExportWorkspaceDiff(r *Repository, name, outDir string) (string, error) {
+	diffs, err := r.DiffWorkspace(name)
+	if err != nil {
+		return "", err
+	}
+	doc := diffDocument{Workspace: name, Base: r.BaseBranch, Types: map[string]map[string]diffDocumentEntry{}}
+	for _, d := range diffs {
+		if doc.Types[d.Type] == nil {
+			doc.Types[d.Type] = map[string]diffDocumentEntry{}
+		}
+		doc.Types[d.Type][d.ID] = diffDocumentEntry{Status: d.Status, Fields: d.Fields}
+	}
+	b, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	b = append(b, '\n')
+	path := filepath.Join(outDir, diffFileName)
+	return path, r.writeFileAll(path, b)
+}
@@ -0,0 +1,123 @@
+package app
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestExportWorkspaceDiffCapturesFieldChanges(t *testing.T) {
+	repo := newTestRepository(t)
+	ws := newTestWorkspace(t, repo, "draft")
+	addTestSchemaField(t, ws, "service", "owner")
+	writeTestFile(t, filepath.Join(ws, "data", "service", testServiceID+".yaml"),
+		"_id: "+testServiceID+"\n_type: service\nname: edge-gateway\nowner: ops\nteamId: "+testTeamID+"\ntier: core\n")
+
+	path, err := ExportWorkspaceDiff(repo, "draft", t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var doc diffDocument
+	if err := json.Unmarshal(b, &doc); err != nil {
+		t.Fatal(err)
+	}
+	if doc.Workspace != "draft" || doc.Base != repo.BaseBranch || len(doc.Types) != 1 {
+		t.Fatalf("doc = %+v, want only the service of draft against %s", doc, repo.BaseBranch)
+	}
+	entry, ok := doc.Types["service"][testServiceID]
+	if !ok || entry.Status != "modified" {
+		t.Fatalf("service entry = %+v, want a modified object", entry)
+	}
+	want := []fieldDiff{
+		{Field: "owner", Workspace: "ops", Status: "added"},
+		{Field: "ports", Main: "443, 8443", Status: "removed"},
+		{Field: "tier", Main: "edge", Workspace: "core", Status: "modified"},
+	}
+	if !reflect.DeepEqual(entry.Fields, want) {
+		t.Errorf("fields = %+v, want %+v", entry.Fields, want)
+	}
+}
+
+func TestDiffWorkspaceReportsAddedDeletedAndModified(t *testing.T) {
+	repo := newTestRepository(t)
+	ws := newTestWorkspace(t, repo, "draft")
+	writeTestFile(t, filepath.Join(ws, "data", "team", testTeamID+".yaml"),
+		"_id: "+testTeamID+"\n_type: team\ncode: PLAT\nname: Platform Core\n")
+	saveTestWorkspace(t, repo, "draft")
+	newTeam := testObjectID(3)
+	writeTestFile(t, filepath.Join(ws, "data", "team", newTeam+".yaml"),
+		"_id: "+newTeam+"\n_type: team\ncode: DATA\nname: Data\n")
+	if err := os.Remove(filepath.Join(ws, "data", "service", testServiceID+".yaml")); err != nil {
+		t.Fatal(err)
+	}
+
+	diffs, err := repo.DiffWorkspace("draft")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, d := range diffs {
+		got = append(got, d.Type+"/"+d.ID+" "+d.Status)
+	}
+	want := []string{
+		"service/" + testServiceID + " deleted",
+		"team/" + testTeamID + " modified",
+		"team/" + newTeam + " added",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("diffs = %v, want %v", got, want)
+	}
+	if fields, want := diffs[1].Fields, []fieldDiff{{Field: "name", Main: "Platform", Workspace: "Platform Core", Status: "modified"}}; !reflect.DeepEqual(fields, want) {
+		t.Errorf("modified fields = %+v, want %+v", fields, want)
+	}
+	for _, f := range diffs[0].Fields {
+		if f.Status != "removed" || f.Workspace != "" {
+			t.Errorf("deleted object field %+v, want only removals", f)
+		}
+	}
+	for _, f := range diffs[2].Fields {
+		if f.Status != "added" || f.Main != "" {
+			t.Errorf("added object field %+v, want only additions", f)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := WriteDiffText(&buf, diffs[1:2]); err != nil {
+		t.Fatal(err)
+	}
+	if want := "team/" + testTeamID + " (modified)\n  ~ name: Platform -> Platform Core\n"; buf.String() != want {
+		t.Errorf("text = %q, want %q", buf.String(), want)
+	}
+}
+
+func TestDiffWorkspaceOmitsFormattingOnlyChanges(t *testing.T) {
+	repo := newTestRepository(t)
+	ws := newTestWorkspace(t, repo, "draft")
+	writeTestFile(t, filepath.Join(ws, "data", "team", testTeamID+".yaml"),
+		"name: Platform\ncode: PLAT\n_type: team\n_id: "+testTeamID+"\n")
+
+	diffs, err := repo.DiffWorkspace("draft")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(diffs) != 0 {
+		t.Errorf("diffs = %+v, want none for reordered keys", diffs)
+	}
+	var buf bytes.Buffer
+	if err := WriteDiffText(&buf, diffs); err != nil {
+		t.Fatal(err)
+	}
+	if buf.String() != "no differences from main\n" {
+		t.Errorf("text = %q", buf.String())
+	}
+	if _, err := repo.DiffWorkspace("missing"); err == nil {
+		t.Error("diff of a missing workspace succeeded")
+	}
+}
@@ -0,0 +1,76 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// LoadEnum reads the sorted values of config/enums/<name>.json. It does not
+// cache: ResolveEnumRefs reads each enum once per call, and the web server's
+// configCache keeps resolved schemas until an enum file changes.
+func LoadEnum(root, name string) ([]string, error) {
+	if name == "" || strings.ContainsAny(name, `/\`) || strings.HasPrefix(name, ".") {
+		return nil, fmt.Errorf("invalid enum name %q", name)
+	}
+	b, err := os.ReadFile(filepath.Join(root, "config", "enums", name+".json"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("missing enum file config/enums/%s.json", name)
+		}
+		return nil, err
+	}
+	var values []string
+	if err := json.Unmarshal(b, &values); err != nil {
+		return nil, fmt.Errorf("parse enum %s: %w", name, err)
+	}
+	sort.Strings(values)
+	return values, nil
+}
+
+// ResolveEnumRefs returns a copy of schemas in which every enumRef property
+// holds the values of its enum file. The schemas passed in, and their
+// property maps, are left untouched, so cached schemas can be resolved
+// safely.
+func ResolveEnumRefs(root string, schemas map[string]Schema) (map[string]Schema, []ValidationIssue) {
+	resolved := make(map[string]Schema, len(schemas))
+	issues := make([]ValidationIssue, 0)
+	loaded := map[string][]string{}
+	loadErrs := map[string]error{}
+	for _, t := range sortedKeys(schemas) {
+		schema := schemas[t]
+		var props map[string]SchemaProperty
+		for _, field := range sortedKeys(schema.Properties) {
+			prop := schema.Properties[field]
+			if prop.EnumRef == "" {
+				continue
+			}
+			values, ok := loaded[prop.EnumRef]
+			err := loadErrs[prop.EnumRef]
+			if !ok && err == nil {
+				values, err = LoadEnum(root, prop.EnumRef)
+				loaded[prop.EnumRef], loadErrs[prop.EnumRef] = values, err
+			}
+			if err != nil {
+				issues = append(issues, ValidationIssue{Stage: "schema", Path: "config/schemas/" + t + ".schema.json", Field: field, Message: err.Error()})
+				continue
+			}
+			if props == nil {
+				props = make(map[string]SchemaProperty, len(schema.Properties))
+				for k, v := range schema.Properties {
+					props[k] = v
+				}
+			}
+			prop.Enum = append([]string(nil), values...)
+			props[field] = prop
+		}
+		if props != nil {
+			schema.Properties = props
+		}
+		resolved[t] = schema
+	}
+	return resolved, issues
+}
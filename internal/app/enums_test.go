@@ -0,0 +1,76 @@
+package app
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestValidateRepositoryResolvesEnumRefs(t *testing.T) {
+	repo := newTestRepository(t)
+	schemaPath := filepath.Join(repo.Root, "config", "schemas", "service.schema.json")
+	writeTestFile(t, filepath.Join(repo.Root, "config", "enums", "regions.json"), `["us-east", "eu-west"]`+"\n")
+	editTestJSON(t, schemaPath, func(doc map[string]any) {
+		doc["properties"].(map[string]any)["region"] = map[string]any{"type": "string", "enumRef": "regions"}
+	})
+	servicePath := filepath.Join(repo.Root, "data", "service", testServiceID+".yaml")
+	writeService := func(region string) {
+		writeTestFile(t, servicePath, "_id: "+testServiceID+"\n_type: service\nname: edge-gateway\nregion: "+region+"\nteamId: "+testTeamID+"\ntier: edge\n")
+	}
+	regionIssues := func() []string {
+		t.Helper()
+		result, err := ValidateRepository(repo.Root, repo.YAMLOptions)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var messages []string
+		for _, issue := range result.Issues {
+			if issue.Field == "region" {
+				messages = append(messages, issue.Message)
+			}
+		}
+		return messages
+	}
+
+	writeService("eu-west")
+	if got := regionIssues(); len(got) != 0 {
+		t.Errorf("referenced value: issues %v, want none", got)
+	}
+	writeService("mars")
+	if got := regionIssues(); len(got) != 1 || got[0] != "value must be one of enum values" {
+		t.Errorf("unlisted value: issues %v, want the enum issue", got)
+	}
+
+	editTestJSON(t, schemaPath, func(doc map[string]any) {
+		doc["properties"].(map[string]any)["region"] = map[string]any{"type": "string", "enumRef": "zones"}
+	})
+	writeService("eu-west")
+	if got := regionIssues(); len(got) != 1 || !strings.Contains(got[0], "missing enum file config/enums/zones.json") {
+		t.Errorf("missing ref: issues %v, want the missing enum file reported", got)
+	}
+}
+
+func TestResolveEnumRefsLeavesInputSchemasUntouched(t *testing.T) {
+	repo := newTestRepository(t)
+	writeTestFile(t, filepath.Join(repo.Root, "config", "enums", "regions.json"), `["us-east", "eu-west"]`+"\n")
+	schemas := map[string]Schema{
+		"service": {Properties: map[string]SchemaProperty{
+			"home":   {Type: "string", EnumRef: "regions"},
+			"backup": {Type: "string", EnumRef: "regions"},
+			"name":   {Type: "string"},
+		}},
+	}
+
+	resolved, issues := ResolveEnumRefs(repo.Root, schemas)
+	if len(issues) != 0 {
+		t.Fatalf("issues = %v, want none", issues)
+	}
+	for _, field := range []string{"home", "backup"} {
+		if got := resolved["service"].Properties[field].Enum; strings.Join(got, ",") != "eu-west,us-east" {
+			t.Errorf("resolved %s enum = %v, want the sorted regions", field, got)
+		}
+		if got := schemas["service"].Properties[field].Enum; got != nil {
+			t.Errorf("input %s enum = %v, want it left unresolved", field, got)
+		}
+	}
+}
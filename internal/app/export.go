@@ -1,15 +1,114 @@
 package app
 
 import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
 	"sort"
+	"strings"
 )
 
-func ExportRepository(root, outDir string) error {
-	result, err := ValidateRepository(root)
+const (
+	ExportFormatJSON = "json"
+	ExportFormatCSV  = "csv"
+
+	defaultCSVSeparator = ";"
+
+	ExportLayoutTypes   = "types"
+	ExportLayoutObjects = "objects"
+)
+
+type ExportOptions struct {
+	Fields    map[string][]string
+	WithIDs   bool
+	Overrides Overrides
+	// Format is ExportFormatJSON (the default) or ExportFormatCSV.
+	Format string
+	// Separator joins array items in CSV cells; defaults to ";".
+	Separator string
+	// Layout is ExportLayoutTypes (the default, one array file per type) or
+	// ExportLayoutObjects (<type>/<id>.json per object, JSON only).
+	Layout string
+	// Files sets how object files are read and the permissions of the
+	// written artifacts.
+	Files FileOptions
+}
+
+func ExportRepository(root, outDir string, opts ExportOptions) error {
+	opts.Overrides = Overrides{}
+	return exportRepository(root, outDir, opts)
+}
+
+// ExportRepositoryCSV writes one <type>.csv per type instead of JSON arrays.
+func ExportRepositoryCSV(root, outDir string, opts ExportOptions) error {
+	opts.Format = ExportFormatCSV
+	return ExportRepository(root, outDir, opts)
+}
+
+// exportExtension is the file extension of per-type artifacts for format.
+func exportExtension(format string) string {
+	if format == ExportFormatCSV {
+		return ".csv"
+	}
+	return ".json"
+}
+
+func ExportWorkspace(workspacePath, outDir string, opts ExportOptions) error {
+	overrides, err := LoadOverrides(workspacePath)
+	if err != nil {
+		return err
+	}
+	opts.Overrides = overrides
+	return exportRepository(workspacePath, outDir, opts)
+}
+
+// ParseExportFields parses --fields values of the form type:field1,field2.
+func ParseExportFields(specs []string) (map[string][]string, error) {
+	fields := make(map[string][]string, len(specs))
+	for _, spec := range specs {
+		typeName, list, ok := strings.Cut(spec, ":")
+		typeName = strings.TrimSpace(typeName)
+		if !ok || typeName == "" {
+			return nil, fmt.Errorf("invalid field list %q (expected type:field1,field2)", spec)
+		}
+		if _, dup := fields[typeName]; dup {
+			return nil, fmt.Errorf("field list for type %q given more than once", typeName)
+		}
+		names := make([]string, 0)
+		for _, name := range strings.Split(list, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				names = append(names, name)
+			}
+		}
+		if len(names) == 0 {
+			return nil, fmt.Errorf("field list for type %q is empty", typeName)
+		}
+		fields[typeName] = names
+	}
+	return fields, nil
+}
+
+func exportRepository(root, outDir string, opts ExportOptions) error {
+	switch opts.Format {
+	case "", ExportFormatJSON, ExportFormatCSV:
+	default:
+		return fmt.Errorf("unknown export format %q", opts.Format)
+	}
+	switch opts.Layout {
+	case "", ExportLayoutTypes:
+	case ExportLayoutObjects:
+		if opts.Format == ExportFormatCSV {
+			return fmt.Errorf("layout %q supports only the json format", opts.Layout)
+		}
+	default:
+		return fmt.Errorf("unknown export layout %q", opts.Layout)
+	}
+	result, err := ValidateRepository(root, opts.Files.YAMLOptions)
 	if err != nil {
 		return err
 	}
@@ -21,21 +120,34 @@ func ExportRepository(root, outDir string) error {
 	if err != nil {
 		return err
 	}
-	objectsByType, err := LoadObjects(root)
+	objectsByType, err := opts.Files.LoadObjects(root)
 	if err != nil {
 		return err
 	}
 
-	if err := os.MkdirAll(outDir, 0o755); err != nil {
-		return err
+	allowed := make(map[string]map[string]bool, len(opts.Fields))
+	for _, typeName := range sortedKeys(opts.Fields) {
+		names := opts.Fields[typeName]
+		schema, ok := schemas[typeName]
+		if !ok {
+			return fmt.Errorf("--fields: unknown type %q", typeName)
+		}
+		allowed[typeName] = make(map[string]bool, len(names))
+		for _, name := range names {
+			if _, ok := schema.Properties[name]; !ok {
+				return fmt.Errorf("--fields: unknown field %q for type %q", name, typeName)
+			}
+			allowed[typeName][name] = true
+		}
 	}
 
-	types := make([]string, 0, len(schemas))
-	for t := range schemas {
-		types = append(types, t)
+	if err := opts.Files.mkdirAll(outDir); err != nil {
+		return err
 	}
-	sort.Strings(types)
 
+	types := sortedKeys(schemas)
+
+	replacer := opts.Overrides.replacer()
 	for _, t := range types {
 		objs := objectsByType[t]
 		sort.Slice(objs, func(i, j int) bool {
@@ -44,23 +156,345 @@ func ExportRepository(root, outDir string) error {
 		rows := make([]map[string]any, 0, len(objs))
 		for _, obj := range objs {
 			row := make(map[string]any, len(obj.Data))
+			if opts.WithIDs || opts.Format == ExportFormatCSV {
+				row["_id"] = obj.ID
+			}
 			for k, v := range obj.Data {
 				if k == "_id" || k == "_type" {
 					continue
 				}
+				if keep, restricted := allowed[t]; restricted && !keep[k] {
+					continue
+				}
+				if replacer != nil {
+					v = applyOverrides(v, replacer)
+				}
 				row[k] = v
 			}
 			rows = append(rows, row)
 		}
-		b, err := json.MarshalIndent(rows, "", "  ")
+		if opts.Layout == ExportLayoutObjects {
+			if err := opts.Files.writeObjectExports(filepath.Join(outDir, t), objs, rows); err != nil {
+				return err
+			}
+			continue
+		}
+		var b []byte
+		if opts.Format == ExportFormatCSV {
+			b, err = marshalCSVRows(csvColumns(schemas[t], allowed[t]), rows, firstNonEmpty(opts.Separator, defaultCSVSeparator))
+		} else {
+			b, err = json.MarshalIndent(rows, "", "  ")
+			b = append(b, '\n')
+		}
+		if err != nil {
+			return err
+		}
+		if err := opts.Files.writeFile(filepath.Join(outDir, t+exportExtension(opts.Format)), b); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// writeObjectExports writes rows[i] to <dir>/<objs[i].ID>.json and removes
+// other .json files in dir, so the directory mirrors data/<type>/.
+func (o FileOptions) writeObjectExports(dir string, objs []Object, rows []map[string]any) error {
+	if err := o.mkdirAll(dir); err != nil {
+		return err
+	}
+	current := make(map[string]bool, len(objs))
+	for i, obj := range objs {
+		b, err := json.MarshalIndent(rows[i], "", "  ")
 		if err != nil {
 			return err
 		}
 		b = append(b, '\n')
-		if err := os.WriteFile(filepath.Join(outDir, t+".json"), b, 0o644); err != nil {
+		name := obj.ID + ".json"
+		current[name] = true
+		if err := o.writeFile(filepath.Join(dir, name), b); err != nil {
+			return err
+		}
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if !entry.Type().IsRegular() || !strings.HasSuffix(entry.Name(), ".json") || current[entry.Name()] {
+			continue
+		}
+		if err := os.Remove(filepath.Join(dir, entry.Name())); err != nil {
 			return err
 		}
 	}
+	return nil
+}
+
+// csvColumns is _id followed by the schema's sorted property names, limited
+// to the allowlist when one is set. Object fields expand to one
+// "<field>.<child>" column per child property.
+func csvColumns(schema Schema, allowed map[string]bool) []string {
+	names := make([]string, 0, len(schema.Properties))
+	for name := range schema.Properties {
+		if allowed != nil && !allowed[name] {
+			continue
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	columns := []string{"_id"}
+	for _, name := range names {
+		prop := schema.Properties[name]
+		if prop.Type != "object" {
+			columns = append(columns, name)
+			continue
+		}
+		children := make([]string, 0, len(prop.Properties))
+		for child := range prop.Properties {
+			children = append(children, name+"."+child)
+		}
+		sort.Strings(children)
+		columns = append(columns, children...)
+	}
+	return columns
+}
+
+func marshalCSVRows(columns []string, rows []map[string]any, separator string) ([]byte, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	if err := w.Write(columns); err != nil {
+		return nil, err
+	}
+	record := make([]string, len(columns))
+	for _, row := range rows {
+		for i, column := range columns {
+			v, ok := row[column]
+			if parent, child, nested := strings.Cut(column, "."); nested {
+				m, _ := row[parent].(map[string]any)
+				v, ok = m[child]
+			}
+			record[i] = ""
+			if ok {
+				record[i] = csvCell(v, separator)
+			}
+		}
+		if err := w.Write(record); err != nil {
+			return nil, err
+		}
+	}
+	w.Flush()
+	return buf.Bytes(), w.Error()
+}
+
+func csvCell(v any, separator string) string {
+	arr, ok := v.([]any)
+	if !ok {
+		return valueToText(v)
+	}
+	parts := make([]string, 0, len(arr))
+	for _, item := range arr {
+		parts = append(parts, valueToText(item))
+	}
+	return strings.Join(parts, separator)
+}
+
+func ExportSchemas(root, outDir string, files FileOptions) error {
+	schemas, err := LoadSchemas(root)
+	if err != nil {
+		return err
+	}
+	schemas, issues := ResolveEnumRefs(root, schemas)
+	if len(issues) > 0 {
+		return fmt.Errorf("cannot export schemas: %s", issues[0].String())
+	}
+	if err := files.mkdirAll(outDir); err != nil {
+		return err
+	}
+	types := sortedKeys(schemas)
 
+	for _, t := range types {
+		b, err := json.MarshalIndent(schemaToJSONSchema(schemas[t]), "", "  ")
+		if err != nil {
+			return err
+		}
+		b = append(b, '\n')
+		if err := files.writeFile(filepath.Join(outDir, t+".json"), b); err != nil {
+			return err
+		}
+	}
 	return nil
 }
+
+func schemaToJSONSchema(schema Schema) map[string]any {
+	required := sortedKeys(schema.Required)
+
+	props := make(map[string]any, len(schema.Properties))
+	for field, p := range schema.Properties {
+		props[field] = jsonSchemaProperty(p)
+	}
+
+	return map[string]any{
+		"$schema":              "https://json-schema.org/draft/2020-12/schema",
+		"title":                schema.Type,
+		"type":                 "object",
+		"required":             required,
+		"properties":           props,
+		"additionalProperties": false,
+	}
+}
+
+func jsonSchemaProperty(p SchemaProperty) map[string]any {
+	prop := map[string]any{"type": p.Type}
+	if len(p.Enum) > 0 {
+		prop["enum"] = p.Enum
+	}
+	if len(p.EnumNumbers) > 0 {
+		prop["enum"] = p.EnumNumbers
+	}
+	if p.MinLength != nil {
+		prop["minLength"] = *p.MinLength
+	}
+	if p.MaxLength != nil {
+		prop["maxLength"] = *p.MaxLength
+	}
+	if p.Pattern != nil {
+		prop["pattern"] = *p.Pattern
+	}
+	if p.Format != "" {
+		prop["format"] = p.Format
+	}
+	if p.Default != nil {
+		prop["default"] = p.Default
+	}
+	if p.Minimum != nil {
+		prop["minimum"] = *p.Minimum
+	}
+	if p.Maximum != nil {
+		prop["maximum"] = *p.Maximum
+	}
+	if p.ExclusiveMinimum != nil {
+		prop["exclusiveMinimum"] = *p.ExclusiveMinimum
+	}
+	if p.ExclusiveMaximum != nil {
+		prop["exclusiveMaximum"] = *p.ExclusiveMaximum
+	}
+	if p.MultipleOf != nil {
+		prop["multipleOf"] = *p.MultipleOf
+	}
+	if p.Type == "array" {
+		prop["items"] = map[string]any{"type": p.ItemsType}
+		if p.MinItems != nil {
+			prop["minItems"] = *p.MinItems
+		}
+		if p.MaxItems != nil {
+			prop["maxItems"] = *p.MaxItems
+		}
+		if p.UniqueItems {
+			prop["uniqueItems"] = true
+		}
+	}
+	if p.Type == "object" {
+		props := make(map[string]any, len(p.Properties))
+		for name, child := range p.Properties {
+			props[name] = jsonSchemaProperty(child)
+		}
+		prop["properties"] = props
+		prop["required"] = sortedKeys(p.Required)
+		prop["additionalProperties"] = false
+	}
+	return prop
+}
+
+// PruneExport removes files with extension ext directly under outDir whose
+// name does not match a current schema type.
+func PruneExport(root, outDir, ext string) ([]string, error) {
+	schemas, err := LoadSchemas(root)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := os.ReadDir(outDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	removed := make([]string, 0)
+	for _, entry := range entries {
+		if !entry.Type().IsRegular() || !strings.HasSuffix(entry.Name(), ext) {
+			continue
+		}
+		if _, ok := schemas[strings.TrimSuffix(entry.Name(), ext)]; ok {
+			continue
+		}
+		path := filepath.Join(outDir, entry.Name())
+		if err := os.Remove(path); err != nil {
+			return removed, err
+		}
+		removed = append(removed, path)
+	}
+	return removed, nil
+}
+
+const manifestFileName = "manifest.json"
+
+type exportManifest struct {
+	Files []manifestEntry `json:"files"`
+}
+
+type manifestEntry struct {
+	Path   string `json:"path"`
+	SHA256 string `json:"sha256"`
+}
+
+func WriteExportManifest(root, outDir string, opts ExportOptions, includeSchemas bool) error {
+	schemas, err := LoadSchemas(root)
+	if err != nil {
+		return err
+	}
+	objectLayout := opts.Layout == ExportLayoutObjects
+	if _, ok := schemas[strings.TrimSuffix(manifestFileName, ".json")]; ok && exportExtension(opts.Format) == ".json" && !objectLayout {
+		return fmt.Errorf("cannot write %s: it collides with the export of type %q", manifestFileName, "manifest")
+	}
+	types := sortedKeys(schemas)
+
+	paths := make([]string, 0, len(types)*2)
+	for _, t := range types {
+		if !objectLayout {
+			paths = append(paths, t+exportExtension(opts.Format))
+			continue
+		}
+		entries, err := os.ReadDir(filepath.Join(outDir, t))
+		if err != nil {
+			return err
+		}
+		for _, entry := range entries {
+			if entry.Type().IsRegular() && strings.HasSuffix(entry.Name(), ".json") {
+				paths = append(paths, t+"/"+entry.Name())
+			}
+		}
+	}
+	if includeSchemas {
+		for _, t := range types {
+			paths = append(paths, "schemas/"+t+".json")
+		}
+	}
+
+	manifest := exportManifest{Files: make([]manifestEntry, 0, len(paths))}
+	for _, rel := range paths {
+		b, err := os.ReadFile(filepath.Join(outDir, filepath.FromSlash(rel)))
+		if err != nil {
+			return err
+		}
+		sum := sha256.Sum256(b)
+		manifest.Files = append(manifest.Files, manifestEntry{Path: rel, SHA256: hex.EncodeToString(sum[:])})
+	}
+	b, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	b = append(b, '\n')
+	return opts.Files.writeFile(filepath.Join(outDir, manifestFileName), b)
+}
@@ -0,0 +1,294 @@
+package app
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestExportSchemasWritesJSONSchema(t *testing.T) {
+	repo := newTestRepository(t)
+	out := filepath.Join(t.TempDir(), "schemas")
+	if err := ExportSchemas(repo.Root, out, repo.FileOptions); err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := os.ReadFile(filepath.Join(out, "service.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var doc struct {
+		Type                 string                    `json:"type"`
+		Required             []string                  `json:"required"`
+		Properties           map[string]map[string]any `json:"properties"`
+		AdditionalProperties bool                      `json:"additionalProperties"`
+	}
+	if err := json.Unmarshal(b, &doc); err != nil {
+		t.Fatalf("parse exported schema: %v", err)
+	}
+	if doc.Type != "object" || doc.AdditionalProperties {
+		t.Errorf("type = %q, additionalProperties = %v, want a closed object", doc.Type, doc.AdditionalProperties)
+	}
+	if want := []string{"name", "teamId", "tier"}; !reflect.DeepEqual(doc.Required, want) {
+		t.Errorf("required = %v, want %v", doc.Required, want)
+	}
+	for _, field := range []string{"name", "teamId", "tier", "ports"} {
+		if _, ok := doc.Properties[field]; !ok {
+			t.Errorf("properties misses %s", field)
+		}
+	}
+	var enum []string
+	for _, v := range doc.Properties["tier"]["enum"].([]any) {
+		enum = append(enum, v.(string))
+	}
+	sort.Strings(enum)
+	if want := []string{"batch", "core", "edge"}; !reflect.DeepEqual(enum, want) {
+		t.Errorf("tier enum = %v, want %v", enum, want)
+	}
+	ports := doc.Properties["ports"]
+	if ports["type"] != "array" || !reflect.DeepEqual(ports["items"], map[string]any{"type": "integer"}) {
+		t.Errorf("ports = %v, want an array of integers", ports)
+	}
+
+	// A second export writes identical bytes.
+	if err := ExportSchemas(repo.Root, out, repo.FileOptions); err != nil {
+		t.Fatal(err)
+	}
+	again, err := os.ReadFile(filepath.Join(out, "service.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(again) != string(b) {
+		t.Error("exporting schemas twice produced different output")
+	}
+}
+
+func TestPruneExportRemovesOnlyStaleTypeFiles(t *testing.T) {
+	repo := newTestRepository(t)
+	out := t.TempDir()
+	for _, name := range []string{"team.json", "service.json", "retired.json", "notes.txt"} {
+		writeTestFile(t, filepath.Join(out, name), "[]\n")
+	}
+	writeTestFile(t, filepath.Join(out, "schemas", "retired.json"), "{}\n")
+
+	removed, err := PruneExport(repo.Root, out, ".json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{filepath.Join(out, "retired.json")}; !reflect.DeepEqual(removed, want) {
+		t.Errorf("removed = %v, want %v", removed, want)
+	}
+	for _, name := range []string{"team.json", "service.json", "notes.txt", "schemas/retired.json"} {
+		if _, err := os.Stat(filepath.Join(out, filepath.FromSlash(name))); err != nil {
+			t.Errorf("%s: %v, want it kept", name, err)
+		}
+	}
+}
+
+func TestWriteExportManifestChecksumsMatchFiles(t *testing.T) {
+	repo := newTestRepository(t)
+	out := t.TempDir()
+	opts := ExportOptions{Files: repo.FileOptions}
+	if err := ExportRepository(repo.Root, out, opts); err != nil {
+		t.Fatal(err)
+	}
+	if err := ExportSchemas(repo.Root, filepath.Join(out, "schemas"), repo.FileOptions); err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteExportManifest(repo.Root, out, opts, true); err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := os.ReadFile(filepath.Join(out, manifestFileName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var manifest exportManifest
+	if err := json.Unmarshal(b, &manifest); err != nil {
+		t.Fatal(err)
+	}
+	var paths []string
+	for _, entry := range manifest.Files {
+		paths = append(paths, entry.Path)
+		content, err := os.ReadFile(filepath.Join(out, filepath.FromSlash(entry.Path)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if sum := sha256.Sum256(content); hex.EncodeToString(sum[:]) != entry.SHA256 {
+			t.Errorf("%s: manifest checksum %s does not match the file", entry.Path, entry.SHA256)
+		}
+	}
+	if want := []string{"service.json", "team.json", "schemas/service.json", "schemas/team.json"}; !reflect.DeepEqual(paths, want) {
+		t.Errorf("manifest paths = %v, want %v", paths, want)
+	}
+
+	// Writing it again from the same export is byte-identical.
+	if err := WriteExportManifest(repo.Root, out, opts, true); err != nil {
+		t.Fatal(err)
+	}
+	if again, err := os.ReadFile(filepath.Join(out, manifestFileName)); err != nil {
+		t.Fatal(err)
+	} else if string(again) != string(b) {
+		t.Error("manifest changed between identical exports")
+	}
+}
+
+// readTestExport decodes the exported JSON array at path.
+func readTestExport(t *testing.T, path string) []map[string]any {
+	t.Helper()
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var rows []map[string]any
+	if err := json.Unmarshal(b, &rows); err != nil {
+		t.Fatalf("parse %s: %v", path, err)
+	}
+	return rows
+}
+
+func TestExportFieldsRestrictsOneType(t *testing.T) {
+	repo := newTestRepository(t)
+	fields, err := ParseExportFields([]string{"service: name, tier"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := t.TempDir()
+	if err := ExportRepository(repo.Root, out, ExportOptions{Fields: fields, WithIDs: true, Files: repo.FileOptions}); err != nil {
+		t.Fatal(err)
+	}
+
+	services := readTestExport(t, filepath.Join(out, "service.json"))
+	want := map[string]any{"_id": testServiceID, "name": "edge-gateway", "tier": "edge"}
+	if len(services) != 1 || !reflect.DeepEqual(services[0], want) {
+		t.Errorf("service rows = %v, want only %v", services, want)
+	}
+	teams := readTestExport(t, filepath.Join(out, "team.json"))
+	if len(teams) != 1 || teams[0]["code"] != "PLAT" || teams[0]["name"] != "Platform" {
+		t.Errorf("team rows = %v, want every field of the unrestricted type", teams)
+	}
+
+	fields, err = ParseExportFields([]string{"service:name,owner"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = ExportRepository(repo.Root, t.TempDir(), ExportOptions{Fields: fields, Files: repo.FileOptions})
+	if err == nil || !strings.Contains(err.Error(), `unknown field "owner" for type "service"`) {
+		t.Errorf("unknown field: error = %v", err)
+	}
+}
+
+func TestExportObjectLayoutWritesOneFilePerObject(t *testing.T) {
+	repo := newTestRepository(t)
+	out := t.TempDir()
+	writeTestFile(t, filepath.Join(out, "team", testObjectID(9)+".json"), "{}\n")
+	opts := ExportOptions{Layout: ExportLayoutObjects, WithIDs: true, Files: repo.FileOptions}
+	if err := ExportRepository(repo.Root, out, opts); err != nil {
+		t.Fatal(err)
+	}
+
+	var files []string
+	err := filepath.WalkDir(out, func(path string, d os.DirEntry, err error) error {
+		if err == nil && !d.IsDir() {
+			rel, _ := filepath.Rel(out, path)
+			files = append(files, filepath.ToSlash(rel))
+		}
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"service/" + testServiceID + ".json", "team/" + testTeamID + ".json"}
+	if !reflect.DeepEqual(files, want) {
+		t.Errorf("files = %v, want %v (the stale team file removed)", files, want)
+	}
+
+	first, err := os.ReadFile(filepath.Join(out, "team", testTeamID+".json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var team map[string]any
+	if err := json.Unmarshal(first, &team); err != nil {
+		t.Fatal(err)
+	}
+	if want := map[string]any{"_id": testTeamID, "code": "PLAT", "name": "Platform"}; !reflect.DeepEqual(team, want) {
+		t.Errorf("team file = %v, want %v", team, want)
+	}
+
+	if err := ExportRepository(repo.Root, out, opts); err != nil {
+		t.Fatal(err)
+	}
+	if again, err := os.ReadFile(filepath.Join(out, "team", testTeamID+".json")); err != nil {
+		t.Fatal(err)
+	} else if string(again) != string(first) {
+		t.Error("exporting twice produced different object files")
+	}
+	if err := ExportRepository(repo.Root, t.TempDir(), ExportOptions{Layout: ExportLayoutObjects, Format: ExportFormatCSV}); err == nil {
+		t.Error("object layout accepted the csv format")
+	}
+}
+
+func TestExportCSVQuotesCellsAndJoinsArrays(t *testing.T) {
+	repo := newTestRepository(t)
+	writeTestFile(t, filepath.Join(repo.Root, "data", "team", testTeamID+".yaml"),
+		"_id: "+testTeamID+"\n_type: team\ncode: PLAT\nname: 'Platform, \"Core\"'\n")
+	out := t.TempDir()
+	if err := ExportRepositoryCSV(repo.Root, out, ExportOptions{WithIDs: true, Files: repo.FileOptions}); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		file string
+		want string
+	}{
+		{"team.csv", "_id,code,name\n" + testTeamID + `,PLAT,"Platform, ""Core"""` + "\n"},
+		{"service.csv", "_id,name,ports,teamId,tier\n" + testServiceID + ",edge-gateway,443;8443," + testTeamID + ",edge\n"},
+	}
+	for _, tt := range tests {
+		b, err := os.ReadFile(filepath.Join(out, tt.file))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(b) != tt.want {
+			t.Errorf("%s =\n%s\nwant\n%s", tt.file, b, tt.want)
+		}
+	}
+
+	if err := ExportRepositoryCSV(repo.Root, out, ExportOptions{WithIDs: true, Separator: "|", Files: repo.FileOptions}); err != nil {
+		t.Fatal(err)
+	}
+	if b, err := os.ReadFile(filepath.Join(out, "service.csv")); err != nil {
+		t.Fatal(err)
+	} else if !strings.Contains(string(b), ",443|8443,") {
+		t.Errorf("service.csv with separator | =\n%s", b)
+	}
+}
+
+func TestCSVColumns(t *testing.T) {
+	schema := Schema{Properties: map[string]SchemaProperty{
+		"zone":     {Type: "string"},
+		"name":     {Type: "string"},
+		"endpoint": {Type: "object", Properties: map[string]SchemaProperty{"port": {Type: "integer"}, "host": {Type: "string"}}},
+	}}
+	tests := []struct {
+		name    string
+		allowed map[string]bool
+		want    []string
+	}{
+		{"all fields", nil, []string{"_id", "endpoint.host", "endpoint.port", "name", "zone"}},
+		{"allowlist", map[string]bool{"zone": true, "name": true}, []string{"_id", "name", "zone"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := csvColumns(schema, tt.allowed); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("csvColumns = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,120 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+// Default permissions of written files and directories, before the umask.
+const (
+	defaultFileMode os.FileMode = 0o644
+	defaultDirMode  os.FileMode = 0o755
+)
+
+// FileOptions control how worktreefoundry reads and writes files. Repository
+// embeds them, so every file access made for a repository goes through its
+// options.
+//
+// A zero FileMode or DirMode keeps the default permission, filtered by the
+// umask. A configured mode is applied exactly with chmod, to new and existing
+// files and directories alike.
+type FileOptions struct {
+	FileMode os.FileMode
+	DirMode  os.FileMode
+	YAMLOptions
+}
+
+// FileOptionsFromEnv reads the modes from WORKTREEFOUNDRY_FILE_MODE and
+// WORKTREEFOUNDRY_DIR_MODE, and the YAML options from yaml overridden by the
+// environment.
+func FileOptionsFromEnv(yaml YAMLOptions) (FileOptions, error) {
+	var o FileOptions
+	var err error
+	if o.YAMLOptions, err = yamlOptionsFromEnv(yaml); err != nil {
+		return FileOptions{}, err
+	}
+	for _, v := range []struct {
+		name string
+		mode *os.FileMode
+	}{{"WORKTREEFOUNDRY_FILE_MODE", &o.FileMode}, {"WORKTREEFOUNDRY_DIR_MODE", &o.DirMode}} {
+		raw := os.Getenv(v.name)
+		if raw == "" {
+			continue
+		}
+		mode, err := parseFileMode(raw)
+		if err != nil {
+			return FileOptions{}, fmt.Errorf("%s: %w", v.name, err)
+		}
+		*v.mode = mode
+	}
+	return o, nil
+}
+
+func parseFileMode(raw string) (os.FileMode, error) {
+	n, err := strconv.ParseUint(raw, 8, 32)
+	if err != nil || n == 0 || n > 0o777 {
+		return 0, fmt.Errorf("invalid octal permission %q", raw)
+	}
+	return os.FileMode(n), nil
+}
+
+func (o FileOptions) writeFile(path string, b []byte) error {
+	if err := os.WriteFile(path, b, firstNonZeroMode(o.FileMode, defaultFileMode)); err != nil {
+		return err
+	}
+	if o.FileMode != 0 {
+		return os.Chmod(path, o.FileMode)
+	}
+	return nil
+}
+
+// mkdirAll creates dir and any missing parents. With a configured DirMode,
+// dir and each directory created along the way get exactly that mode.
+func (o FileOptions) mkdirAll(dir string) error {
+	if o.DirMode == 0 {
+		return os.MkdirAll(dir, defaultDirMode)
+	}
+	created := []string{dir}
+	for d := filepath.Dir(dir); d != filepath.Dir(d); d = filepath.Dir(d) {
+		if _, err := os.Stat(d); err == nil {
+			break
+		}
+		created = append(created, d)
+	}
+	if err := os.MkdirAll(dir, o.DirMode); err != nil {
+		return err
+	}
+	for _, d := range created {
+		if err := os.Chmod(d, o.DirMode); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeFileAll is writeFile after creating the file's directory.
+func (o FileOptions) writeFileAll(path string, b []byte) error {
+	if err := o.mkdirAll(filepath.Dir(path)); err != nil {
+		return err
+	}
+	return o.writeFile(path, b)
+}
+
+func (o FileOptions) writeJSONFile(path string, value any) error {
+	b, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		return err
+	}
+	b = append(b, '\n')
+	return o.writeFileAll(path, b)
+}
+
+func firstNonZeroMode(mode, fallback os.FileMode) os.FileMode {
+	if mode != 0 {
+		return mode
+	}
+	return fallback
+}
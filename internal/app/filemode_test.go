@@ -0,0 +1,59 @@
+package app
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestConfiguredModesApplyToWrittenFilesAndDirectories(t *testing.T) {
+	t.Setenv("WORKTREEFOUNDRY_FILE_MODE", "0600")
+	t.Setenv("WORKTREEFOUNDRY_DIR_MODE", "0700")
+	repo := newTestRepository(t)
+	if repo.FileMode != 0o600 || repo.DirMode != 0o700 {
+		t.Fatalf("repository modes = %o/%o, want 600/700", repo.FileMode, repo.DirMode)
+	}
+	ws := newTestWorkspace(t, repo, "draft")
+	// data/team exists in the checkout with git's permissions; data/region
+	// is new.
+	team := Object{ID: "33333333-3333-4333-8333-333333333333", Type: "team", Data: map[string]any{"name": "Data", "code": "DATA"}}
+	region := Object{ID: "44444444-4444-4444-8444-444444444444", Type: "region", Data: map[string]any{"name": "West"}}
+	for _, obj := range []Object{team, region} {
+		if err := repo.WriteObject(ws, obj); err != nil {
+			t.Fatal(err)
+		}
+	}
+	out := filepath.Join(t.TempDir(), "out", "nested")
+	if err := ExportRepository(repo.Root, out, ExportOptions{Files: repo.FileOptions}); err != nil {
+		t.Fatal(err)
+	}
+
+	wantModes := map[string]os.FileMode{
+		filepath.Join(ws, "data", "team", team.ID+".yaml"):     0o600,
+		filepath.Join(ws, "data", "region", region.ID+".yaml"): 0o600,
+		filepath.Join(ws, "data", "team"):                      0o700,
+		filepath.Join(ws, "data", "region"):                    0o700,
+		filepath.Join(out, "team.json"):                        0o600,
+		out:                                                    0o700,
+		filepath.Dir(out):                                      0o700,
+		filepath.Join(repo.Root, "config", "ui.json"):          0o600,
+	}
+	for path, want := range wantModes {
+		st, err := os.Stat(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := st.Mode().Perm(); got != want {
+			t.Errorf("%s: mode %o, want %o", path, got, want)
+		}
+	}
+}
+
+func TestFileOptionsFromEnvRejectsInvalidModes(t *testing.T) {
+	for _, raw := range []string{"644x", "0", "1777", "rw-r--r--"} {
+		t.Setenv("WORKTREEFOUNDRY_FILE_MODE", raw)
+		if _, err := FileOptionsFromEnv(YAMLOptions{}); err == nil {
+			t.Errorf("WORKTREEFOUNDRY_FILE_MODE=%s: no error", raw)
+		}
+	}
+}
@@ -0,0 +1,118 @@
+package app
+
+import "strings"
+
+// formDescriptor is the client-consumable description of an object form. The
+// object page embeds it for client-side validation and the JSON API serves it
+// at /types/<type>/form, so both derive widgets from the live schema.
+type formDescriptor struct {
+	Type   string      `json:"type"`
+	Fields []formField `json:"fields"`
+}
+
+type formField struct {
+	Name             string         `json:"name"`
+	Widget           string         `json:"widget"`
+	Type             string         `json:"type"`
+	ItemsType        string         `json:"itemsType,omitempty"`
+	Required         bool           `json:"required"`
+	Enum             []any          `json:"enum,omitempty"`
+	MinLength        *int           `json:"minLength,omitempty"`
+	MaxLength        *int           `json:"maxLength,omitempty"`
+	Pattern          string         `json:"pattern,omitempty"`
+	Format           string         `json:"format,omitempty"`
+	Minimum          *float64       `json:"minimum,omitempty"`
+	Maximum          *float64       `json:"maximum,omitempty"`
+	ExclusiveMinimum *float64       `json:"exclusiveMinimum,omitempty"`
+	ExclusiveMaximum *float64       `json:"exclusiveMaximum,omitempty"`
+	MultipleOf       *float64       `json:"multipleOf,omitempty"`
+	MinItems         *int           `json:"minItems,omitempty"`
+	MaxItems         *int           `json:"maxItems,omitempty"`
+	Unique           bool           `json:"uniqueItems,omitempty"`
+	ForeignKey       *formReference `json:"foreignKey,omitempty"`
+	Fields           []formField    `json:"fields,omitempty"`
+}
+
+type formReference struct {
+	ValueField   string             `json:"valueField"`
+	DisplayField string             `json:"displayField"`
+	Options      []formReferenceOpt `json:"options"`
+}
+
+type formReferenceOpt struct {
+	Value   string `json:"value"`
+	Display string `json:"display"`
+}
+
+// buildFormDescriptor describes fields in the order and visibility produced by
+// schemaToFieldData, including foreign key options from enrichForeignKeys.
+func buildFormDescriptor(typeName string, schema Schema, fields []fieldData) formDescriptor {
+	return formDescriptor{Type: typeName, Fields: buildFormFields("", schema.Properties, fields)}
+}
+
+// buildFormFields describes fields whose names carry prefix ahead of their
+// property name, as object children do.
+func buildFormFields(prefix string, props map[string]SchemaProperty, fields []fieldData) []formField {
+	out := make([]formField, 0, len(fields))
+	for _, f := range fields {
+		prop := props[strings.TrimPrefix(f.Name, prefix)]
+		ff := formField{
+			Name:             f.Name,
+			Widget:           formWidget(f),
+			Type:             f.Type,
+			ItemsType:        f.ItemsType,
+			Required:         f.Required,
+			MinLength:        prop.MinLength,
+			MaxLength:        prop.MaxLength,
+			Pattern:          f.Pattern,
+			Format:           prop.Format,
+			Minimum:          prop.Minimum,
+			Maximum:          prop.Maximum,
+			ExclusiveMinimum: prop.ExclusiveMinimum,
+			ExclusiveMaximum: prop.ExclusiveMaximum,
+			MultipleOf:       prop.MultipleOf,
+			MinItems:         prop.MinItems,
+			MaxItems:         prop.MaxItems,
+			Unique:           prop.UniqueItems,
+		}
+		for _, v := range prop.Enum {
+			ff.Enum = append(ff.Enum, v)
+		}
+		for _, n := range prop.EnumNumbers {
+			ff.Enum = append(ff.Enum, n)
+		}
+		if f.ForeignKey != nil {
+			ref := &formReference{
+				ValueField:   f.ForeignKey.ValueField,
+				DisplayField: f.ForeignKey.DisplayField,
+				Options:      make([]formReferenceOpt, 0, len(f.ForeignKey.Options)),
+			}
+			for _, o := range f.ForeignKey.Options {
+				ref.Options = append(ref.Options, formReferenceOpt{Value: o.Value, Display: o.Display})
+			}
+			ff.ForeignKey = ref
+		}
+		if f.Type == "object" {
+			ff.Fields = buildFormFields(f.Name+".", prop.Properties, f.Children)
+		}
+		out = append(out, ff)
+	}
+	return out
+}
+
+func formWidget(f fieldData) string {
+	switch {
+	case f.ForeignKey != nil:
+		return "reference"
+	case len(f.Enum) > 0:
+		return "select"
+	case f.Type == "string":
+		return "text"
+	case f.Type == "number" || f.Type == "integer":
+		return "number"
+	case f.Type == "array":
+		return "list"
+	default:
+		return f.Type
+	}
+}
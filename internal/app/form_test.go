@@ -0,0 +1,68 @@
+package app
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFormDescriptorReflectsEnumArrayAndForeignKeyFields(t *testing.T) {
+	repo := newTestRepository(t)
+	editTestJSON(t, filepath.Join(repo.Root, "config", "schemas", "service.schema.json"), func(doc map[string]any) {
+		doc["properties"].(map[string]any)["location"] = map[string]any{
+			"type":     "object",
+			"required": []any{"zone"},
+			"properties": map[string]any{
+				"zone":  map[string]any{"type": "string", "enum": []any{"a", "b"}},
+				"racks": map[string]any{"type": "array", "items": map[string]any{"type": "integer"}, "maxItems": 4},
+			},
+		}
+	})
+	h := newTestHandler(t, repo)
+
+	var desc formDescriptor
+	if err := json.Unmarshal(getTestPage(t, h, "/w/main/api/types/service/form"), &desc); err != nil {
+		t.Fatal(err)
+	}
+	fields := map[string]formField{}
+	for _, f := range desc.Fields {
+		fields[f.Name] = f
+		for _, c := range f.Fields {
+			fields[c.Name] = c
+		}
+	}
+
+	tier := fields["tier"]
+	if tier.Widget != "select" || !tier.Required || len(tier.Enum) != 3 || tier.Enum[0] != "batch" {
+		t.Errorf("tier = %+v, want required select over batch, core, edge", tier)
+	}
+	ports := fields["ports"]
+	if ports.Widget != "list" || ports.Type != "array" || ports.ItemsType != "integer" {
+		t.Errorf("ports = %+v, want list of integers", ports)
+	}
+	team := fields["teamId"]
+	if team.Widget != "reference" || team.ForeignKey == nil || team.ForeignKey.ValueField != "_id" ||
+		len(team.ForeignKey.Options) != 1 || team.ForeignKey.Options[0].Value != "11111111-1111-4111-8111-111111111111" {
+		t.Errorf("teamId = %+v (foreign key %+v), want reference to the sample team", team, team.ForeignKey)
+	}
+
+	// Children are keyed by their dotted input names so the object page
+	// validates them like top-level fields.
+	if loc := fields["location"]; loc.Widget != "object" || len(loc.Fields) != 2 {
+		t.Errorf("location = %+v, want object with two children", loc)
+	}
+	if zone := fields["location.zone"]; zone.Widget != "select" || !zone.Required || len(zone.Enum) != 2 {
+		t.Errorf("location.zone = %+v, want required select", zone)
+	}
+	if racks := fields["location.racks"]; racks.ItemsType != "integer" || racks.MaxItems == nil || *racks.MaxItems != 4 {
+		t.Errorf("location.racks = %+v, want integer list with maxItems 4", racks)
+	}
+
+	page := string(getTestPage(t, h, "/w/main/types/service/new"))
+	for _, want := range []string{`"name":"location.zone"`, `name="field.location.zone"`} {
+		if !strings.Contains(page, want) {
+			t.Errorf("object page does not contain %s", want)
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package app
+
+import (
+	"errors"
+	"net/mail"
+	"net/url"
+	"time"
+)
+
+var stringFormats = map[string]func(string) error{
+	"email":     checkEmailFormat,
+	"date":      checkDateFormat,
+	"date-time": checkDateTimeFormat,
+	"uri":       checkURIFormat,
+	"uuid":      checkUUIDFormat,
+}
+
+func checkEmailFormat(s string) error {
+	addr, err := mail.ParseAddress(s)
+	if err != nil || addr.Address != s {
+		return errors.New("must be an email address")
+	}
+	return nil
+}
+
+func checkDateFormat(s string) error {
+	if _, err := time.Parse(time.DateOnly, s); err != nil {
+		return errors.New("must be a date (YYYY-MM-DD)")
+	}
+	return nil
+}
+
+func checkDateTimeFormat(s string) error {
+	if _, err := time.Parse(time.RFC3339, s); err != nil {
+		return errors.New("must be an RFC 3339 date-time")
+	}
+	return nil
+}
+
+func checkURIFormat(s string) error {
+	u, err := url.Parse(s)
+	if err != nil || u.Scheme == "" || (u.Host == "" && u.Opaque == "" && u.Path == "") {
+		return errors.New("must be an absolute URI")
+	}
+	return nil
+}
+
+func checkUUIDFormat(s string) error {
+	if !uuidPattern.MatchString(s) {
+		return errors.New("must be a UUID")
+	}
+	return nil
+}
@@ -0,0 +1,98 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+type RelationshipGraph struct {
+	Nodes []GraphNode `json:"nodes"`
+	Edges []GraphEdge `json:"edges"`
+}
+
+type GraphNode struct {
+	Type   string   `json:"type"`
+	Unique []string `json:"unique"`
+}
+
+type GraphEdge struct {
+	FromType  string `json:"fromType"`
+	FromField string `json:"fromField"`
+	ToType    string `json:"toType"`
+	ToField   string `json:"toField"`
+}
+
+func BuildRelationshipGraph(root string) (RelationshipGraph, error) {
+	schemas, err := LoadSchemas(root)
+	if err != nil {
+		return RelationshipGraph{}, err
+	}
+	constraints, err := LoadConstraints(root)
+	if err != nil {
+		return RelationshipGraph{}, err
+	}
+
+	unique := map[string][]string{}
+	for _, c := range constraints.Unique {
+		unique[c.Type] = append(unique[c.Type], c.Field)
+	}
+	types := sortedKeys(schemas)
+
+	g := RelationshipGraph{Nodes: make([]GraphNode, 0, len(types)), Edges: make([]GraphEdge, 0, len(constraints.ForeignKeys))}
+	for _, t := range types {
+		fields := append([]string{}, unique[t]...)
+		sort.Strings(fields)
+		g.Nodes = append(g.Nodes, GraphNode{Type: t, Unique: fields})
+	}
+	for _, fk := range constraints.ForeignKeys {
+		g.Edges = append(g.Edges, GraphEdge{FromType: fk.FromType, FromField: fk.FromField, ToType: fk.ToType, ToField: fk.ToField})
+	}
+	sort.Slice(g.Edges, func(i, j int) bool {
+		a, b := g.Edges[i], g.Edges[j]
+		if a.FromType != b.FromType {
+			return a.FromType < b.FromType
+		}
+		if a.FromField != b.FromField {
+			return a.FromField < b.FromField
+		}
+		return a.ToType < b.ToType
+	})
+	return g, nil
+}
+
+func WriteGraphDOT(w io.Writer, g RelationshipGraph) error {
+	var b strings.Builder
+	b.WriteString("digraph worktreefoundry {\n")
+	b.WriteString("  node [shape=box];\n")
+	for _, n := range g.Nodes {
+		label := n.Type
+		if len(n.Unique) > 0 {
+			label += "\\nunique: " + strings.Join(n.Unique, ", ")
+		}
+		fmt.Fprintf(&b, "  %s [label=%s];\n", strconv.Quote(n.Type), dotQuote(label))
+	}
+	for _, e := range g.Edges {
+		fmt.Fprintf(&b, "  %s -> %s [label=%s];\n", strconv.Quote(e.FromType), strconv.Quote(e.ToType), strconv.Quote(e.FromField+" -> "+e.ToField))
+	}
+	b.WriteString("}\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func WriteGraphJSON(w io.Writer, g RelationshipGraph) error {
+	b, err := json.MarshalIndent(g, "", "  ")
+	if err != nil {
+		return err
+	}
+	b = append(b, '\n')
+	_, err = w.Write(b)
+	return err
+}
+
+func dotQuote(label string) string {
+	return `"` + strings.ReplaceAll(label, `"`, `\"`) + `"`
+}
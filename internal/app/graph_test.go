@@ -0,0 +1,28 @@
+package app
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestWriteGraphDOTHasServiceToTeamEdge(t *testing.T) {
+	repo := newTestRepository(t)
+	g, err := BuildRelationshipGraph(repo.Root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out strings.Builder
+	if err := WriteGraphDOT(&out, g); err != nil {
+		t.Fatal(err)
+	}
+	dot := out.String()
+	for _, want := range []string{
+		`"service" -> "team" [label="teamId -> _id"];`,
+		`"team" [label="team\nunique: code"];`,
+		`"service" [label="service\nunique: name"];`,
+	} {
+		if !strings.Contains(dot, want) {
+			t.Errorf("DOT output misses %s\n%s", want, dot)
+		}
+	}
+}
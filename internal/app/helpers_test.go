@@ -0,0 +1,154 @@
+package app
+
+import (
+	"encoding/json"
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// newTestRepository initializes a sample repository in a temporary directory
+// and opens it.
+func newTestRepository(t *testing.T) *Repository {
+	t.Helper()
+	root := filepath.Join(t.TempDir(), "repo")
+	if err := InitializeRepository(root, false, true); err != nil {
+		t.Fatalf("initialize repository: %v", err)
+	}
+	repo, err := OpenRepository(root, "", "")
+	if err != nil {
+		t.Fatalf("open repository: %v", err)
+	}
+	return repo
+}
+
+// newTestWorkspace creates workspace name and returns its checkout path.
+func newTestWorkspace(t *testing.T, repo *Repository, name string) string {
+	t.Helper()
+	if err := repo.CreateWorkspace(name); err != nil {
+		t.Fatalf("create workspace %s: %v", name, err)
+	}
+	return repo.WorkspacePath(name)
+}
+
+// testObjectID returns a valid object id made of digit, such as
+// 33333333-3333-4333-8333-333333333333 for 3.
+func testObjectID(digit int) string {
+	d := strconv.Itoa(digit)
+	return strings.Repeat(d, 8) + "-" + strings.Repeat(d, 4) + "-4" + strings.Repeat(d, 3) + "-8" + strings.Repeat(d, 3) + "-" + strings.Repeat(d, 12)
+}
+
+func writeTestFile(t testing.TB, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// editTestJSON rewrites the JSON file at path after passing its decoded
+// content to edit.
+func editTestJSON(t *testing.T, path string, edit func(doc map[string]any)) {
+	t.Helper()
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var doc map[string]any
+	if err := json.Unmarshal(b, &doc); err != nil {
+		t.Fatalf("parse %s: %v", path, err)
+	}
+	edit(doc)
+	b, err = json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeTestFile(t, path, string(b)+"\n")
+}
+
+// addTestSchemaField adds an optional string property to the schema of
+// typeName under root.
+func addTestSchemaField(t *testing.T, root, typeName, field string) {
+	t.Helper()
+	editTestJSON(t, filepath.Join(root, "config", "schemas", typeName+".schema.json"), func(doc map[string]any) {
+		doc["properties"].(map[string]any)[field] = map[string]any{"type": "string"}
+	})
+}
+
+func saveTestWorkspace(t *testing.T, repo *Repository, name string) {
+	t.Helper()
+	if _, err := repo.SaveWorkspace(name, "test changes"); err != nil {
+		t.Fatalf("save workspace %s: %v", name, err)
+	}
+}
+
+// commitTestMain commits every change in the repository root directly to
+// the base branch.
+func commitTestMain(t *testing.T, repo *Repository, message string) {
+	t.Helper()
+	if _, err := repo.runGit(repo.Root, "add", "-A"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repo.runGit(repo.Root, "-c", "user.name=test", "-c", "user.email=test@local", "commit", "-m", message); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// newTestServer builds the web server of repo like StartWebServer does.
+func newTestServer(t *testing.T, repo *Repository) *webServer {
+	t.Helper()
+	tmpl, err := template.ParseFS(webAssets, "templates/*.html")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &webServer{repo: repo, templates: tmpl}
+}
+
+// newTestHandler serves the web UI of repo like StartWebServer does.
+func newTestHandler(t *testing.T, repo *Repository) http.Handler {
+	t.Helper()
+	return serveTestServer(newTestServer(t, repo))
+}
+
+func serveTestServer(server *webServer) http.Handler {
+	mux := http.NewServeMux()
+	server.routes(mux)
+	return mux
+}
+
+// getTestPage requests path and fails the test unless it answers 200 OK.
+func getTestPage(t *testing.T, h http.Handler, path string) []byte {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET %s: status %d\n%s", path, rec.Code, rec.Body.String())
+	}
+	return rec.Body.Bytes()
+}
+
+// postTestForm submits form to path and returns the response; handlers
+// answer form posts with a redirect carrying a flash message.
+func postTestForm(t *testing.T, h http.Handler, path string, form url.Values) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+// testInputTag returns the first <input> or <select> tag of page named name.
+func testInputTag(page, name string) string {
+	re := regexp.MustCompile(`<(?:input|select)[^>]*\bname="` + regexp.QuoteMeta(name) + `"[^>]*>`)
+	return re.FindString(page)
+}
@@ -0,0 +1,398 @@
+package app
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+const (
+	ImportFormatJSON = "json"
+	ImportFormatCSV  = "csv"
+)
+
+type ImportOptions struct {
+	// Upsert replaces stored objects whose _id matches a row.
+	Upsert bool
+	// Strict aborts the whole import on the first rejected row instead of
+	// skipping it.
+	Strict bool
+}
+
+type ImportResult struct {
+	Created  []string
+	Updated  []string
+	Rejected []ImportRejection
+}
+
+// ImportRejection is a row left out of a non-strict import. Row is 1-based.
+type ImportRejection struct {
+	Row    int
+	ID     string
+	Reason string
+}
+
+func (r ImportRejection) String() string {
+	if r.ID != "" {
+		return fmt.Sprintf("row %d (%s): %s", r.Row, r.ID, r.Reason)
+	}
+	return fmt.Sprintf("row %d: %s", r.Row, r.Reason)
+}
+
+// ParseImportRows decodes a JSON array of objects or a CSV file with a header
+// row. An empty format is inferred from the name's extension, defaulting to
+// JSON. CSV cells are kept as text; empty cells are omitted and a "parent.child"
+// header fills a field of an object property.
+func ParseImportRows(name, format string, b []byte) ([]map[string]any, error) {
+	if format == "" {
+		format = ImportFormatJSON
+		if strings.EqualFold(filepath.Ext(name), ".csv") {
+			format = ImportFormatCSV
+		}
+	}
+	switch format {
+	case ImportFormatJSON:
+		var rows []map[string]any
+		if err := json.Unmarshal(b, &rows); err != nil {
+			return nil, fmt.Errorf("parse %s: %w", name, err)
+		}
+		return rows, nil
+	case ImportFormatCSV:
+		rows, err := parseImportCSV(b)
+		if err != nil {
+			return nil, fmt.Errorf("parse %s: %w", name, err)
+		}
+		return rows, nil
+	default:
+		return nil, fmt.Errorf("unknown import format %q", format)
+	}
+}
+
+func parseImportCSV(b []byte) ([]map[string]any, error) {
+	reader := csv.NewReader(bytes.NewReader(bytes.TrimPrefix(b, []byte("\ufeff"))))
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, errors.New("missing header row")
+	}
+	if err != nil {
+		return nil, err
+	}
+	seen := make(map[string]bool, len(header))
+	for i, name := range header {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			return nil, fmt.Errorf("column %d has no header", i+1)
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("column %q appears more than once", name)
+		}
+		seen[name] = true
+		header[i] = name
+	}
+
+	rows := make([]map[string]any, 0)
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		row := map[string]any{}
+		for i, cell := range record {
+			if strings.TrimSpace(cell) == "" {
+				continue
+			}
+			parent, child, nested := strings.Cut(header[i], ".")
+			if !nested {
+				row[header[i]] = cell
+				continue
+			}
+			m, ok := row[parent].(map[string]any)
+			if !ok {
+				m = map[string]any{}
+				row[parent] = m
+			}
+			m[child] = cell
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// coerceImportValue converts text to the property's type with the same rules
+// as a web form submission, so "42" becomes a number and "a, b" an array.
+// Values that are already typed are returned unchanged.
+func coerceImportValue(v any, prop SchemaProperty) (any, error) {
+	switch t := v.(type) {
+	case string:
+		if prop.Type == "string" || prop.Type == "object" {
+			return t, nil
+		}
+		return parseFormField(strings.TrimSpace(t), prop)
+	case map[string]any:
+		if prop.Type != "object" {
+			return t, nil
+		}
+		out := make(map[string]any, len(t))
+		for _, name := range sortedKeys(t) {
+			child, ok := prop.Properties[name]
+			if !ok {
+				out[name] = t[name]
+				continue
+			}
+			cv, err := coerceImportValue(t[name], child)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", name, err)
+			}
+			out[name] = cv
+		}
+		return out, nil
+	default:
+		return v, nil
+	}
+}
+
+// ImportObjects writes rows as objects of typeName. Rows without an _id are
+// created with a new UUID. A row whose _id already exists is rejected unless
+// opts.Upsert is set, in which case the stored object is replaced.
+//
+// A row that fails validation, alone or against the rest of the repository, is
+// reported in ImportResult.Rejected and the remaining rows are still written.
+// With opts.Strict nothing is written unless every row is accepted.
+func (r *Repository) ImportObjects(repoPath, typeName string, rows []map[string]any, opts ImportOptions) (ImportResult, ValidationResult, error) {
+	result := ImportResult{}
+	schemas, err := LoadSchemas(repoPath)
+	if err != nil {
+		return result, ValidationResult{}, err
+	}
+	schemas, issues := ResolveEnumRefs(repoPath, schemas)
+	if len(issues) > 0 {
+		return result, ValidationResult{}, fmt.Errorf("cannot import: %s", issues[0].String())
+	}
+	schema, ok := schemas[typeName]
+	if !ok {
+		return result, ValidationResult{}, fmt.Errorf("unknown type %q", typeName)
+	}
+	existing, err := r.ListObjectsForType(repoPath, typeName)
+	if err != nil {
+		return result, ValidationResult{}, err
+	}
+	exists := make(map[string]bool, len(existing))
+	for _, obj := range existing {
+		exists[obj.ID] = true
+	}
+
+	objects := make([]Object, 0, len(rows))
+	rowOf := make(map[string]int, len(rows))
+	updated := make(map[string]bool)
+	checks := ValidationResult{}
+	reject := func(row int, id, reason string) error {
+		if opts.Strict {
+			return fmt.Errorf("row %d: %s", row, reason)
+		}
+		result.Rejected = append(result.Rejected, ImportRejection{Row: row, ID: id, Reason: reason})
+		return nil
+	}
+	for i, row := range rows {
+		obj, reason, err := importRowObject(row, typeName, schema)
+		if err != nil {
+			return result, ValidationResult{}, err
+		}
+		if reason == "" {
+			switch {
+			case rowOf[obj.ID] > 0:
+				reason = fmt.Sprintf("_id %s appears more than once", obj.ID)
+			case exists[obj.ID] && !opts.Upsert:
+				reason = fmt.Sprintf("object %s already exists (use --upsert to update)", obj.ID)
+			}
+		}
+		if reason != "" {
+			if err := reject(i+1, obj.ID, reason); err != nil {
+				return result, ValidationResult{}, err
+			}
+			continue
+		}
+		rowChecks := ValidationResult{}
+		validateObjectInvariants(obj, &rowChecks)
+		validateObjectSchema(obj, schema, &rowChecks)
+		if !rowChecks.OK() {
+			if opts.Strict {
+				checks.Issues = append(checks.Issues, rowChecks.Issues...)
+			} else {
+				result.Rejected = append(result.Rejected, ImportRejection{Row: i + 1, ID: obj.ID, Reason: joinIssueMessages(rowChecks.Issues)})
+				continue
+			}
+		}
+		rowOf[obj.ID] = i + 1
+		if exists[obj.ID] {
+			updated[obj.ID] = true
+		}
+		objects = append(objects, obj)
+	}
+	if !checks.OK() {
+		return result, checks, fmt.Errorf("import blocked by %d validation issue(s)", len(checks.Issues))
+	}
+
+	before, err := ValidateRepository(repoPath, r.YAMLOptions)
+	if err != nil {
+		return result, ValidationResult{}, err
+	}
+	known := make(map[string]bool, len(before.Issues))
+	for _, issue := range before.Issues {
+		known[issue.String()] = true
+	}
+
+	rels := make([]string, 0, len(objects))
+	for _, obj := range objects {
+		rels = append(rels, obj.Path)
+	}
+	backups, err := backupPaths(repoPath, rels)
+	if err != nil {
+		return result, ValidationResult{}, err
+	}
+	for _, obj := range objects {
+		if err := r.WriteObject(repoPath, obj); err != nil {
+			_ = r.restorePaths(repoPath, backups)
+			return result, ValidationResult{}, err
+		}
+	}
+
+	// Rejecting a row can invalidate another (a reference to it, say), so
+	// repeat until the written rows introduce no issues.
+	for len(objects) > 0 {
+		after, err := ValidateRepository(repoPath, r.YAMLOptions)
+		if err != nil {
+			_ = r.restorePaths(repoPath, backups)
+			return result, ValidationResult{}, err
+		}
+		introduced := ValidationResult{}
+		for _, issue := range after.Issues {
+			if !known[issue.String()] {
+				introduced.Add(issue)
+			}
+		}
+		if introduced.OK() {
+			break
+		}
+		blamed := blameImportedObjects(objects, introduced.Issues)
+		if opts.Strict || len(blamed) == 0 {
+			_ = r.restorePaths(repoPath, backups)
+			return result, introduced, fmt.Errorf("import blocked by %d validation issue(s)", len(introduced.Issues))
+		}
+		kept := make([]Object, 0, len(objects))
+		undo := make([]fileBackup, 0, len(blamed))
+		for _, obj := range objects {
+			issues, ok := blamed[obj.Path]
+			if !ok {
+				kept = append(kept, obj)
+				continue
+			}
+			result.Rejected = append(result.Rejected, ImportRejection{Row: rowOf[obj.ID], ID: obj.ID, Reason: joinIssueMessages(issues)})
+			for _, b := range backups {
+				if b.rel == obj.Path {
+					undo = append(undo, b)
+				}
+			}
+		}
+		if err := r.restorePaths(repoPath, undo); err != nil {
+			return result, ValidationResult{}, err
+		}
+		objects = kept
+	}
+
+	sort.SliceStable(result.Rejected, func(i, j int) bool { return result.Rejected[i].Row < result.Rejected[j].Row })
+	for _, obj := range objects {
+		if updated[obj.ID] {
+			result.Updated = append(result.Updated, obj.ID)
+		} else {
+			result.Created = append(result.Created, obj.ID)
+		}
+	}
+	return result, ValidationResult{}, nil
+}
+
+// importRowObject builds the object for one row. A non-empty reason rejects
+// the row; err is reserved for failures that stop the import.
+func importRowObject(row map[string]any, typeName string, schema Schema) (Object, string, error) {
+	data := make(map[string]any, len(row)+2)
+	row, clashes := canonicalAliasData(row, schema)
+	if len(clashes) > 0 {
+		id, _ := row["_id"].(string)
+		return Object{ID: id}, fmt.Sprintf("field %s: alias of %s, which is also set", clashes[0], schema.Aliases[clashes[0]]), nil
+	}
+	for _, k := range sortedKeys(row) {
+		v := row[k]
+		if prop, ok := schema.Properties[k]; ok {
+			cv, err := coerceImportValue(v, prop)
+			if err != nil {
+				return Object{}, fmt.Sprintf("field %s: %v", k, err), nil
+			}
+			v = cv
+		}
+		nv, err := normalizeObjectValue(v)
+		if err != nil {
+			return Object{}, fmt.Sprintf("field %s: %v", k, err), nil
+		}
+		data[k] = nv
+	}
+	id, _ := data["_id"].(string)
+	if t, ok := data["_type"]; ok && t != typeName {
+		return Object{ID: id}, fmt.Sprintf("_type %v does not match %q", t, typeName), nil
+	}
+	switch {
+	case id == "":
+		var err error
+		if id, err = NewUUID(); err != nil {
+			return Object{}, "", err
+		}
+	case !uuidPattern.MatchString(id):
+		return Object{ID: id}, fmt.Sprintf("_id %q must be a UUID", id), nil
+	}
+	data["_id"] = id
+	data["_type"] = typeName
+	return Object{ID: id, Type: typeName, Data: data, Path: filepath.ToSlash(filepath.Join("data", typeName, id+".yaml"))}, "", nil
+}
+
+// blameImportedObjects maps introduced issues to the imported objects that
+// caused them. An issue reported on a stored object (the first holder of a
+// unique value, for instance) is blamed on the imported object it names.
+func blameImportedObjects(objects []Object, issues []ValidationIssue) map[string][]ValidationIssue {
+	imported := make(map[string]bool, len(objects))
+	for _, obj := range objects {
+		imported[obj.Path] = true
+	}
+	blamed := map[string][]ValidationIssue{}
+	for _, issue := range issues {
+		if imported[issue.Path] {
+			blamed[issue.Path] = append(blamed[issue.Path], issue)
+			continue
+		}
+		for _, obj := range objects {
+			if strings.Contains(issue.Message, obj.Path) {
+				blamed[obj.Path] = append(blamed[obj.Path], issue)
+				break
+			}
+		}
+	}
+	return blamed
+}
+
+func joinIssueMessages(issues []ValidationIssue) string {
+	parts := make([]string, 0, len(issues))
+	for _, issue := range issues {
+		if issue.Field != "" {
+			parts = append(parts, issue.Field+": "+issue.Message)
+		} else {
+			parts = append(parts, issue.Message)
+		}
+	}
+	return strings.Join(parts, "; ")
+}
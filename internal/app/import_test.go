@@ -0,0 +1,222 @@
+package app
+
+import (
+	"bytes"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestImportObjectsUpsertCreatesUpdatesAndRejects(t *testing.T) {
+	repo := newTestRepository(t)
+	ws := newTestWorkspace(t, repo, "draft")
+	newService := testObjectID(4)
+	rows := func() []map[string]any {
+		return []map[string]any{
+			{"_id": testServiceID, "name": "edge-gateway", "teamId": testTeamID, "tier": "core"},
+			{"_id": testObjectID(3), "name": "bad-tier", "teamId": testTeamID, "tier": "gold"},
+			{"_id": newService, "name": "ingest", "teamId": testTeamID, "tier": "batch", "ports": "9000, 9001"},
+		}
+	}
+
+	// Strict mode writes nothing when one row in the middle fails.
+	_, checks, err := repo.ImportObjects(ws, "service", rows(), ImportOptions{Upsert: true, Strict: true})
+	if err == nil || len(checks.Issues) != 1 || checks.Issues[0].Field != "tier" {
+		t.Errorf("strict: error %v, issues %v; want the bad tier to block the import", err, checks.Issues)
+	}
+	if changed, err := repo.ChangedFiles(ws); err != nil {
+		t.Fatal(err)
+	} else if len(changed) != 0 {
+		t.Fatalf("strict import wrote %v", changed)
+	}
+
+	// Without --upsert an existing id is rejected rather than replaced.
+	result, _, err := repo.ImportObjects(ws, "service", rows()[:1], ImportOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Rejected) != 1 || !strings.Contains(result.Rejected[0].Reason, "already exists (use --upsert to update)") {
+		t.Errorf("rejected = %v, want the existing id rejected", result.Rejected)
+	}
+
+	result, _, err = repo.ImportObjects(ws, "service", rows(), ImportOptions{Upsert: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(result.Updated, []string{testServiceID}) || !reflect.DeepEqual(result.Created, []string{newService}) {
+		t.Errorf("updated %v, created %v; want the existing service updated and one created", result.Updated, result.Created)
+	}
+	if len(result.Rejected) != 1 || result.Rejected[0].Row != 2 {
+		t.Errorf("rejected = %v, want row 2", result.Rejected)
+	}
+
+	updated, err := repo.ReadObject(ws, "service", testServiceID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if updated.Data["tier"] != "core" {
+		t.Errorf("updated tier = %v, want core", updated.Data["tier"])
+	}
+	created, err := repo.ReadObject(ws, "service", newService)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(created.Data["ports"], []any{9000.0, 9001.0}) {
+		t.Errorf("created ports = %#v, want coerced integers", created.Data["ports"])
+	}
+}
+
+func TestParseImportRowsCSV(t *testing.T) {
+	input := "\ufeffname,ports,endpoint.host,endpoint.port,tier\n" +
+		`"edge, west",443;8443,edge.local,443,` + "\n" +
+		"ingest,,,,batch\n"
+	rows, err := ParseImportRows("services.CSV", "", []byte(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []map[string]any{
+		{"name": "edge, west", "ports": "443;8443", "endpoint": map[string]any{"host": "edge.local", "port": "443"}},
+		{"name": "ingest", "tier": "batch"},
+	}
+	if !reflect.DeepEqual(rows, want) {
+		t.Errorf("rows = %#v, want %#v", rows, want)
+	}
+
+	errorTests := []struct {
+		name, format, input, want string
+	}{
+		{"services.csv", "", "name,name\na,b\n", `column "name" appears more than once`},
+		{"services.csv", "", "name,\na,b\n", "column 2 has no header"},
+		{"services.csv", "", "", "missing header row"},
+		{"services.xlsx", "", "PK\x03\x04", "parse services.xlsx"},
+		{"services.xml", "xml", "<services/>", `unknown import format "xml"`},
+	}
+	for _, tt := range errorTests {
+		if _, err := ParseImportRows(tt.name, tt.format, []byte(tt.input)); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s (%q): error = %v, want %q", tt.name, tt.input, err, tt.want)
+		}
+	}
+}
+
+func TestCoerceImportValue(t *testing.T) {
+	tests := []struct {
+		name string
+		v    any
+		prop SchemaProperty
+		want any
+	}{
+		{"integer", "42", SchemaProperty{Type: "integer"}, 42.0},
+		{"number", " 2.5 ", SchemaProperty{Type: "number"}, 2.5},
+		{"invalid number kept for validation", "many", SchemaProperty{Type: "integer"}, "many"},
+		{"boolean", "true", SchemaProperty{Type: "boolean"}, true},
+		{"invalid boolean kept for validation", "yes", SchemaProperty{Type: "boolean"}, "yes"},
+		{"integer array", "80, 443", SchemaProperty{Type: "array", ItemsType: "integer"}, []any{80.0, 443.0}},
+		{"string array", "a, b,", SchemaProperty{Type: "array", ItemsType: "string"}, []any{"a", "b"}},
+		{"string untouched", " 42 ", SchemaProperty{Type: "string"}, " 42 "},
+		{"typed value untouched", 7.0, SchemaProperty{Type: "string"}, 7.0},
+		{
+			"object children",
+			map[string]any{"port": "443", "extra": "x"},
+			SchemaProperty{Type: "object", Properties: map[string]SchemaProperty{"port": {Type: "integer"}}},
+			map[string]any{"port": 443.0, "extra": "x"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := coerceImportValue(tt.v, tt.prop)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("coerce %#v = %#v, want %#v", tt.v, got, tt.want)
+			}
+		})
+	}
+}
+
+// postTestImport uploads content as the import form's file named filename.
+func postTestImport(t *testing.T, h http.Handler, path, filename, content string, fields url.Values) url.Values {
+	t.Helper()
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	for name, values := range fields {
+		for _, v := range values {
+			if err := mw.WriteField(name, v); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	fw, err := mw.CreateFormFile("file", filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := fw.Write([]byte(content)); err != nil {
+		t.Fatal(err)
+	}
+	if err := mw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	req := httptest.NewRequest(http.MethodPost, path, &body)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Code != http.StatusSeeOther {
+		t.Fatalf("import %s: status %d\n%s", filename, rec.Code, rec.Body.String())
+	}
+	loc, err := url.Parse(rec.Header().Get("Location"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return loc.Query()
+}
+
+func TestObjectImportHandler(t *testing.T) {
+	repo := newTestRepository(t)
+	ws := newTestWorkspace(t, repo, "draft")
+	editTestJSON(t, filepath.Join(ws, "config", "schemas", "service.schema.json"), func(doc map[string]any) {
+		doc["properties"].(map[string]any)["public"] = map[string]any{"type": "boolean"}
+	})
+	h := newTestHandler(t, repo)
+	const path = "/w/draft/types/service/import"
+	created := testObjectID(4)
+
+	q := postTestImport(t, h, path, "services.csv",
+		"_id,name,teamId,tier,ports,public\n"+created+",ingest,"+testTeamID+",batch,\"9000, 9001\",true\n"+
+			testObjectID(5)+",bad-tier,"+testTeamID+",gold,,false\n",
+		url.Values{"strict": {"on"}})
+	if q.Get("error") != "1" || !strings.Contains(q.Get("flash"), "import blocked by 1 validation issue(s)") {
+		t.Errorf("strict import: flash %q error=%q, want it blocked", q.Get("flash"), q.Get("error"))
+	}
+	if changed, err := repo.ChangedFiles(ws); err != nil {
+		t.Fatal(err)
+	} else if len(changed) != 1 {
+		t.Fatalf("blocked import changed %v, want only the schema edit", changed)
+	}
+
+	q = postTestImport(t, h, path, "services.csv",
+		"_id,name,teamId,tier,ports,public\n"+created+",ingest,"+testTeamID+",batch,\"9000, 9001\",true\n", nil)
+	if q.Get("error") != "" || q.Get("flash") != "Imported 1 created, 0 updated." {
+		t.Errorf("import: flash %q error=%q", q.Get("flash"), q.Get("error"))
+	}
+	obj, err := repo.ReadObject(ws, "service", created)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(obj.Data["ports"], []any{9000.0, 9001.0}) || obj.Data["public"] != true {
+		t.Errorf("imported data = %#v, want coerced ports and public", obj.Data)
+	}
+
+	q = postTestImport(t, h, path, "services.xlsx", "PK\x03\x04", nil)
+	if q.Get("error") != "1" || !strings.HasPrefix(q.Get("flash"), "import: parse services.xlsx") {
+		t.Errorf("unsupported file: flash %q error=%q", q.Get("flash"), q.Get("error"))
+	}
+	q = postTestImport(t, h, "/w/main/types/service/import", "services.csv", "name\nx\n", nil)
+	if q.Get("error") != "1" || q.Get("flash") != "main is read-only" {
+		t.Errorf("import on main: flash %q error=%q", q.Get("flash"), q.Get("error"))
+	}
+}
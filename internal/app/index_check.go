@@ -0,0 +1,88 @@
+package app
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// IndexDiscrepancy is a path under data/ where the git index and the working
+// tree disagree in a way the editing flows never produce, typically left
+// behind by an interrupted operation.
+type IndexDiscrepancy struct {
+	Path   string `json:"path"`
+	Reason string `json:"reason"`
+}
+
+// FindIndexDiscrepancies compares the files git tracks under data/ in dir
+// with the files actually present. It reports tracked files missing from the
+// working tree and untracked files that are not data/<type>/<uuid>.yaml. In a
+// workspace with unsaved deletions the deleted objects are reported too, so
+// the check is meant for the repository root or a saved workspace.
+func (r *Repository) FindIndexDiscrepancies(dir string) ([]IndexDiscrepancy, error) {
+	tracked, err := r.runGit(dir, "ls-files", "-z", "--", "data")
+	if err != nil {
+		return nil, err
+	}
+	untracked, err := r.runGit(dir, "ls-files", "-z", "--others", "--exclude-standard", "--", "data")
+	if err != nil {
+		return nil, err
+	}
+	found := make([]IndexDiscrepancy, 0)
+	for _, rel := range splitNUL(tracked) {
+		if _, err := os.Lstat(filepath.Join(dir, filepath.FromSlash(rel))); err != nil {
+			if !errors.Is(err, os.ErrNotExist) {
+				return nil, err
+			}
+			found = append(found, IndexDiscrepancy{Path: rel, Reason: "tracked in git but missing from the working tree"})
+		}
+	}
+	for _, rel := range splitNUL(untracked) {
+		if _, id, ok := parseDataObjectPath(rel); ok && uuidPattern.MatchString(id) {
+			continue
+		}
+		found = append(found, IndexDiscrepancy{Path: rel, Reason: "untracked file that is not an object path"})
+	}
+	sort.Slice(found, func(i, j int) bool {
+		return found[i].Path < found[j].Path
+	})
+	return found, nil
+}
+
+func splitNUL(out string) []string {
+	parts := strings.Split(out, "\x00")
+	paths := make([]string, 0, len(parts))
+	for _, p := range parts {
+		if p != "" {
+			paths = append(paths, p)
+		}
+	}
+	return paths
+}
+
+func writeIndexDiscrepancies(w io.Writer, found []IndexDiscrepancy, format string) error {
+	if format == "json" {
+		b, err := json.MarshalIndent(found, "", "  ")
+		if err != nil {
+			return err
+		}
+		b = append(b, '\n')
+		_, err = w.Write(b)
+		return err
+	}
+	if len(found) == 0 {
+		_, err := fmt.Fprintln(w, "git index matches the working tree")
+		return err
+	}
+	for _, d := range found {
+		if _, err := fmt.Fprintf(w, "%s: %s\n", d.Path, d.Reason); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,34 @@
+package app
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestFindIndexDiscrepanciesReportsMissingAndStrayFiles(t *testing.T) {
+	repo := newTestRepository(t)
+	if found, err := repo.FindIndexDiscrepancies(repo.Root); err != nil || len(found) != 0 {
+		t.Fatalf("fresh repository: %v, %v; want no discrepancies", found, err)
+	}
+
+	if err := os.Remove(filepath.Join(repo.Root, "data", "team", testTeamID+".yaml")); err != nil {
+		t.Fatal(err)
+	}
+	writeTestFile(t, filepath.Join(repo.Root, "data", "team", "notes.txt"), "stray\n")
+	// A new object file is an ordinary draft, not a discrepancy.
+	writeTestFile(t, filepath.Join(repo.Root, "data", "team", testObjectID(3)+".yaml"), "_type: team\n")
+
+	found, err := repo.FindIndexDiscrepancies(repo.Root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []IndexDiscrepancy{
+		{Path: "data/team/" + testTeamID + ".yaml", Reason: "tracked in git but missing from the working tree"},
+		{Path: "data/team/notes.txt", Reason: "untracked file that is not an object path"},
+	}
+	if !reflect.DeepEqual(found, want) {
+		t.Errorf("discrepancies = %+v, want %+v", found, want)
+	}
+}
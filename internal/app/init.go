@@ -1,7 +1,6 @@
 package app
 
 import (
-	"encoding/json"
 	"errors"
 	"fmt"
 	"os"
@@ -26,34 +25,38 @@ func InitializeRepository(root string, force bool, sample bool) error {
 	} else if !errors.Is(err, os.ErrNotExist) {
 		return err
 	}
-	if err := os.MkdirAll(abs, 0o755); err != nil {
+	files, err := FileOptionsFromEnv(YAMLOptions{})
+	if err != nil {
+		return err
+	}
+	if err := files.mkdirAll(abs); err != nil {
 		return err
 	}
 
 	if err := initGitRepo(abs); err != nil {
 		return err
 	}
-	if err := os.MkdirAll(filepath.Join(abs, "config", "schemas"), 0o755); err != nil {
+	if err := files.mkdirAll(filepath.Join(abs, "config", "schemas")); err != nil {
 		return err
 	}
-	if err := os.MkdirAll(filepath.Join(abs, "data", "team"), 0o755); err != nil {
+	if err := files.mkdirAll(filepath.Join(abs, "data", "team")); err != nil {
 		return err
 	}
-	if err := os.MkdirAll(filepath.Join(abs, "data", "service"), 0o755); err != nil {
+	if err := files.mkdirAll(filepath.Join(abs, "data", "service")); err != nil {
 		return err
 	}
-	if err := os.MkdirAll(filepath.Join(abs, "output"), 0o755); err != nil {
+	if err := files.mkdirAll(filepath.Join(abs, "output")); err != nil {
 		return err
 	}
 
 	if sample {
-		if err := writeSampleSchemas(abs); err != nil {
+		if err := writeSampleSchemas(files, abs); err != nil {
 			return err
 		}
-		if err := writeSampleConstraints(abs); err != nil {
+		if err := writeSampleConstraints(files, abs); err != nil {
 			return err
 		}
-		if err := writeSampleObjects(abs); err != nil {
+		if err := writeSampleObjects(files, abs); err != nil {
 			return err
 		}
 	}
@@ -62,11 +65,11 @@ func InitializeRepository(root string, force bool, sample bool) error {
 		return err
 	}
 	if err == nil {
-		if err := SaveUIConfig(abs, DefaultUIConfig(abs, schemas)); err != nil {
+		if err := files.SaveUIConfig(abs, DefaultUIConfig(abs, schemas)); err != nil {
 			return err
 		}
 	}
-	if err := ensureGitignoreDefaults(abs); err != nil {
+	if err := ensureGitignoreDefaults(files, abs); err != nil {
 		return err
 	}
 
@@ -89,7 +92,7 @@ func initGitRepo(root string) error {
 	return nil
 }
 
-func writeSampleSchemas(root string) error {
+func writeSampleSchemas(files FileOptions, root string) error {
 	teamSchema := map[string]any{
 		"type":     "object",
 		"required": []string{"name", "code"},
@@ -108,16 +111,16 @@ func writeSampleSchemas(root string) error {
 			"ports":  map[string]any{"type": "array", "items": map[string]any{"type": "integer"}},
 		},
 	}
-	if err := writeJSONFile(filepath.Join(root, "config", "schemas", "team.schema.json"), teamSchema); err != nil {
+	if err := files.writeJSONFile(filepath.Join(root, "config", "schemas", "team.schema.json"), teamSchema); err != nil {
 		return err
 	}
-	if err := writeJSONFile(filepath.Join(root, "config", "schemas", "service.schema.json"), serviceSchema); err != nil {
+	if err := files.writeJSONFile(filepath.Join(root, "config", "schemas", "service.schema.json"), serviceSchema); err != nil {
 		return err
 	}
 	return nil
 }
 
-func writeSampleConstraints(root string) error {
+func writeSampleConstraints(files FileOptions, root string) error {
 	c := Constraints{
 		Unique: []UniqueConstraint{
 			{Type: "team", Field: "code"},
@@ -127,10 +130,10 @@ func writeSampleConstraints(root string) error {
 			{FromType: "service", FromField: "teamId", ToType: "team", ToField: "_id"},
 		},
 	}
-	return writeJSONFile(filepath.Join(root, "config", "constraints.json"), c)
+	return files.writeJSONFile(filepath.Join(root, "config", "constraints.json"), c)
 }
 
-func writeSampleObjects(root string) error {
+func writeSampleObjects(files FileOptions, root string) error {
 	teamID := "11111111-1111-4111-8111-111111111111"
 	serviceID := "22222222-2222-4222-8222-222222222222"
 
@@ -156,16 +159,16 @@ func writeSampleObjects(root string) error {
 			"ports":  []any{float64(443), float64(8443)},
 		},
 	}
-	if err := WriteObject(root, team); err != nil {
+	if err := files.WriteObject(root, team); err != nil {
 		return err
 	}
-	if err := WriteObject(root, service); err != nil {
+	if err := files.WriteObject(root, service); err != nil {
 		return err
 	}
 	return nil
 }
 
-func ensureGitignoreDefaults(root string) error {
+func ensureGitignoreDefaults(files FileOptions, root string) error {
 	path := filepath.Join(root, ".gitignore")
 	content := ""
 	if b, err := os.ReadFile(path); err == nil {
@@ -184,7 +187,7 @@ func ensureGitignoreDefaults(root string) error {
 	if !containsGitignoreLine(content, ".worktreefoundry/") {
 		content += ".worktreefoundry/\n"
 	}
-	return os.WriteFile(path, []byte(content), 0o644)
+	return files.writeFile(path, []byte(content))
 }
 
 func containsGitignoreLine(content, line string) bool {
@@ -197,18 +200,6 @@ func containsGitignoreLine(content, line string) bool {
 	return false
 }
 
-func writeJSONFile(path string, value any) error {
-	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
-		return err
-	}
-	b, err := json.MarshalIndent(value, "", "  ")
-	if err != nil {
-		return err
-	}
-	b = append(b, '\n')
-	return os.WriteFile(path, b, 0o644)
-}
-
 func gitCommitAll(root, message string) error {
 	if _, err := runCommand(root, "git", "add", "-A"); err != nil {
 		return err
@@ -0,0 +1,23 @@
+package app
+
+// LintSchemas reports schema style problems that do not make data invalid.
+func LintSchemas(schemas map[string]Schema) []ValidationIssue {
+	types := sortedKeys(schemas)
+
+	warnings := make([]ValidationIssue, 0)
+	for _, t := range types {
+		schema := schemas[t]
+		fields := sortedKeys(schema.Properties)
+		for _, field := range fields {
+			prop := schema.Properties[field]
+			if len(prop.Enum) == 0 && len(prop.EnumNumbers) == 0 && prop.EnumRef == "" {
+				continue
+			}
+			if _, required := schema.Required[field]; required || prop.Default != nil {
+				continue
+			}
+			warnings = append(warnings, ValidationIssue{Stage: "lint", Path: "config/schemas/" + t + ".schema.json", Field: field, Message: "enum field is neither required nor given a default; an omitted value is ambiguous"})
+		}
+	}
+	return warnings
+}
@@ -0,0 +1,31 @@
+package app
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestLintSchemasWarnsOnOptionalEnumWithoutDefault(t *testing.T) {
+	repo := newTestRepository(t)
+	editTestJSON(t, filepath.Join(repo.Root, "config", "schemas", "service.schema.json"), func(doc map[string]any) {
+		props := doc["properties"].(map[string]any)
+		props["stage"] = map[string]any{"type": "string", "enum": []any{"alpha", "ga"}}
+		props["region"] = map[string]any{"type": "string", "enum": []any{"us", "eu"}, "default": "us"}
+	})
+	schemas, err := LoadSchemas(repo.Root)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// tier is a required enum and region has a default: only stage warns.
+	warnings := LintSchemas(schemas)
+	if len(warnings) != 1 || warnings[0].Field != "stage" || warnings[0].Stage != "lint" || warnings[0].Path != "config/schemas/service.schema.json" {
+		t.Fatalf("warnings = %v, want one for service.stage", warnings)
+	}
+
+	schema := schemas["service"]
+	schema.Required["stage"] = struct{}{}
+	if warnings := LintSchemas(schemas); len(warnings) != 0 {
+		t.Errorf("warnings = %v, want none once stage is required", warnings)
+	}
+}
@@ -10,6 +10,11 @@ import (
 	"strings"
 )
 
+const (
+	arrayMergeReplace = "replace"
+	arrayMergeUnion   = "union"
+)
+
 type FieldConflict struct {
 	File      string
 	Field     string
@@ -26,59 +31,95 @@ type MergeResult struct {
 	Message     string
 	Workspace   string
 	MergedFiles int
+	Pushed      bool
+	PushOutput  string
+	SyncOutput  string
 }
 
-func (r *Repository) MergeWorkspace(name string, resolutions map[string]string, manualValues map[string]string) (MergeResult, error) {
+type MergeOptions struct {
+	// Push pushes the base branch to origin after the merge commit. The
+	// workspace is only deleted once the push succeeds.
+	Push bool
+	// Sync fast-forwards the base branch from origin before the merge, so the
+	// three-way merge runs against the latest shared main.
+	Sync bool
+}
+
+// pushRemote is the remote a merge pushes to and syncs from.
+const pushRemote = "origin"
+
+func (r *Repository) MergeWorkspace(name string, resolutions map[string]string, manualValues map[string]string, opts MergeOptions) (MergeResult, error) {
 	path := r.WorkspacePath(name)
 	if _, err := os.Stat(path); err != nil {
 		return MergeResult{}, fmt.Errorf("workspace %q not found", name)
 	}
 	branch := r.BranchForWorkspace(name)
+	if opts.Push && !r.HasPushRemote() {
+		return MergeResult{}, fmt.Errorf("cannot push: no remote named %q is configured", pushRemote)
+	}
+	if opts.Sync && !r.HasPushRemote() {
+		return MergeResult{}, fmt.Errorf("cannot sync: no remote named %q is configured", pushRemote)
+	}
+
+	// The webhook is sent after r.mu is released, so a slow endpoint does not
+	// hold up other repository operations: this defer runs after the unlock.
+	var webhook *mergeWebhookPayload
+	defer func() {
+		if webhook != nil {
+			r.notifyMerge(*webhook)
+		}
+	}()
 
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
-	if branchName, err := r.CurrentBranch(r.Root); err != nil {
+	if err := r.requireCleanMain(); err != nil {
 		return MergeResult{}, err
-	} else if branchName != "main" {
-		return MergeResult{}, fmt.Errorf("main worktree must be on main branch (current: %s)", branchName)
 	}
-	if changed, err := r.ChangedFiles(r.Root); err != nil {
-		return MergeResult{}, err
-	} else if len(changed) > 0 {
-		return MergeResult{}, errors.New("main worktree has uncommitted changes")
+	// Syncing before the diff means the changed files and the merge base are
+	// both computed against the fetched main.
+	syncOutput := ""
+	if opts.Sync {
+		out, err := r.syncMainLocked()
+		if err != nil {
+			return MergeResult{}, err
+		}
+		syncOutput = out
 	}
 
-	changedFiles, err := r.diffWorkspaceDataFiles(branch)
+	dataFiles, err := r.diffWorkspaceDataFiles(branch)
 	if err != nil {
 		return MergeResult{}, err
 	}
-	if len(changedFiles) == 0 {
-		return MergeResult{Merged: false, Workspace: name, Message: "no changes to merge"}, nil
+	configFiles, err := r.diffWorkspaceConfigFiles(branch)
+	if err != nil {
+		return MergeResult{}, err
 	}
-
-	mergedFiles := map[string]*map[string]any{}
-	conflicts := make([]FieldConflict, 0)
-
-	for _, rel := range changedFiles {
-		baseMap, _ := r.readObjectAtRef("main", rel)
-		mainMap, _ := r.readObjectAtRef("main", rel)
-		wsMap, _ := r.readObjectAtRef(branch, rel)
-		if baseSha, err := r.mergeBase("main", branch); err == nil {
-			if m, ok := r.readObjectAtRef(baseSha, rel); ok {
-				baseMap = m
-			} else {
-				baseMap = nil
-			}
+	// With nothing to merge a push still runs, so a merge whose push failed
+	// can be retried once the remote is fixed.
+	noChanges := func() (MergeResult, error) {
+		result := MergeResult{Merged: false, Workspace: name, Message: "no changes to merge", SyncOutput: syncOutput}
+		if !opts.Push {
+			return result, nil
 		}
-
-		merged, fileConflicts := mergeThreeWayObject(rel, baseMap, mainMap, wsMap, resolutions, manualValues)
-		if len(fileConflicts) > 0 {
-			conflicts = append(conflicts, fileConflicts...)
-			continue
+		out, err := r.pushBaseBranch()
+		result.PushOutput = out
+		if err != nil {
+			return result, fmt.Errorf("push failed: %w", err)
 		}
-		mergedFiles[rel] = merged
+		result.Pushed = true
+		result.Message = "no changes to merge; " + r.BaseBranch + " pushed"
+		return result, nil
 	}
+	if len(dataFiles) == 0 && len(configFiles) == 0 {
+		return noChanges()
+	}
+	changedFiles := append(append([]string(nil), dataFiles...), configFiles...)
+	sort.Strings(changedFiles)
+
+	mergedFiles, conflicts := r.computeMerge(branch, dataFiles, resolutions, manualValues)
+	configOutcomes, configConflicts := r.computeConfigMerge(branch, configFiles, resolutions)
+	conflicts = append(conflicts, configConflicts...)
 
 	if len(conflicts) > 0 {
 		sort.Slice(conflicts, func(i, j int) bool {
@@ -88,11 +129,12 @@ func (r *Repository) MergeWorkspace(name string, resolutions map[string]string,
 			return conflicts[i].File < conflicts[j].File
 		})
 		return MergeResult{
-			Merged:    false,
-			Workspace: name,
-			Changed:   changedFiles,
-			Conflicts: conflicts,
-			Message:   "conflicts require resolution",
+			Merged:     false,
+			Workspace:  name,
+			Changed:    changedFiles,
+			Conflicts:  conflicts,
+			Message:    "conflicts require resolution",
+			SyncOutput: syncOutput,
 		}, nil
 	}
 
@@ -101,31 +143,19 @@ func (r *Repository) MergeWorkspace(name string, resolutions map[string]string,
 		return MergeResult{}, err
 	}
 	rollback := func() {
-		_ = restorePaths(r.Root, backups)
+		_ = r.restorePaths(r.Root, backups)
 	}
 
-	for _, rel := range changedFiles {
-		full := filepath.Join(r.Root, filepath.FromSlash(rel))
-		merged := mergedFiles[rel]
-		if merged == nil || len(*merged) == 0 {
-			if err := os.Remove(full); err != nil && !errors.Is(err, os.ErrNotExist) {
-				rollback()
-				return MergeResult{}, err
-			}
-			continue
-		}
-		obj, err := objectFromPathAndData(rel, *merged)
-		if err != nil {
-			rollback()
-			return MergeResult{}, err
-		}
-		if err := WriteObject(r.Root, obj); err != nil {
-			rollback()
-			return MergeResult{}, err
-		}
+	if err := r.writeMergedFiles(r.Root, dataFiles, mergedFiles); err != nil {
+		rollback()
+		return MergeResult{}, err
+	}
+	if err := r.writeConfigOutcomes(r.Root, configOutcomes); err != nil {
+		rollback()
+		return MergeResult{}, err
 	}
 
-	if validation, err := ValidateRepository(r.Root); err != nil {
+	if validation, err := ValidateRepository(r.Root, r.YAMLOptions); err != nil {
 		rollback()
 		return MergeResult{}, err
 	} else if !validation.OK() {
@@ -137,20 +167,157 @@ func (r *Repository) MergeWorkspace(name string, resolutions map[string]string,
 		rollback()
 		return MergeResult{}, err
 	}
-	if _, err := r.runGit(r.Root, "-c", "user.name=worktreefoundry", "-c", "user.email=worktreefoundry@local", "commit", "-m", fmt.Sprintf("Merge %s into main", branch)); err != nil {
+	// The workspace may only differ by changes main already has.
+	if staged, err := r.ChangedFiles(r.Root); err != nil {
 		rollback()
 		return MergeResult{}, err
+	} else if len(staged) == 0 {
+		return noChanges()
+	}
+	if _, err := r.runGit(r.Root, "-c", "user.name=worktreefoundry", "-c", "user.email=worktreefoundry@local", "commit", "-m", fmt.Sprintf("Merge %s into %s", branch, r.BaseBranch)); err != nil {
+		rollback()
+		return MergeResult{}, err
+	}
+
+	webhook = r.mergeWebhook(name, changedFiles)
+
+	result := MergeResult{Merged: true, Workspace: name, Changed: changedFiles, MergedFiles: len(changedFiles), Message: "merge complete", SyncOutput: syncOutput}
+	if opts.Push {
+		out, err := r.pushBaseBranch()
+		result.PushOutput = out
+		if err != nil {
+			result.Message = "merge committed; push failed"
+			return result, fmt.Errorf("merge committed to %s but push failed, workspace %s kept: %w", r.BaseBranch, name, err)
+		}
+		result.Pushed = true
+		result.Message = "merge complete and pushed"
 	}
 
 	if err := r.deleteWorkspaceLocked(name); err != nil {
 		return MergeResult{}, err
 	}
 
-	return MergeResult{Merged: true, Workspace: name, Changed: changedFiles, MergedFiles: len(changedFiles), Message: "merge complete"}, nil
+	return result, nil
+}
+
+// SyncMain fetches the base branch from origin and fast-forwards the main
+// worktree to it. It returns git's output and fails without touching main if
+// main has local commits that origin does not.
+func (r *Repository) SyncMain() (string, error) {
+	if !r.HasPushRemote() {
+		return "", fmt.Errorf("cannot sync: no remote named %q is configured", pushRemote)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err := r.requireCleanMain(); err != nil {
+		return "", err
+	}
+	return r.syncMainLocked()
+}
+
+func (r *Repository) syncMainLocked() (string, error) {
+	fetchOut, err := r.runGit(r.Root, "fetch", pushRemote, r.BaseBranch)
+	if err != nil {
+		return "", fmt.Errorf("sync failed: %w", err)
+	}
+	remoteRef := pushRemote + "/" + r.BaseBranch
+	if _, err := r.runGit(r.Root, "merge-base", "--is-ancestor", r.BaseBranch, remoteRef); err != nil {
+		return "", fmt.Errorf("cannot sync: %s has commits that are not on %s; push or reconcile them first", r.BaseBranch, remoteRef)
+	}
+	mergeOut, err := r.runGit(r.Root, "merge", "--ff-only", remoteRef)
+	if err != nil {
+		return "", fmt.Errorf("sync failed: %w", err)
+	}
+	return strings.TrimSpace(strings.TrimSpace(fetchOut) + "\n" + strings.TrimSpace(mergeOut)), nil
+}
+
+// requireCleanMain checks that the main worktree is on the base branch with
+// no uncommitted changes. Callers hold r.mu.
+func (r *Repository) requireCleanMain() error {
+	if branchName, err := r.CurrentBranch(r.Root); err != nil {
+		return err
+	} else if branchName != r.BaseBranch {
+		return fmt.Errorf("main worktree must be on %s branch (current: %s)", r.BaseBranch, branchName)
+	}
+	if changed, err := r.ChangedFiles(r.Root); err != nil {
+		return err
+	} else if len(changed) > 0 {
+		return errors.New("main worktree has uncommitted changes")
+	}
+	return nil
+}
+
+// HasPushRemote reports whether the repository has the remote merges push to.
+func (r *Repository) HasPushRemote() bool {
+	_, err := r.runGit(r.Root, "remote", "get-url", pushRemote)
+	return err == nil
+}
+
+// pushBaseBranch pushes the base branch to origin and returns git's output.
+func (r *Repository) pushBaseBranch() (string, error) {
+	if !r.HasPushRemote() {
+		return "", fmt.Errorf("cannot push: no remote named %q is configured", pushRemote)
+	}
+	out, err := r.runGit(r.Root, "push", pushRemote, r.BaseBranch)
+	return strings.TrimSpace(out), err
+}
+
+func (r *Repository) computeMerge(branch string, changedFiles []string, resolutions, manualValues map[string]string) (map[string]*map[string]any, []FieldConflict) {
+	mergedFiles := map[string]*map[string]any{}
+	conflicts := make([]FieldConflict, 0)
+	baseSha, baseErr := r.mergeBase(r.BaseBranch, branch)
+	// Merge strategies come from main's schemas; a schema change arriving
+	// with this workspace only takes effect for later merges.
+	schemas, _ := LoadSchemas(r.Root)
+	for _, rel := range changedFiles {
+		mainMap, onMain := r.readObjectAtRef(r.BaseBranch, rel)
+		wsMap, onWorkspace := r.readObjectAtRef(branch, rel)
+		baseMap, onBase := mainMap, onMain
+		if baseErr == nil {
+			baseMap, onBase = r.readObjectAtRef(baseSha, rel)
+		}
+
+		// Objects created only in the workspace cannot conflict.
+		if !onMain && !onBase && onWorkspace {
+			mergedFiles[rel] = &wsMap
+			continue
+		}
+
+		typeName, _, _ := parseDataObjectPath(rel)
+		union := unionArrayFields(schemas[typeName])
+		merged, fileConflicts := mergeThreeWayObject(rel, baseMap, mainMap, wsMap, union, resolutions, manualValues)
+		if len(fileConflicts) > 0 {
+			conflicts = append(conflicts, fileConflicts...)
+			continue
+		}
+		mergedFiles[rel] = merged
+	}
+	return mergedFiles, conflicts
+}
+
+func (o FileOptions) writeMergedFiles(root string, changedFiles []string, mergedFiles map[string]*map[string]any) error {
+	for _, rel := range changedFiles {
+		full := filepath.Join(root, filepath.FromSlash(rel))
+		merged := mergedFiles[rel]
+		if merged == nil || len(*merged) == 0 {
+			if err := os.Remove(full); err != nil && !errors.Is(err, os.ErrNotExist) {
+				return err
+			}
+			continue
+		}
+		obj, err := objectFromPathAndData(rel, *merged)
+		if err != nil {
+			return err
+		}
+		if err := o.WriteObject(root, obj); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 func (r *Repository) diffWorkspaceDataFiles(branch string) ([]string, error) {
-	out, err := r.runGit(r.Root, "diff", "--name-only", "main.."+branch, "--", "data")
+	out, err := r.runGit(r.Root, "diff", "--name-only", r.BaseBranch+".."+branch, "--", "data")
 	if err != nil {
 		return nil, err
 	}
@@ -183,7 +350,7 @@ func (r *Repository) readObjectAtRef(ref, relPath string) (map[string]any, bool)
 	if err != nil {
 		return nil, false
 	}
-	m, err := ParseSimpleYAMLObject([]byte(out))
+	m, _, err := r.parseObjectYAML([]byte(out))
 	if err != nil {
 		return nil, false
 	}
@@ -198,7 +365,7 @@ func (r *Repository) readObjectAtRef(ref, relPath string) (map[string]any, bool)
 	return normalized, true
 }
 
-func mergeThreeWayObject(rel string, base, main, ws map[string]any, resolutions, manual map[string]string) (*map[string]any, []FieldConflict) {
+func mergeThreeWayObject(rel string, base, main, ws map[string]any, union map[string]bool, resolutions, manual map[string]string) (*map[string]any, []FieldConflict) {
 	keys := map[string]struct{}{}
 	for k := range base {
 		keys[k] = struct{}{}
@@ -210,11 +377,7 @@ func mergeThreeWayObject(rel string, base, main, ws map[string]any, resolutions,
 		keys[k] = struct{}{}
 	}
 
-	keyList := make([]string, 0, len(keys))
-	for k := range keys {
-		keyList = append(keyList, k)
-	}
-	sort.Strings(keyList)
+	keyList := sortedKeys(keys)
 
 	merged := make(map[string]any)
 	conflicts := make([]FieldConflict, 0)
@@ -252,6 +415,12 @@ func mergeThreeWayObject(rel string, base, main, ws map[string]any, resolutions,
 			}
 			continue
 		}
+		if union[field] {
+			if arr, ok := mergeArrayUnion(b, m, w); ok {
+				merged[field] = arr
+				continue
+			}
+		}
 
 		key := conflictKey(rel, field)
 		choice := resolutions[key]
@@ -264,6 +433,15 @@ func mergeThreeWayObject(rel string, base, main, ws map[string]any, resolutions,
 			if wOK {
 				merged[field] = w
 			}
+		case "union":
+			// Every element of either side, including ones the other side
+			// removed; a non-array value leaves the conflict open.
+			arr, ok := mergeArrayUnion(nil, m, w)
+			if !ok {
+				conflicts = append(conflicts, FieldConflict{File: rel, Field: field, Base: b, Main: m, Workspace: w, Key: key})
+				continue
+			}
+			merged[field] = arr
 		case "manual":
 			manualValue, err := parseManualFieldValue(manual[key])
 			if err != nil {
@@ -289,6 +467,78 @@ func mergeThreeWayObject(rel string, base, main, ws map[string]any, resolutions,
 	return &merged, conflicts
 }
 
+func unionArrayFields(schema Schema) map[string]bool {
+	fields := map[string]bool{}
+	for name, prop := range schema.Properties {
+		if prop.Type == "array" && prop.ArrayMerge == arrayMergeUnion {
+			fields[name] = true
+		}
+	}
+	return fields
+}
+
+// mergeArrayUnion applies the element additions and removals made on main
+// and in the workspace relative to base. An element from base survives only
+// if neither side removed it. The result is deduplicated the way uniqueItems
+// compares items, then sorted. It reports false when any side holds a
+// non-array value or an item that is not a scalar.
+func mergeArrayUnion(base, main, ws any) ([]any, bool) {
+	sets := make([]map[string]any, 0, 3)
+	for _, v := range []any{base, main, ws} {
+		set := map[string]any{}
+		if v != nil {
+			arr, ok := v.([]any)
+			if !ok {
+				return nil, false
+			}
+			// Items are keyed as uniqueItems validation compares them, so
+			// 443 and "443" stay distinct.
+			for _, item := range arr {
+				key := constraintValueKey(item)
+				if key == "" {
+					return nil, false
+				}
+				set[key] = item
+			}
+		}
+		sets = append(sets, set)
+	}
+	baseSet, mainSet, wsSet := sets[0], sets[1], sets[2]
+
+	kept := map[string]any{}
+	for _, side := range []map[string]any{mainSet, wsSet} {
+		for key, item := range side {
+			if _, inBase := baseSet[key]; inBase {
+				_, inMain := mainSet[key]
+				_, inWs := wsSet[key]
+				if !inMain || !inWs {
+					continue
+				}
+			}
+			kept[key] = item
+		}
+	}
+
+	out := make([]any, 0, len(kept))
+	numeric := true
+	for _, item := range kept {
+		if _, ok := item.(float64); !ok {
+			numeric = false
+		}
+		out = append(out, item)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if numeric {
+			return out[i].(float64) < out[j].(float64)
+		}
+		if a, b := valueToText(out[i]), valueToText(out[j]); a != b {
+			return a < b
+		}
+		return constraintValueKey(out[i]) < constraintValueKey(out[j])
+	})
+	return out, true
+}
+
 func parseManualFieldValue(raw string) (any, error) {
 	trimmed := strings.TrimSpace(raw)
 	if trimmed == "" {
@@ -335,14 +585,11 @@ func backupPaths(root string, relPaths []string) ([]fileBackup, error) {
 	return backups, nil
 }
 
-func restorePaths(root string, backups []fileBackup) error {
+func (o FileOptions) restorePaths(root string, backups []fileBackup) error {
 	for _, b := range backups {
 		abs := filepath.Join(root, filepath.FromSlash(b.rel))
 		if b.exists {
-			if err := os.MkdirAll(filepath.Dir(abs), 0o755); err != nil {
-				return err
-			}
-			if err := os.WriteFile(abs, b.data, 0o644); err != nil {
+			if err := o.writeFileAll(abs, b.data); err != nil {
 				return err
 			}
 		} else {
@@ -0,0 +1,115 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// wholeFileField marks a conflict on an entire config file rather than on a
+// single object field.
+const wholeFileField = "*"
+
+type configOutcome struct {
+	data   []byte
+	remove bool
+}
+
+// diffWorkspaceConfigFiles lists the config files the workspace changed since
+// its merge base. config/overrides.json is workspace-only and never merged.
+func (r *Repository) diffWorkspaceConfigFiles(branch string) ([]string, error) {
+	base, err := r.mergeBase(r.BaseBranch, branch)
+	if err != nil {
+		return nil, err
+	}
+	out, err := r.runGit(r.Root, "diff", "--name-only", base, branch, "--", "config")
+	if err != nil {
+		return nil, err
+	}
+	files := make([]string, 0)
+	for _, line := range strings.Split(strings.TrimSpace(out), "\n") {
+		line = filepath.ToSlash(strings.TrimSpace(line))
+		if strings.HasPrefix(line, "config/") && line != overridesFile {
+			files = append(files, line)
+		}
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+func (r *Repository) readFileAtRef(ref, relPath string) (string, bool) {
+	out, err := r.runGit(r.Root, "show", fmt.Sprintf("%s:%s", ref, relPath))
+	if err != nil {
+		return "", false
+	}
+	return out, true
+}
+
+// computeConfigMerge merges config files as whole files: a side that left the
+// file as it was at the merge base yields to the other, and edits on both
+// sides conflict unless they are identical.
+func (r *Repository) computeConfigMerge(branch string, files []string, resolutions map[string]string) (map[string]configOutcome, []FieldConflict) {
+	outcomes := map[string]configOutcome{}
+	conflicts := make([]FieldConflict, 0)
+	baseSha, baseErr := r.mergeBase(r.BaseBranch, branch)
+	for _, rel := range files {
+		mainText, onMain := r.readFileAtRef(r.BaseBranch, rel)
+		wsText, onWorkspace := r.readFileAtRef(branch, rel)
+		baseText, onBase := mainText, onMain
+		if baseErr == nil {
+			baseText, onBase = r.readFileAtRef(baseSha, rel)
+		}
+		take := func() {
+			outcomes[rel] = configOutcome{data: []byte(wsText), remove: !onWorkspace}
+		}
+		if onMain == onBase && mainText == baseText {
+			take()
+			continue
+		}
+		if onMain == onWorkspace && mainText == wsText {
+			continue
+		}
+		key := conflictKey(rel, wholeFileField)
+		switch resolutions[key] {
+		case "main":
+		case "workspace":
+			take()
+		default:
+			conflicts = append(conflicts, FieldConflict{
+				File:      rel,
+				Field:     wholeFileField,
+				Base:      optionalText(baseText, onBase),
+				Main:      optionalText(mainText, onMain),
+				Workspace: optionalText(wsText, onWorkspace),
+				Key:       key,
+			})
+		}
+	}
+	return outcomes, conflicts
+}
+
+func optionalText(text string, ok bool) any {
+	if !ok {
+		return nil
+	}
+	return text
+}
+
+func (o FileOptions) writeConfigOutcomes(root string, outcomes map[string]configOutcome) error {
+	for rel, outcome := range outcomes {
+		full := filepath.Join(root, filepath.FromSlash(rel))
+		if outcome.remove {
+			if err := os.Remove(full); err != nil && !errors.Is(err, os.ErrNotExist) {
+				return err
+			}
+			continue
+		}
+		if err := o.writeFileAll(full, outcome.data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
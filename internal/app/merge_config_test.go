@@ -0,0 +1,75 @@
+package app
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestMergeWorkspaceReportsConcurrentSchemaEdits(t *testing.T) {
+	repo := newTestRepository(t)
+	first := newTestWorkspace(t, repo, "first")
+	second := newTestWorkspace(t, repo, "second")
+	addTestSchemaField(t, first, "team", "region")
+	addTestSchemaField(t, second, "team", "owner")
+	saveTestWorkspace(t, repo, "first")
+	saveTestWorkspace(t, repo, "second")
+
+	result, err := repo.MergeWorkspace("first", nil, nil, MergeOptions{})
+	if err != nil {
+		t.Fatalf("merge first: %v", err)
+	}
+	if !result.Merged {
+		t.Fatalf("merge first: not merged: %s", result.Message)
+	}
+
+	result, err = repo.MergeWorkspace("second", nil, nil, MergeOptions{})
+	if err != nil {
+		t.Fatalf("merge second: %v", err)
+	}
+	if result.Merged {
+		t.Fatal("merge second: merged over the first workspace's schema edit")
+	}
+	if len(result.Conflicts) != 1 {
+		t.Fatalf("merge second: got %d conflicts, want 1: %+v", len(result.Conflicts), result.Conflicts)
+	}
+	c := result.Conflicts[0]
+	if c.File != "config/schemas/team.schema.json" || c.Field != wholeFileField {
+		t.Fatalf("conflict = %s %s, want config/schemas/team.schema.json %s", c.File, c.Field, wholeFileField)
+	}
+
+	schemas, err := LoadSchemas(repo.Root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := schemas["team"].Properties["region"]; !ok {
+		t.Fatal("main lost the first workspace's field")
+	}
+	if _, ok := schemas["team"].Properties["owner"]; ok {
+		t.Fatal("main has the conflicting field of the second workspace")
+	}
+}
+
+func TestMergeWorkspaceSkipsOverrides(t *testing.T) {
+	repo := newTestRepository(t)
+	ws := newTestWorkspace(t, repo, "staging")
+	writeTestFile(t, filepath.Join(ws, "config", "overrides.json"), `{"replace": {"core": "staging-core"}}`+"\n")
+	addTestSchemaField(t, ws, "team", "region")
+	saveTestWorkspace(t, repo, "staging")
+
+	result, err := repo.MergeWorkspace("staging", nil, nil, MergeOptions{})
+	if err != nil {
+		t.Fatalf("merge: %v", err)
+	}
+	if !result.Merged {
+		t.Fatalf("not merged: %s", result.Message)
+	}
+	for _, file := range result.Changed {
+		if file == overridesFile {
+			t.Fatalf("merge reported %s as changed", overridesFile)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(repo.Root, "config", "overrides.json")); !os.IsNotExist(err) {
+		t.Fatalf("overrides reached main: stat error %v", err)
+	}
+}
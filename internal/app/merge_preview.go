@@ -0,0 +1,128 @@
+package app
+
+import (
+	"archive/tar"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+type MergePreview struct {
+	Workspace  string
+	Changed    []string
+	Conflicts  []FieldConflict
+	Validation ValidationResult
+}
+
+func (p MergePreview) OK() bool {
+	return len(p.Conflicts) == 0 && p.Validation.OK()
+}
+
+// ValidateMergePreview validates the result of merging a workspace into the
+// current main without touching the main worktree.
+func (r *Repository) ValidateMergePreview(name string) (MergePreview, error) {
+	return r.ValidateMergeResult(name, nil, nil)
+}
+
+// ValidateMergeResult is ValidateMergePreview with conflict resolutions
+// applied. Conflicts left unresolved are returned instead of validating.
+func (r *Repository) ValidateMergeResult(name string, resolutions, manualValues map[string]string) (MergePreview, error) {
+	if _, err := os.Stat(r.WorkspacePath(name)); err != nil {
+		return MergePreview{}, fmt.Errorf("workspace %q not found", name)
+	}
+	branch := r.BranchForWorkspace(name)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	dataFiles, err := r.diffWorkspaceDataFiles(branch)
+	if err != nil {
+		return MergePreview{}, err
+	}
+	configFiles, err := r.diffWorkspaceConfigFiles(branch)
+	if err != nil {
+		return MergePreview{}, err
+	}
+	changedFiles := append(append([]string(nil), dataFiles...), configFiles...)
+	sort.Strings(changedFiles)
+	preview := MergePreview{Workspace: name, Changed: changedFiles}
+	mergedFiles, conflicts := r.computeMerge(branch, dataFiles, resolutions, manualValues)
+	configOutcomes, configConflicts := r.computeConfigMerge(branch, configFiles, resolutions)
+	conflicts = append(conflicts, configConflicts...)
+	if len(conflicts) > 0 {
+		sort.Slice(conflicts, func(i, j int) bool {
+			if conflicts[i].File == conflicts[j].File {
+				return conflicts[i].Field < conflicts[j].Field
+			}
+			return conflicts[i].File < conflicts[j].File
+		})
+		preview.Conflicts = conflicts
+		return preview, nil
+	}
+
+	tmp, err := os.MkdirTemp("", "worktreefoundry-preview-")
+	if err != nil {
+		return MergePreview{}, err
+	}
+	defer os.RemoveAll(tmp)
+	if err := r.extractRef(r.BaseBranch, tmp); err != nil {
+		return MergePreview{}, err
+	}
+	if err := r.writeMergedFiles(tmp, dataFiles, mergedFiles); err != nil {
+		return MergePreview{}, err
+	}
+	if err := r.writeConfigOutcomes(tmp, configOutcomes); err != nil {
+		return MergePreview{}, err
+	}
+	validation, err := ValidateRepository(tmp, r.YAMLOptions)
+	if err != nil {
+		return MergePreview{}, err
+	}
+	preview.Validation = validation
+	return preview, nil
+}
+
+func (r *Repository) extractRef(ref, dest string) error {
+	cmd := exec.Command("git", "archive", "--format=tar", ref)
+	cmd.Dir = r.Root
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return fmt.Errorf("git archive %s failed: %w: %s", ref, err, strings.TrimSpace(stderr.String()))
+	}
+	tr := tar.NewReader(bytes.NewReader(out))
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		name := filepath.FromSlash(hdr.Name)
+		if !filepath.IsLocal(name) {
+			continue
+		}
+		target := filepath.Join(dest, name)
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			if err := r.mkdirAll(target); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			b, err := io.ReadAll(tr)
+			if err != nil {
+				return err
+			}
+			if err := r.writeFileAll(target, b); err != nil {
+				return err
+			}
+		}
+	}
+}
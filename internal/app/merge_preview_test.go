@@ -0,0 +1,54 @@
+package app
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestValidateMergeResultChecksChosenResolution(t *testing.T) {
+	repo := newTestRepository(t)
+	service := func(root, id, name string) {
+		writeTestFile(t, filepath.Join(root, "data", "service", id+".yaml"),
+			"_id: "+id+"\n_type: service\nname: "+name+"\nteamId: "+testTeamID+"\ntier: edge\n")
+	}
+	ws := newTestWorkspace(t, repo, "draft")
+	service(ws, testServiceID, "reports")
+	saveTestWorkspace(t, repo, "draft")
+	// Main renames the service too and adds another one named reports.
+	service(repo.Root, testServiceID, "edge-v2")
+	service(repo.Root, testObjectID(3), "reports")
+	commitTestMain(t, repo, "rename edge-gateway")
+
+	preview, err := repo.ValidateMergePreview("draft")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(preview.Conflicts) != 1 || preview.Conflicts[0].Field != "name" {
+		t.Fatalf("conflicts = %+v, want the name conflict", preview.Conflicts)
+	}
+	key := preview.Conflicts[0].Key
+
+	preview, err = repo.ValidateMergeResult("draft", map[string]string{key: "main"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !preview.OK() {
+		t.Errorf("keeping main's name: issues %v, want none", preview.Validation.Issues)
+	}
+
+	preview, err = repo.ValidateMergeResult("draft", map[string]string{key: "workspace"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if preview.OK() || len(preview.Validation.Issues) == 0 || !strings.Contains(preview.Validation.Issues[0].Message, "duplicate value") {
+		t.Errorf("taking the workspace name: issues %v, want the duplicate name reported", preview.Validation.Issues)
+	}
+
+	// Neither preview touched main.
+	if changed, err := repo.ChangedFiles(repo.Root); err != nil {
+		t.Fatal(err)
+	} else if len(changed) != 0 {
+		t.Errorf("main changed: %v", changed)
+	}
+}
@@ -0,0 +1,432 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestMergeArrayUnion(t *testing.T) {
+	tests := []struct {
+		name           string
+		base, main, ws any
+		want           []any
+	}{
+		{
+			name: "add/add of the same item",
+			base: []any{float64(80)},
+			main: []any{float64(80), float64(443)},
+			ws:   []any{float64(443), float64(80)},
+			want: []any{float64(80), float64(443)},
+		},
+		{
+			name: "add/add of different items",
+			base: []any{"a"},
+			main: []any{"a", "c"},
+			ws:   []any{"b", "a"},
+			want: []any{"a", "b", "c"},
+		},
+		{
+			name: "add/remove",
+			base: []any{float64(80), float64(443)},
+			main: []any{float64(80), float64(443), float64(8080)},
+			ws:   []any{float64(80)},
+			want: []any{float64(80), float64(8080)},
+		},
+		{
+			name: "number and string with the same text stay distinct",
+			base: []any{float64(443)},
+			main: []any{float64(443)},
+			ws:   []any{"443", float64(443)},
+			want: []any{float64(443), "443"},
+		},
+		{
+			name: "removing the number keeps the string",
+			base: []any{float64(443), "443"},
+			main: []any{"443"},
+			ws:   []any{float64(443), "443"},
+			want: []any{"443"},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, ok := mergeArrayUnion(tc.base, tc.main, tc.ws)
+			if !ok || !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("mergeArrayUnion = %#v, %v; want %#v", got, ok, tc.want)
+			}
+		})
+	}
+
+	if _, ok := mergeArrayUnion(nil, []any{"a"}, "a"); ok {
+		t.Error("non-array side merged")
+	}
+	if _, ok := mergeArrayUnion(nil, []any{"a"}, []any{map[string]any{"a": "b"}}); ok {
+		t.Error("non-scalar item merged")
+	}
+}
+
+func TestMergeThreeWayObjectUnionsArrayFields(t *testing.T) {
+	base := map[string]any{"_id": "x", "name": "edge", "ports": []any{float64(443), float64(8443)}}
+	main := map[string]any{"_id": "x", "name": "edge", "ports": []any{float64(443), float64(8443), float64(9443)}}
+	ws := map[string]any{"_id": "x", "name": "edge", "ports": []any{float64(443), "8443"}}
+
+	merged, conflicts := mergeThreeWayObject("data/service/x.yaml", base, main, ws, map[string]bool{"ports": true}, nil, nil)
+	if len(conflicts) != 0 {
+		t.Fatalf("conflicts = %+v", conflicts)
+	}
+	// The workspace replaced 8443 with "8443": the number is removed and the
+	// string added, alongside main's 9443.
+	want := []any{float64(443), "8443", float64(9443)}
+	if got := (*merged)["ports"]; !reflect.DeepEqual(got, want) {
+		t.Errorf("ports = %#v, want %#v", got, want)
+	}
+}
+
+func TestMergeWorkspaceOfOnlyNewObjects(t *testing.T) {
+	repo := newTestRepository(t)
+	ws := newTestWorkspace(t, repo, "additions")
+	team, service := testObjectID(3), testObjectID(4)
+	files := map[string]string{
+		"data/team/" + team + ".yaml":       "_id: " + team + "\n_type: team\ncode: DATA\nname: Data\n",
+		"data/service/" + service + ".yaml": "_id: " + service + "\n_type: service\nname: ingest\nteamId: " + team + "\ntier: batch\n",
+	}
+	for rel, content := range files {
+		writeTestFile(t, filepath.Join(ws, filepath.FromSlash(rel)), content)
+	}
+	saveTestWorkspace(t, repo, "additions")
+
+	result, err := repo.MergeWorkspace("additions", nil, nil, MergeOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.Merged || result.MergedFiles != 2 || len(result.Conflicts) != 0 {
+		t.Fatalf("result = %+v, want both new files merged", result)
+	}
+	for rel, content := range files {
+		b, err := os.ReadFile(filepath.Join(repo.Root, filepath.FromSlash(rel)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(b) != content {
+			t.Errorf("%s =\n%s\nwant the workspace version\n%s", rel, b, content)
+		}
+	}
+
+	// New objects still have to validate against main, which has since
+	// taken the name the draft uses.
+	ws = newTestWorkspace(t, repo, "invalid")
+	added := testObjectID(5)
+	writeTestFile(t, filepath.Join(ws, "data", "service", added+".yaml"),
+		"_id: "+added+"\n_type: service\nname: reports\nteamId: "+testTeamID+"\ntier: batch\n")
+	saveTestWorkspace(t, repo, "invalid")
+	taken := testObjectID(6)
+	writeTestFile(t, filepath.Join(repo.Root, "data", "service", taken+".yaml"),
+		"_id: "+taken+"\n_type: service\nname: reports\nteamId: "+testTeamID+"\ntier: core\n")
+	commitTestMain(t, repo, "add reports")
+	if _, err := repo.MergeWorkspace("invalid", nil, nil, MergeOptions{}); err == nil || !strings.Contains(err.Error(), "merge blocked by validation") {
+		t.Errorf("merge error = %v, want it blocked by validation", err)
+	}
+	if _, err := os.Stat(filepath.Join(repo.Root, "data", "service", added+".yaml")); !os.IsNotExist(err) {
+		t.Errorf("blocked merge left the new file in main: %v", err)
+	}
+}
+
+// addTestRemote adds a bare repository as origin, pushes the base branch to
+// it, and returns its path.
+func addTestRemote(t *testing.T, repo *Repository) string {
+	t.Helper()
+	remote := filepath.Join(t.TempDir(), "remote.git")
+	if _, err := repo.runGit(repo.Root, "init", "--bare", "-q", remote); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repo.runGit(repo.Root, "remote", "add", pushRemote, remote); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repo.runGit(repo.Root, "push", "-q", pushRemote, repo.BaseBranch); err != nil {
+		t.Fatal(err)
+	}
+	return remote
+}
+
+func TestMergeWorkspaceSyncsMainFromOrigin(t *testing.T) {
+	repo := newTestRepository(t)
+	remote := addTestRemote(t, repo)
+
+	// The draft edits the team code while someone else renames the team
+	// on the shared main.
+	teamRel := filepath.Join("data", "team", testTeamID+".yaml")
+	ws := newTestWorkspace(t, repo, "recode")
+	replaceTestFile(t, filepath.Join(ws, teamRel), "code: PLAT", "code: PLT")
+	saveTestWorkspace(t, repo, "recode")
+
+	other := filepath.Join(t.TempDir(), "other")
+	if _, err := repo.runGit(repo.Root, "clone", "-q", "--branch", repo.BaseBranch, remote, other); err != nil {
+		t.Fatal(err)
+	}
+	replaceTestFile(t, filepath.Join(other, teamRel), "name: Platform", "name: Platform Engineering")
+	if _, err := repo.runGit(other, "-c", "user.name=test", "-c", "user.email=test@local", "commit", "-qam", "rename team"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repo.runGit(other, "push", "-q", "origin", repo.BaseBranch); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := repo.MergeWorkspace("recode", nil, nil, MergeOptions{Sync: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.Merged || len(result.Conflicts) != 0 {
+		t.Fatalf("result = %+v, want a clean merge", result)
+	}
+	b, err := os.ReadFile(filepath.Join(repo.Root, teamRel))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"code: PLT", "Platform Engineering"} {
+		if !strings.Contains(string(b), want) {
+			t.Errorf("merged team =\n%s\nwant %q", b, want)
+		}
+	}
+
+	// A main with commits that origin lacks cannot fast-forward.
+	if _, err := repo.runGit(other, "-c", "user.name=test", "-c", "user.email=test@local", "commit", "-q", "--allow-empty", "-m", "remote only"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repo.runGit(other, "push", "-q", "origin", repo.BaseBranch); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repo.runGit(repo.Root, "-c", "user.name=test", "-c", "user.email=test@local", "commit", "-q", "--allow-empty", "-m", "local only"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repo.SyncMain(); err == nil || !strings.Contains(err.Error(), "cannot sync") {
+		t.Errorf("SyncMain error = %v, want diverged main refused", err)
+	}
+}
+
+func TestSyncMainRequiresOrigin(t *testing.T) {
+	repo := newTestRepository(t)
+	if _, err := repo.SyncMain(); err == nil || !strings.Contains(err.Error(), "no remote") {
+		t.Errorf("SyncMain error = %v, want a missing remote error", err)
+	}
+	newTestWorkspace(t, repo, "draft")
+	if _, err := repo.MergeWorkspace("draft", nil, nil, MergeOptions{Sync: true}); err == nil || !strings.Contains(err.Error(), "cannot sync") {
+		t.Errorf("merge error = %v, want sync refused without a remote", err)
+	}
+}
+
+// replaceTestFile replaces old with new in the file at path, failing if
+// old is absent.
+func replaceTestFile(t *testing.T, path, old, new string) {
+	t.Helper()
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(b), old) {
+		t.Fatalf("%s does not contain %q:\n%s", path, old, b)
+	}
+	writeTestFile(t, path, strings.Replace(string(b), old, new, 1))
+}
+
+func TestMergeWorkspacePushesBaseBranch(t *testing.T) {
+	repo := newTestRepository(t)
+	teamRel := filepath.Join("data", "team", testTeamID+".yaml")
+	ws := newTestWorkspace(t, repo, "recode")
+	replaceTestFile(t, filepath.Join(ws, teamRel), "code: PLAT", "code: PLT")
+	saveTestWorkspace(t, repo, "recode")
+	if _, err := repo.MergeWorkspace("recode", nil, nil, MergeOptions{Push: true}); err == nil || !strings.Contains(err.Error(), "cannot push") {
+		t.Errorf("push without origin: error = %v", err)
+	}
+
+	remote := addTestRemote(t, repo)
+	remoteHead := func() string {
+		t.Helper()
+		out, err := repo.runGit(repo.Root, "--git-dir", remote, "rev-parse", repo.BaseBranch)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return strings.TrimSpace(out)
+	}
+	localHead := func() string {
+		t.Helper()
+		out, err := repo.runGit(repo.Root, "rev-parse", repo.BaseBranch)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return strings.TrimSpace(out)
+	}
+
+	result, err := repo.MergeWorkspace("recode", nil, nil, MergeOptions{Push: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.Merged || !result.Pushed {
+		t.Errorf("result = %+v, want merged and pushed", result)
+	}
+	if remoteHead() != localHead() {
+		t.Error("origin does not have the merge commit")
+	}
+	if repo.WorkspaceExists("recode") {
+		t.Error("pushed merge kept the workspace")
+	}
+
+	// Someone else pushes first, so this push is rejected as non-fast-forward.
+	other := filepath.Join(t.TempDir(), "other")
+	if _, err := repo.runGit(repo.Root, "clone", "-q", "--branch", repo.BaseBranch, remote, other); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repo.runGit(other, "-c", "user.name=test", "-c", "user.email=test@local", "commit", "-q", "--allow-empty", "-m", "remote only"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repo.runGit(other, "push", "-q", "origin", repo.BaseBranch); err != nil {
+		t.Fatal(err)
+	}
+	ws = newTestWorkspace(t, repo, "rename")
+	replaceTestFile(t, filepath.Join(ws, teamRel), "name: Platform", "name: Core")
+	saveTestWorkspace(t, repo, "rename")
+	before := remoteHead()
+
+	result, err = repo.MergeWorkspace("rename", nil, nil, MergeOptions{Push: true})
+	if err == nil || !strings.Contains(err.Error(), "push failed, workspace rename kept") {
+		t.Fatalf("rejected push: error = %v", err)
+	}
+	if !result.Merged || result.Pushed {
+		t.Errorf("result = %+v, want merged but not pushed", result)
+	}
+	if subject, err := repo.runGit(repo.Root, "log", "-1", "--format=%s", repo.BaseBranch); err != nil {
+		t.Fatal(err)
+	} else if !strings.HasPrefix(subject, "Merge ") {
+		t.Errorf("%s head = %q, want the merge commit kept", repo.BaseBranch, subject)
+	}
+	if !repo.WorkspaceExists("rename") {
+		t.Error("failed push deleted the workspace")
+	}
+	if remoteHead() != before {
+		t.Error("rejected push changed origin")
+	}
+}
+
+func TestMergeWorkspaceResolvesArrayConflictWithUnion(t *testing.T) {
+	repo := newTestRepository(t)
+	serviceRel := filepath.Join("data", "service", testServiceID+".yaml")
+	ws := newTestWorkspace(t, repo, "ports")
+	replaceTestFile(t, filepath.Join(ws, serviceRel), "  - 443\n  - 8443\n", "  - 8443\n  - 80\n  - 443\n")
+	saveTestWorkspace(t, repo, "ports")
+	replaceTestFile(t, filepath.Join(repo.Root, serviceRel), "  - 443\n  - 8443\n", "  - 9000\n  - 443\n")
+	commitTestMain(t, repo, "main ports")
+
+	result, err := repo.MergeWorkspace("ports", nil, nil, MergeOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Merged || len(result.Conflicts) != 1 || result.Conflicts[0].Field != "ports" {
+		t.Fatalf("result = %+v, want one ports conflict", result)
+	}
+	c := result.Conflicts[0]
+	wantElements := []conflictElement{
+		{Value: "443", InBase: true, InMain: true, InWorkspace: true},
+		{Value: "8443", InBase: true, InWorkspace: true},
+		{Value: "9000", InMain: true},
+		{Value: "80", InWorkspace: true},
+	}
+	if got := arrayConflictElements(c.Base, c.Main, c.Workspace); !reflect.DeepEqual(got, wantElements) {
+		t.Errorf("elements = %+v, want %+v", got, wantElements)
+	}
+
+	h := newTestHandler(t, repo)
+	rec := postTestForm(t, h, "/w/ports/promote", url.Values{})
+	if radio := `name="resolve.` + c.Key + `" value="union"`; rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), radio) {
+		t.Errorf("conflict page: status %d, want a union choice %s", rec.Code, radio)
+	}
+	rec = postTestForm(t, h, "/w/ports/promote", url.Values{"resolve." + c.Key: {"union"}})
+	if loc := rec.Header().Get("Location"); rec.Code != http.StatusSeeOther || strings.Contains(loc, "error=1") {
+		t.Fatalf("promote with union: status %d, location %q\n%s", rec.Code, loc, rec.Body.String())
+	}
+	b, err := os.ReadFile(filepath.Join(repo.Root, serviceRel))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Every item of either side once, in numeric order; with no shared base
+	// the union keeps 8443 even though main removed it.
+	if want := "ports:\n  - 80\n  - 443\n  - 8443\n  - 9000\n"; !strings.Contains(string(b), want) {
+		t.Errorf("merged service =\n%s\nwant %q", b, want)
+	}
+}
+
+func TestMergeDryRunWritesAndCommitsNothing(t *testing.T) {
+	repo := newTestRepository(t)
+	t.Setenv("WORKTREEFOUNDRY_WORKSPACE", "")
+	teamRel := filepath.Join("data", "team", testTeamID+".yaml")
+	added := testObjectID(3)
+	ws := newTestWorkspace(t, repo, "draft")
+	replaceTestFile(t, filepath.Join(ws, teamRel), "code: PLAT", "code: PLT")
+	writeTestFile(t, filepath.Join(ws, "data", "team", added+".yaml"), "_id: "+added+"\n_type: team\ncode: DATA\nname: Data\n")
+	saveTestWorkspace(t, repo, "draft")
+
+	git := func(dir string, args ...string) string {
+		t.Helper()
+		out, err := repo.runGit(dir, args...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return out
+	}
+	snapshot := func() []string {
+		t.Helper()
+		team, err := os.ReadFile(filepath.Join(repo.Root, teamRel))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return []string{
+			git(repo.Root, "rev-parse", repo.BaseBranch),
+			git(repo.Root, "rev-parse", repo.BranchForWorkspace("draft")),
+			git(repo.Root, "status", "--porcelain"),
+			git(ws, "status", "--porcelain"),
+			git(repo.Root, "worktree", "list", "--porcelain"),
+			string(team),
+		}
+	}
+	before := snapshot()
+
+	var buf bytes.Buffer
+	if err := mergeDryRun(&buf, repo, "draft", "json", nil, nil, ""); err != nil {
+		t.Fatal(err)
+	}
+	var report mergeDryRunReport
+	if err := json.Unmarshal(buf.Bytes(), &report); err != nil {
+		t.Fatal(err)
+	}
+	wantChanged := []string{"data/team/" + testTeamID + ".yaml", "data/team/" + added + ".yaml"}
+	if !report.OK || !reflect.DeepEqual(report.Changed, wantChanged) {
+		t.Errorf("report = %+v, want ok with changed %v", report, wantChanged)
+	}
+	if after := snapshot(); !reflect.DeepEqual(after, before) {
+		t.Errorf("dry run changed the repository:\nbefore %q\nafter  %q", before, after)
+	}
+	if _, err := os.Stat(filepath.Join(repo.Root, "data", "team", added+".yaml")); !os.IsNotExist(err) {
+		t.Errorf("dry run wrote the new object to main: %v", err)
+	}
+
+	// A blocked dry run also leaves everything alone.
+	replaceTestFile(t, filepath.Join(repo.Root, teamRel), "code: PLAT", "code: PLTF")
+	commitTestMain(t, repo, "main code")
+	before = snapshot()
+	conflicts := filepath.Join(t.TempDir(), "conflicts.json")
+	err := Run(context.Background(), []string{"merge", "--repository", repo.Root, "--workspace", "draft", "--dry-run", "--export-conflicts", conflicts}, "test")
+	if err == nil || !strings.Contains(err.Error(), "merge would be blocked by 1 conflict(s)") {
+		t.Errorf("conflicting dry run: error = %v", err)
+	}
+	if after := snapshot(); !reflect.DeepEqual(after, before) {
+		t.Errorf("conflicting dry run changed the repository:\nbefore %q\nafter  %q", before, after)
+	}
+	if _, err := os.Stat(conflicts); err != nil {
+		t.Errorf("conflict file not exported: %v", err)
+	}
+}
@@ -0,0 +1,96 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+type FieldMigration struct {
+	Type     string
+	Field    string
+	Checked  int
+	Breaking []ValidationIssue
+	Applied  bool
+}
+
+// MigrateFieldType replaces the schema property for field with newProp after
+// checking every existing object's value against it. The schema file is only
+// rewritten when no object would break, unless force is set.
+func (r *Repository) MigrateFieldType(repoPath, typeName, field string, newProp json.RawMessage, force bool) (FieldMigration, error) {
+	migration := FieldMigration{Type: typeName, Field: field}
+	path := filepath.Join(repoPath, "config", "schemas", typeName+".schema.json")
+	b, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return migration, fmt.Errorf("unknown type %q", typeName)
+		}
+		return migration, err
+	}
+	var doc map[string]any
+	if err := json.Unmarshal(b, &doc); err != nil {
+		return migration, fmt.Errorf("parse schema %s: %w", typeName, err)
+	}
+	var prop any
+	if err := json.Unmarshal(newProp, &prop); err != nil {
+		return migration, fmt.Errorf("parse property: %w", err)
+	}
+	if _, ok := prop.(map[string]any); !ok {
+		return migration, fmt.Errorf("property must be a JSON object")
+	}
+	props, _ := doc["properties"].(map[string]any)
+	if props == nil {
+		props = make(map[string]any)
+		doc["properties"] = props
+	}
+	props[field] = prop
+
+	updated, err := json.Marshal(doc)
+	if err != nil {
+		return migration, err
+	}
+	var raw rawSchema
+	if err := json.Unmarshal(updated, &raw); err != nil {
+		return migration, fmt.Errorf("schema %s: %w", typeName, err)
+	}
+	if err := resolveSchemaRefs(repoPath, &raw); err != nil {
+		return migration, fmt.Errorf("schema %s: %w", typeName, err)
+	}
+	schema, err := normalizeSchema(typeName, raw)
+	if err != nil {
+		return migration, fmt.Errorf("schema %s: %w", typeName, err)
+	}
+	resolved, issues := ResolveEnumRefs(repoPath, map[string]Schema{typeName: schema})
+	if len(issues) > 0 {
+		return migration, fmt.Errorf("schema %s: %s", typeName, issues[0].Message)
+	}
+	schema = resolved[typeName]
+	sp := schema.Properties[field]
+
+	objects, err := r.ListObjectsForType(repoPath, typeName)
+	if err != nil {
+		return migration, err
+	}
+	result := ValidationResult{}
+	for _, obj := range objects {
+		migration.Checked++
+		value, ok := obj.Data[field]
+		if !ok || value == nil {
+			if _, required := schema.Required[field]; required {
+				result.Add(ValidationIssue{Stage: "schema", Path: obj.Path, Field: field, Message: "required field is missing"})
+			}
+			continue
+		}
+		validateProperty(field, value, sp, obj.Path, &result)
+	}
+	migration.Breaking = result.Issues
+	if len(migration.Breaking) > 0 && !force {
+		return migration, nil
+	}
+	if err := r.writeJSONFile(path, doc); err != nil {
+		return migration, err
+	}
+	migration.Applied = true
+	return migration, nil
+}
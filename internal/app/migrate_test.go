@@ -0,0 +1,58 @@
+package app
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestMigrateFieldTypeWideningAndTightening(t *testing.T) {
+	repo := newTestRepository(t)
+	ws := newTestWorkspace(t, repo, "draft")
+	schemaPath := filepath.Join(ws, "config", "schemas", "service.schema.json")
+	tierEnum := func() []any {
+		t.Helper()
+		b, err := os.ReadFile(schemaPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var doc map[string]any
+		if err := json.Unmarshal(b, &doc); err != nil {
+			t.Fatal(err)
+		}
+		enum, _ := doc["properties"].(map[string]any)["tier"].(map[string]any)["enum"].([]any)
+		return enum
+	}
+
+	// Widening keeps every object valid and is applied.
+	m, err := repo.MigrateFieldType(ws, "service", "tier", json.RawMessage(`{"type":"string","enum":["batch","core","edge","gpu"]}`), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !m.Applied || m.Checked != 1 || len(m.Breaking) != 0 || len(tierEnum()) != 4 {
+		t.Errorf("widening = %+v (enum %v), want applied with nothing breaking", m, tierEnum())
+	}
+
+	// Dropping edge breaks the sample service, so the schema is kept.
+	tighter := json.RawMessage(`{"type":"string","enum":["batch","core"]}`)
+	m, err = repo.MigrateFieldType(ws, "service", "tier", tighter, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Applied || len(m.Breaking) != 1 || m.Breaking[0].Path != "data/service/"+testServiceID+".yaml" {
+		t.Errorf("tightening = %+v, want the sample service reported and nothing applied", m)
+	}
+	if len(tierEnum()) != 4 {
+		t.Errorf("enum = %v, want the schema unchanged", tierEnum())
+	}
+
+	// force applies it anyway and still reports what breaks.
+	m, err = repo.MigrateFieldType(ws, "service", "tier", tighter, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !m.Applied || len(m.Breaking) != 1 || len(tierEnum()) != 2 {
+		t.Errorf("forced tightening = %+v (enum %v), want applied with one breaking object", m, tierEnum())
+	}
+}
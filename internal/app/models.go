@@ -2,26 +2,45 @@ package app
 
 import (
 	"fmt"
+	"regexp"
 	"sort"
 )
 
 type ValidationResult struct {
-	Issues []ValidationIssue
+	Issues   []ValidationIssue
+	Warnings []ValidationIssue
+	// exitOnFirst keeps only the first issue; see ValidateOptions.
+	exitOnFirst bool
 }
 
 func (r *ValidationResult) Add(issue ValidationIssue) {
+	if r.stopped() {
+		return
+	}
 	r.Issues = append(r.Issues, issue)
 }
 
+// stopped reports whether an exit-on-first walk already has its issue.
+func (r *ValidationResult) stopped() bool {
+	return r.exitOnFirst && len(r.Issues) > 0
+}
+
+func (r *ValidationResult) AddWarning(issue ValidationIssue) {
+	r.Warnings = append(r.Warnings, issue)
+}
+
 func (r ValidationResult) OK() bool {
 	return len(r.Issues) == 0
 }
 
 type ValidationIssue struct {
-	Stage   string
-	Path    string
-	Field   string
-	Message string
+	Stage   string `json:"stage"`
+	Path    string `json:"path"`
+	Field   string `json:"field"`
+	Message string `json:"message"`
+	// Related lists the other object files a constraint issue involves, such
+	// as the object already holding a duplicate unique value.
+	Related []string `json:"related,omitempty"`
 }
 
 func (i ValidationIssue) String() string {
@@ -35,19 +54,40 @@ func (i ValidationIssue) String() string {
 }
 
 type Schema struct {
-	Type       string
-	Required   map[string]struct{}
-	Properties map[string]SchemaProperty
+	Type              string
+	Required          map[string]struct{}
+	Properties        map[string]SchemaProperty
+	OneOfRequired     [][]string
+	MutuallyExclusive [][]string
+	// Aliases maps each property alias to its property name.
+	Aliases map[string]string
 }
 
 type SchemaProperty struct {
-	Type      string
-	Enum      []string
-	MinLength *int
-	MaxLength *int
-	Minimum   *float64
-	Maximum   *float64
-	ItemsType string
+	Type             string
+	Enum             []string
+	EnumNumbers      []float64
+	EnumRef          string
+	MinLength        *int
+	MaxLength        *int
+	Pattern          *string
+	Format           string
+	Default          any
+	Minimum          *float64
+	Maximum          *float64
+	ExclusiveMinimum *float64
+	ExclusiveMaximum *float64
+	MultipleOf       *float64
+	ItemsType        string
+	MinItems         *int
+	MaxItems         *int
+	UniqueItems      bool
+	ArrayMerge       string
+	Aliases          []string
+	Properties       map[string]SchemaProperty
+	Required         map[string]struct{}
+
+	patternRe *regexp.Regexp
 }
 
 type Constraints struct {
@@ -66,6 +106,7 @@ type ForeignKeyConstraint struct {
 	ToType         string `json:"toType"`
 	ToField        string `json:"toField"`
 	ToDisplayField string `json:"toDisplayField,omitempty"`
+	OnDelete       string `json:"onDelete,omitempty"`
 }
 
 type Object struct {
@@ -75,6 +116,7 @@ type Object struct {
 	Path     string
 	Deleted  bool
 	Modified bool
+	Comments *YAMLComments
 }
 
 type RepositoryState struct {
@@ -83,7 +125,10 @@ type RepositoryState struct {
 	Constraints Constraints
 }
 
-func sortedKeys(m map[string]any) []string {
+// sortedKeys returns the keys of m in ascending order. Loops whose output is
+// user-visible (issues, errors, rendered lists) range over sortedKeys rather
+// than the map so repeated runs produce identical results.
+func sortedKeys[V any](m map[string]V) []string {
 	keys := make([]string, 0, len(m))
 	for k := range m {
 		keys = append(keys, k)
@@ -1,18 +1,32 @@
 package app
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
 	"regexp"
+	"runtime"
 	"sort"
 	"strings"
+	"sync"
+	"unicode/utf8"
 )
 
 var uuidPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[1-5][0-9a-fA-F]{3}-[89abAB][0-9a-fA-F]{3}-[0-9a-fA-F]{12}$`)
 
-func LoadObjects(root string) (map[string][]Object, error) {
+// parseObjectYAML parses object YAML, returning its comments when
+// YAMLComments is set.
+func (y YAMLOptions) parseObjectYAML(b []byte) (map[string]any, *YAMLComments, error) {
+	if y.YAMLComments {
+		return ParseSimpleYAMLObjectWithComments(b)
+	}
+	m, err := ParseSimpleYAMLObject(b)
+	return m, nil, err
+}
+
+func (y YAMLOptions) LoadObjects(root string) (map[string][]Object, error) {
 	dataDir := filepath.Join(root, "data")
 	entries, err := os.ReadDir(dataDir)
 	if err != nil {
@@ -33,19 +47,11 @@ func LoadObjects(root string) (map[string][]Object, error) {
 		if err != nil {
 			return nil, err
 		}
-		for _, file := range files {
-			if file.IsDir() || !strings.HasSuffix(file.Name(), ".yaml") {
-				continue
+		for _, parsed := range y.parseObjectFiles(root, typeName, files) {
+			if parsed.err != nil {
+				return nil, parsed.err
 			}
-			id := strings.TrimSuffix(file.Name(), ".yaml")
-			objPath := filepath.Join(typeDir, file.Name())
-			obj, err := ParseObjectFile(objPath, typeName, id)
-			if err != nil {
-				return nil, err
-			}
-			obj.Path, _ = filepath.Rel(root, objPath)
-			obj.Path = filepath.ToSlash(obj.Path)
-			objects[typeName] = append(objects[typeName], obj)
+			objects[typeName] = append(objects[typeName], parsed.obj)
 		}
 		sort.Slice(objects[typeName], func(i, j int) bool {
 			return objects[typeName][i].ID < objects[typeName][j].ID
@@ -54,12 +60,69 @@ func LoadObjects(root string) (map[string][]Object, error) {
 	return objects, nil
 }
 
-func ParseObjectFile(path, expectedType, expectedID string) (Object, error) {
+// parsedObject is the outcome of parsing one object file; rel is its
+// slash-separated path relative to the repository root.
+type parsedObject struct {
+	rel string
+	obj Object
+	err error
+}
+
+// parseObjectFiles parses the .yaml files of a type directory on up to
+// GOMAXPROCS goroutines. Results keep the order of files, so callers see the
+// same objects and errors in the same order as a serial walk.
+func (y YAMLOptions) parseObjectFiles(root, typeName string, files []os.DirEntry) []parsedObject {
+	typeDir := filepath.Join(root, "data", typeName)
+	results := make([]parsedObject, 0, len(files))
+	for _, file := range files {
+		if file.IsDir() || isSymlink(file) || !strings.HasSuffix(file.Name(), ".yaml") {
+			continue
+		}
+		results = append(results, parsedObject{rel: filepath.ToSlash(filepath.Join("data", typeName, file.Name()))})
+	}
+	workers := min(runtime.GOMAXPROCS(0), len(results))
+	next := make(chan int)
+	var wg sync.WaitGroup
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				name := filepath.Base(results[i].rel)
+				obj, err := y.ParseObjectFile(filepath.Join(typeDir, name), typeName, strings.TrimSuffix(name, ".yaml"))
+				obj.Path = results[i].rel
+				results[i].obj, results[i].err = obj, err
+			}
+		}()
+	}
+	for i := range results {
+		next <- i
+	}
+	close(next)
+	wg.Wait()
+	return results
+}
+
+func (y YAMLOptions) ParseObjectFile(path, expectedType, expectedID string) (Object, error) {
 	b, err := os.ReadFile(path)
 	if err != nil {
 		return Object{}, err
 	}
-	m, err := ParseSimpleYAMLObject(b)
+	obj, err := y.parseObjectContent(b, expectedType, expectedID)
+	if err != nil {
+		return Object{}, err
+	}
+	obj.Path = path
+	return obj, nil
+}
+
+// parseObjectContent parses the content of an object file, checking its _id
+// and _type against the expected values when they are set.
+func (y YAMLOptions) parseObjectContent(b []byte, expectedType, expectedID string) (Object, error) {
+	if offset := invalidUTF8Offset(b); offset >= 0 {
+		return Object{}, fmt.Errorf("file is not valid UTF-8 (invalid byte at offset %d)", offset)
+	}
+	m, comments, err := y.parseObjectYAML(b)
 	if err != nil {
 		return Object{}, fmt.Errorf("parse YAML: %w", err)
 	}
@@ -68,8 +131,8 @@ func ParseObjectFile(path, expectedType, expectedID string) (Object, error) {
 	}
 
 	normalized := make(map[string]any, len(m))
-	for k, v := range m {
-		nv, err := normalizeObjectValue(v)
+	for _, k := range sortedKeys(m) {
+		nv, err := normalizeObjectValue(m[k])
 		if err != nil {
 			return Object{}, fmt.Errorf("field %s: %w", k, err)
 		}
@@ -89,7 +152,23 @@ func ParseObjectFile(path, expectedType, expectedID string) (Object, error) {
 	if expectedType != "" && typeVal != expectedType {
 		return Object{}, fmt.Errorf("_type %q does not match folder %q", typeVal, expectedType)
 	}
-	return Object{ID: idVal, Type: typeVal, Data: normalized, Path: path}, nil
+	return Object{ID: idVal, Type: typeVal, Data: normalized, Comments: comments}, nil
+}
+
+// invalidUTF8Offset returns the offset of the first byte that does not start a
+// valid UTF-8 sequence, or -1 when b is valid.
+func invalidUTF8Offset(b []byte) int {
+	if utf8.Valid(b) {
+		return -1
+	}
+	for i := 0; i < len(b); {
+		r, size := utf8.DecodeRune(b[i:])
+		if r == utf8.RuneError && size <= 1 {
+			return i
+		}
+		i += size
+	}
+	return -1
 }
 
 func normalizeObjectValue(v any) (any, error) {
@@ -135,26 +214,73 @@ func normalizeObjectValue(v any) (any, error) {
 		}
 		return result, nil
 	case map[string]any:
-		return nil, errors.New("nested objects are not supported in v1")
+		result := make(map[string]any, len(t))
+		for _, k := range sortedKeys(t) {
+			item := t[k]
+			if _, ok := item.(map[string]any); ok {
+				return nil, errors.New("nested objects deeper than one level are not supported")
+			}
+			nv, err := normalizeObjectValue(item)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", k, err)
+			}
+			result[k] = nv
+		}
+		return result, nil
 	default:
 		return nil, fmt.Errorf("unsupported value type %T", v)
 	}
 }
 
-func WriteObject(repoRoot string, obj Object) error {
+func (o FileOptions) WriteObject(repoRoot string, obj Object) error {
 	if obj.ID == "" || obj.Type == "" {
 		return errors.New("object missing id/type")
 	}
+	enforceReservedFields(&obj)
 	rel := filepath.Join("data", obj.Type, obj.ID+".yaml")
 	abs := filepath.Join(repoRoot, rel)
-	if err := os.MkdirAll(filepath.Dir(abs), 0o755); err != nil {
+	if err := o.mkdirAll(filepath.Dir(abs)); err != nil {
 		return err
 	}
-	b, err := CanonicalYAML(obj.Data)
+	comments := obj.Comments
+	if comments == nil && o.YAMLComments {
+		comments = existingComments(abs)
+	}
+	b, err := o.MarshalObject(obj.Data, comments)
 	if err != nil {
 		return err
 	}
-	return os.WriteFile(abs, b, 0o644)
+	return o.writeFile(abs, b)
+}
+
+// existingComments returns the comments of the file at path, or nil when it
+// is missing or unreadable, so rewrites of an object keep its annotations.
+func existingComments(path string) *YAMLComments {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	_, comments, err := ParseSimpleYAMLObjectWithComments(b)
+	if err != nil {
+		return nil
+	}
+	return comments
+}
+
+func enforceReservedFields(obj *Object) []string {
+	if obj.Data == nil {
+		obj.Data = map[string]any{}
+	}
+	notes := make([]string, 0)
+	if v, ok := obj.Data["_id"]; ok && v != obj.ID {
+		notes = append(notes, fmt.Sprintf("_id %q ignored; must match path id %q", valueToText(v), obj.ID))
+	}
+	if v, ok := obj.Data["_type"]; ok && v != obj.Type {
+		notes = append(notes, fmt.Sprintf("_type %q ignored; must match path type %q", valueToText(v), obj.Type))
+	}
+	obj.Data["_id"] = obj.ID
+	obj.Data["_type"] = obj.Type
+	return notes
 }
 
 func DeleteObject(repoRoot, typeName, id string) error {
@@ -165,9 +291,9 @@ func DeleteObject(repoRoot, typeName, id string) error {
 	return nil
 }
 
-func ReadObject(repoRoot, typeName, id string) (Object, error) {
+func (y YAMLOptions) ReadObject(repoRoot, typeName, id string) (Object, error) {
 	path := filepath.Join(repoRoot, "data", typeName, id+".yaml")
-	obj, err := ParseObjectFile(path, typeName, id)
+	obj, err := y.ParseObjectFile(path, typeName, id)
 	if err != nil {
 		return Object{}, err
 	}
@@ -187,53 +313,117 @@ func formatNumber(n float64) string {
 	return fmt.Sprintf("%g", n)
 }
 
-func RewriteCanonicalFiles(repoPath string, changed []string) error {
+// RewriteCanonicalFiles rewrites the listed data files in canonical form and
+// returns the paths whose content changed. Keys stored under a schema alias
+// are renamed to their property.
+func (o FileOptions) RewriteCanonicalFiles(repoPath string, changed []string) ([]string, error) {
+	rewritten := make([]string, 0)
+	schemas, _ := LoadSchemas(repoPath)
 	for _, rel := range changed {
 		if !strings.HasPrefix(rel, "data/") || !strings.HasSuffix(rel, ".yaml") {
 			continue
 		}
 		abs := filepath.Join(repoPath, filepath.FromSlash(rel))
-		if _, err := os.Stat(abs); err != nil {
+		current, err := os.ReadFile(abs)
+		if err != nil {
 			continue
 		}
 		typeName := filepath.Base(filepath.Dir(abs))
 		id := strings.TrimSuffix(filepath.Base(abs), ".yaml")
-		obj, err := ParseObjectFile(abs, typeName, id)
+		obj, err := o.ParseObjectFile(abs, typeName, id)
 		if err != nil {
-			return fmt.Errorf("canonicalize %s: %w", rel, err)
+			return rewritten, fmt.Errorf("canonicalize %s: %w", rel, err)
+		}
+		data, _ := canonicalAliasData(obj.Data, schemas[typeName])
+		for _, alias := range sortedKeys(schemas[typeName].Aliases) {
+			_, had := obj.Data[alias]
+			_, kept := data[alias]
+			if had && !kept {
+				renameCommentKeys(obj.Comments, alias, schemas[typeName].Aliases[alias])
+			}
 		}
-		b, err := CanonicalYAML(obj.Data)
+		obj.Data = data
+		b, err := o.MarshalObject(obj.Data, obj.Comments)
 		if err != nil {
-			return err
+			return rewritten, err
 		}
-		if err := os.WriteFile(abs, b, 0o644); err != nil {
-			return err
+		if bytes.Equal(current, b) {
+			continue
+		}
+		if err := o.writeFile(abs, b); err != nil {
+			return rewritten, err
 		}
+		rewritten = append(rewritten, rel)
 	}
-	return nil
+	return rewritten, nil
 }
 
-func ListObjectsForType(repoRoot, typeName string) ([]Object, error) {
-	dir := filepath.Join(repoRoot, "data", typeName)
-	entries, err := os.ReadDir(dir)
+// renameCommentKeys moves the comments of key, its array items, and its
+// nested fields to newKey.
+func renameCommentKeys(comments *YAMLComments, key, newKey string) {
+	if comments == nil {
+		return
+	}
+	for _, path := range sortedKeys(comments.Keys) {
+		rest, ok := strings.CutPrefix(path, key)
+		if !ok || (rest != "" && !strings.HasPrefix(rest, "[") && !strings.HasPrefix(rest, ".")) {
+			continue
+		}
+		comments.Keys[newKey+rest] = comments.Keys[path]
+		delete(comments.Keys, path)
+	}
+}
+
+// CanonicalizeDataFiles runs RewriteCanonicalFiles over every object file
+// under data/.
+func (o FileOptions) CanonicalizeDataFiles(repoPath string) ([]string, error) {
+	dataDir := filepath.Join(repoPath, "data")
+	types, err := os.ReadDir(dataDir)
 	if err != nil {
 		if os.IsNotExist(err) {
 			return nil, nil
 		}
 		return nil, err
 	}
-	objs := make([]Object, 0)
-	for _, e := range entries {
-		if e.IsDir() || !strings.HasSuffix(e.Name(), ".yaml") {
+	files := make([]string, 0)
+	for _, typeEntry := range types {
+		if !typeEntry.IsDir() || isSymlink(typeEntry) {
 			continue
 		}
-		id := strings.TrimSuffix(e.Name(), ".yaml")
-		obj, err := ParseObjectFile(filepath.Join(dir, e.Name()), typeName, id)
+		entries, err := os.ReadDir(filepath.Join(dataDir, typeEntry.Name()))
 		if err != nil {
 			return nil, err
 		}
-		obj.Path = filepath.ToSlash(filepath.Join("data", typeName, e.Name()))
-		objs = append(objs, obj)
+		for _, e := range entries {
+			if e.IsDir() || isSymlink(e) || !strings.HasSuffix(e.Name(), ".yaml") {
+				continue
+			}
+			files = append(files, "data/"+typeEntry.Name()+"/"+e.Name())
+		}
+	}
+	sort.Strings(files)
+	return o.RewriteCanonicalFiles(repoPath, files)
+}
+
+func isSymlink(entry os.DirEntry) bool {
+	return entry.Type()&os.ModeSymlink != 0
+}
+
+func (y YAMLOptions) ListObjectsForType(repoRoot, typeName string) ([]Object, error) {
+	dir := filepath.Join(repoRoot, "data", typeName)
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	objs := make([]Object, 0)
+	for _, parsed := range y.parseObjectFiles(repoRoot, typeName, entries) {
+		if parsed.err != nil {
+			return nil, parsed.err
+		}
+		objs = append(objs, parsed.obj)
 	}
 	sort.Slice(objs, func(i, j int) bool {
 		return objs[i].ID < objs[j].ID
@@ -0,0 +1,73 @@
+package app
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// writeTestServices writes n valid service objects to root and returns
+// their ids.
+func writeTestServices(tb testing.TB, root string, n int) []string {
+	tb.Helper()
+	ids := make([]string, 0, n)
+	for i := range n {
+		id := fmt.Sprintf("%08x-0000-4000-8000-%012x", i+1, i+1)
+		ids = append(ids, id)
+		content := "_id: " + id + "\n_type: service\nname: svc-" + id[:8] + "\nteamId: " + testTeamID + "\ntier: core\n"
+		writeTestFile(tb, filepath.Join(root, "data", "service", id+".yaml"), content)
+	}
+	return ids
+}
+
+func TestLoadObjectsWithIssuesOrderAndIssues(t *testing.T) {
+	repo := newTestRepository(t)
+	ids := append(writeTestServices(t, repo.Root, 200), testServiceID)
+	sort.Strings(ids)
+	broken := []string{"data/service/" + testObjectID(7) + ".yaml", "data/service/" + testObjectID(9) + ".yaml"}
+	for _, rel := range broken {
+		writeTestFile(t, filepath.Join(repo.Root, filepath.FromSlash(rel)), "name: [unclosed\n")
+	}
+
+	objects, issues := repo.loadObjectsWithIssues(repo.Root)
+	got := make([]string, 0, len(objects["service"]))
+	for _, obj := range objects["service"] {
+		got = append(got, obj.ID)
+	}
+	if strings.Join(got, ",") != strings.Join(ids, ",") {
+		t.Errorf("service ids are not the sorted valid objects:\ngot  %v\nwant %v", got, ids)
+	}
+	if len(objects["team"]) != 1 {
+		t.Errorf("teams = %d, want 1", len(objects["team"]))
+	}
+	if len(issues) != len(broken) {
+		t.Fatalf("issues = %v, want one per broken file", issues)
+	}
+	for i, issue := range issues {
+		if issue.Stage != "parse" || issue.Path != broken[i] {
+			t.Errorf("issue %d = %+v, want a parse issue for %s", i, issue, broken[i])
+		}
+	}
+
+	// LoadObjects fails on the first broken file in directory order.
+	if _, err := repo.LoadObjects(repo.Root); err == nil || err.Error() != issues[0].Message {
+		t.Errorf("LoadObjects error = %v, want %q", err, issues[0].Message)
+	}
+}
+
+func BenchmarkLoadObjects(b *testing.B) {
+	root := b.TempDir()
+	if err := InitializeRepository(root, false, true); err != nil {
+		b.Fatal(err)
+	}
+	writeTestServices(b, root, 2000)
+	y := YAMLOptions{}
+	b.ResetTimer()
+	for range b.N {
+		if _, err := y.LoadObjects(root); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
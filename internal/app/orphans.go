@@ -0,0 +1,100 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Orphan is an object file whose type has no schema: either its data/<type>/
+// directory has no config/schemas/<type>.schema.json, or its _type names a
+// type without a schema.
+type Orphan struct {
+	Type   string `json:"type"`
+	ID     string `json:"id"`
+	Path   string `json:"path"`
+	Reason string `json:"reason"`
+}
+
+// FindOrphans lists orphaned object files under root, sorted by path. Type and
+// ID are taken from the file's location so the result can be passed to
+// DeleteObject. Files that cannot be parsed are left to validation.
+func (y YAMLOptions) FindOrphans(root string) ([]Orphan, error) {
+	schemas, err := LoadSchemas(root)
+	if err != nil {
+		return nil, err
+	}
+	dataDir := filepath.Join(root, "data")
+	types, err := os.ReadDir(dataDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	orphans := make([]Orphan, 0)
+	for _, typeEntry := range types {
+		if !typeEntry.IsDir() || isSymlink(typeEntry) {
+			continue
+		}
+		typeName := typeEntry.Name()
+		_, hasSchema := schemas[typeName]
+		entries, err := os.ReadDir(filepath.Join(dataDir, typeName))
+		if err != nil {
+			return nil, err
+		}
+		for _, e := range entries {
+			if e.IsDir() || isSymlink(e) || !strings.HasSuffix(e.Name(), ".yaml") {
+				continue
+			}
+			orphan := Orphan{
+				Type: typeName,
+				ID:   strings.TrimSuffix(e.Name(), ".yaml"),
+				Path: "data/" + typeName + "/" + e.Name(),
+			}
+			if !hasSchema {
+				orphan.Reason = "missing schema file config/schemas/" + typeName + ".schema.json"
+				orphans = append(orphans, orphan)
+				continue
+			}
+			obj, err := y.ParseObjectFile(filepath.Join(dataDir, typeName, e.Name()), "", "")
+			if err != nil {
+				continue
+			}
+			if _, ok := schemas[obj.Type]; !ok {
+				orphan.Reason = fmt.Sprintf("_type %q has no schema", obj.Type)
+				orphans = append(orphans, orphan)
+			}
+		}
+	}
+	sort.Slice(orphans, func(i, j int) bool {
+		return orphans[i].Path < orphans[j].Path
+	})
+	return orphans, nil
+}
+
+func writeOrphans(w io.Writer, orphans []Orphan, format string) error {
+	if format == "json" {
+		b, err := json.MarshalIndent(orphans, "", "  ")
+		if err != nil {
+			return err
+		}
+		b = append(b, '\n')
+		_, err = w.Write(b)
+		return err
+	}
+	if len(orphans) == 0 {
+		_, err := fmt.Fprintln(w, "no orphaned objects")
+		return err
+	}
+	for _, o := range orphans {
+		if _, err := fmt.Fprintf(w, "%s: %s\n", o.Path, o.Reason); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+package app
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// writeTestOrphans adds a file under a type without a schema and a team file
+// whose _type has no schema to root, returning their data-relative paths.
+func writeTestOrphans(t *testing.T, root string) []string {
+	t.Helper()
+	widget := "data/widget/" + testObjectID(5) + ".yaml"
+	gadget := "data/team/" + testObjectID(6) + ".yaml"
+	writeTestFile(t, filepath.Join(root, filepath.FromSlash(widget)), "_id: "+testObjectID(5)+"\n_type: widget\nname: w\n")
+	writeTestFile(t, filepath.Join(root, filepath.FromSlash(gadget)), "_id: "+testObjectID(6)+"\n_type: gadget\nname: g\n")
+	return []string{gadget, widget}
+}
+
+func TestFindOrphansListsTypesWithoutSchema(t *testing.T) {
+	repo := newTestRepository(t)
+	if orphans, err := repo.FindOrphans(repo.Root); err != nil {
+		t.Fatal(err)
+	} else if len(orphans) != 0 {
+		t.Errorf("sample repository orphans = %+v, want none", orphans)
+	}
+
+	paths := writeTestOrphans(t, repo.Root)
+	orphans, err := repo.FindOrphans(repo.Root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Orphan{
+		{Type: "team", ID: testObjectID(6), Path: paths[0], Reason: `_type "gadget" has no schema`},
+		{Type: "widget", ID: testObjectID(5), Path: paths[1], Reason: "missing schema file config/schemas/widget.schema.json"},
+	}
+	if !reflect.DeepEqual(orphans, want) {
+		t.Errorf("orphans = %+v, want %+v", orphans, want)
+	}
+}
+
+func TestPruneDeletesOrphansOnlyWithConfirm(t *testing.T) {
+	repo := newTestRepository(t)
+	t.Setenv("WORKTREEFOUNDRY_WORKSPACE", "")
+	ws := newTestWorkspace(t, repo, "draft")
+	paths := writeTestOrphans(t, ws)
+	kept := []string{"data/team/" + testTeamID + ".yaml", "data/service/" + testServiceID + ".yaml"}
+	exists := func(rel string) bool {
+		_, err := os.Stat(filepath.Join(ws, filepath.FromSlash(rel)))
+		return err == nil
+	}
+	args := []string{"prune", "--repository", repo.Root, "--workspace", "draft"}
+
+	err := Run(context.Background(), args, "test")
+	if err == nil || !strings.Contains(err.Error(), "2 orphaned object(s) found") {
+		t.Errorf("prune without --confirm: error = %v", err)
+	}
+	for _, rel := range append(paths, kept...) {
+		if !exists(rel) {
+			t.Errorf("prune without --confirm removed %s", rel)
+		}
+	}
+
+	if err := Run(context.Background(), append(args, "--confirm"), "test"); err != nil {
+		t.Fatal(err)
+	}
+	for _, rel := range paths {
+		if exists(rel) {
+			t.Errorf("prune --confirm kept orphan %s", rel)
+		}
+	}
+	for _, rel := range kept {
+		if !exists(rel) {
+			t.Errorf("prune --confirm removed referenced object %s", rel)
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package app
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// overridesFile is the repository-relative path of a workspace's overrides.
+const overridesFile = "config/overrides.json"
+
+type Overrides struct {
+	Replace map[string]string `json:"replace"`
+}
+
+// LoadOverrides reads config/overrides.json of a workspace checkout. The file
+// is workspace-only: for any other root, such as the main checkout or an
+// extracted merge preview, it is ignored and no overrides are returned.
+func LoadOverrides(root string) (Overrides, error) {
+	if !isWorkspaceCheckout(root) {
+		return Overrides{}, nil
+	}
+	path := filepath.Join(root, filepath.FromSlash(overridesFile))
+	b, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return Overrides{}, nil
+		}
+		return Overrides{}, err
+	}
+	var o Overrides
+	if err := json.Unmarshal(b, &o); err != nil {
+		return Overrides{}, fmt.Errorf("parse overrides: %w", err)
+	}
+	for from := range o.Replace {
+		if from == "" {
+			return Overrides{}, errors.New("overrides: replace keys must be non-empty")
+		}
+	}
+	return o, nil
+}
+
+// isWorkspaceCheckout reports whether root is a workspace, that is a linked
+// git worktree, whose .git is a file rather than the repository directory.
+func isWorkspaceCheckout(root string) bool {
+	st, err := os.Lstat(filepath.Join(root, ".git"))
+	return err == nil && st.Mode().IsRegular()
+}
+
+// replacer applies longer keys first so overlapping substitutions are
+// deterministic.
+func (o Overrides) replacer() *strings.Replacer {
+	if len(o.Replace) == 0 {
+		return nil
+	}
+	keys := make([]string, 0, len(o.Replace))
+	for k := range o.Replace {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if len(keys[i]) != len(keys[j]) {
+			return len(keys[i]) > len(keys[j])
+		}
+		return keys[i] < keys[j]
+	})
+	pairs := make([]string, 0, len(keys)*2)
+	for _, k := range keys {
+		pairs = append(pairs, k, o.Replace[k])
+	}
+	return strings.NewReplacer(pairs...)
+}
+
+func applyOverrides(v any, r *strings.Replacer) any {
+	switch t := v.(type) {
+	case string:
+		return r.Replace(t)
+	case []any:
+		out := make([]any, len(t))
+		for i, item := range t {
+			out[i] = applyOverrides(item, r)
+		}
+		return out
+	default:
+		return v
+	}
+}
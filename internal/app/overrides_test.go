@@ -0,0 +1,84 @@
+package app
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestExportWorkspaceOverridesLeaveMainUnaffected(t *testing.T) {
+	repo := newTestRepository(t)
+	out := t.TempDir()
+	before := filepath.Join(out, "before")
+	if err := ExportRepository(repo.Root, before, ExportOptions{}); err != nil {
+		t.Fatalf("export main: %v", err)
+	}
+
+	ws := newTestWorkspace(t, repo, "staging")
+	writeTestFile(t, filepath.Join(ws, "config", "overrides.json"), `{"replace": {"Platform": "Staging Platform"}}`+"\n")
+	saveTestWorkspace(t, repo, "staging")
+	staged := filepath.Join(out, "staging")
+	if err := ExportWorkspace(ws, staged, ExportOptions{}); err != nil {
+		t.Fatalf("export workspace: %v", err)
+	}
+	b, err := os.ReadFile(filepath.Join(staged, "team.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(b), `"Staging Platform"`) {
+		t.Fatalf("workspace export did not apply overrides:\n%s", b)
+	}
+
+	// A copy of the file in the main checkout must not change main's export.
+	writeTestFile(t, filepath.Join(repo.Root, "config", "overrides.json"), `{"replace": {"Platform": "Staging Platform"}}`+"\n")
+	after := filepath.Join(out, "after")
+	if err := ExportRepository(repo.Root, after, ExportOptions{}); err != nil {
+		t.Fatalf("export main: %v", err)
+	}
+	entries, err := os.ReadDir(before)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) == 0 {
+		t.Fatal("main export wrote no files")
+	}
+	for _, e := range entries {
+		want, err := os.ReadFile(filepath.Join(before, e.Name()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := os.ReadFile(filepath.Join(after, e.Name()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(got, want) {
+			t.Errorf("%s changed after a workspace added overrides:\nbefore:\n%s\nafter:\n%s", e.Name(), want, got)
+		}
+	}
+}
+
+func TestValidateReadsOverridesOnlyInWorkspaces(t *testing.T) {
+	repo := newTestRepository(t)
+	ws := newTestWorkspace(t, repo, "staging")
+	for _, root := range []string{repo.Root, ws} {
+		writeTestFile(t, filepath.Join(root, "config", "overrides.json"), `{"replace": {"": "x"}}`+"\n")
+	}
+
+	result, err := ValidateRepository(repo.Root, repo.YAMLOptions)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.OK() {
+		t.Fatalf("main validated its overrides file: %v", result.Issues)
+	}
+
+	result, err = ValidateRepository(ws, repo.YAMLOptions)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Issues) != 1 || result.Issues[0].Path != overridesFile {
+		t.Fatalf("workspace issues = %v, want one %s issue", result.Issues, overridesFile)
+	}
+}
@@ -8,18 +8,34 @@ import (
 	"path/filepath"
 	"regexp"
 	"sort"
+	"strconv"
 	"strings"
 	"sync"
 )
 
 var workspaceNamePattern = regexp.MustCompile(`^[a-zA-Z0-9._-]+$`)
 
+var commitHashPattern = regexp.MustCompile(`^[0-9a-fA-F]{4,64}$`)
+
 type Repository struct {
 	Root          string
 	WorkspaceRoot string
-	mu            sync.Mutex
+	// BaseBranch is the Git branch the "main" workspace tracks; workspaces
+	// branch from it and merge into it.
+	BaseBranch   string
+	MergeWebhook string
+	// Settings is config/settings.json as read from the repository root.
+	Settings Settings
+	// FileOptions apply to every file written for the repository, in the
+	// main checkout, workspaces, and exports.
+	FileOptions
+	mu sync.Mutex
 }
 
+// defaultBaseBranch is used when it exists; otherwise OpenRepository falls
+// back to the branch checked out in the repository root.
+const defaultBaseBranch = "main"
+
 type Workspace struct {
 	Name         string
 	Branch       string
@@ -28,12 +44,20 @@ type Workspace struct {
 	ChangedFiles []string
 }
 
+type SyncStatus struct {
+	Ahead  int
+	Behind int
+}
+
 type ChangedEntry struct {
 	Path   string
 	Status string
 }
 
-func OpenRepository(root, workspaceRoot string) (*Repository, error) {
+// OpenRepository opens the repository at root. baseBranch, typically from
+// --base-branch or WORKTREEFOUNDRY_BASE_BRANCH, overrides the baseBranch
+// setting; when both are empty the base branch is detected.
+func OpenRepository(root, workspaceRoot, baseBranch string) (*Repository, error) {
 	if root == "" {
 		return nil, errors.New("repository root required")
 	}
@@ -58,10 +82,53 @@ func OpenRepository(root, workspaceRoot string) (*Repository, error) {
 	if !filepath.IsAbs(wsRoot) {
 		wsRoot = filepath.Join(absRoot, wsRoot)
 	}
-	if err := os.MkdirAll(wsRoot, 0o755); err != nil {
+	settings, err := LoadSettings(absRoot)
+	if err != nil {
+		return nil, err
+	}
+	files, err := FileOptionsFromEnv(settings.yamlOptions())
+	if err != nil {
+		return nil, err
+	}
+	if err := files.mkdirAll(wsRoot); err != nil {
 		return nil, fmt.Errorf("create workspace root: %w", err)
 	}
-	return &Repository{Root: absRoot, WorkspaceRoot: wsRoot}, nil
+	repo := &Repository{Root: absRoot, WorkspaceRoot: wsRoot, MergeWebhook: settings.MergeWebhook, Settings: settings, FileOptions: files}
+	if err := repo.resolveBaseBranch(firstNonEmpty(strings.TrimSpace(baseBranch), settings.BaseBranch)); err != nil {
+		return nil, err
+	}
+	return repo, nil
+}
+
+// resolveBaseBranch sets BaseBranch to override when given, else to main when
+// that branch exists, else to the branch HEAD points at (read with git
+// symbolic-ref). The chosen branch must exist, or be HEAD's unborn branch in
+// a repository with no commits yet.
+func (r *Repository) resolveBaseBranch(override string) error {
+	head := ""
+	if out, err := r.runGit(r.Root, "symbolic-ref", "--quiet", "--short", "HEAD"); err == nil {
+		head = strings.TrimSpace(out)
+	}
+	branch := override
+	switch {
+	case branch != "":
+	case r.branchExists(defaultBaseBranch):
+		branch = defaultBaseBranch
+	case head != "":
+		branch = head
+	default:
+		return fmt.Errorf("cannot determine base branch: no %q branch and HEAD is detached (set --base-branch or WORKTREEFOUNDRY_BASE_BRANCH)", defaultBaseBranch)
+	}
+	if branch != head && !r.branchExists(branch) {
+		return fmt.Errorf("base branch %q not found in %s", branch, r.Root)
+	}
+	r.BaseBranch = branch
+	return nil
+}
+
+func (r *Repository) branchExists(name string) bool {
+	_, err := r.runGit(r.Root, "rev-parse", "--verify", "--quiet", "refs/heads/"+name)
+	return err == nil
 }
 
 func (r *Repository) BranchForWorkspace(name string) string {
@@ -108,6 +175,9 @@ func (r *Repository) CreateWorkspace(name string) error {
 	if !workspaceNamePattern.MatchString(name) {
 		return fmt.Errorf("workspace name %q is invalid", name)
 	}
+	if err := checkReservedWorkspaceName(name); err != nil {
+		return err
+	}
 	path := r.WorkspacePath(name)
 	if _, err := os.Stat(path); err == nil {
 		return fmt.Errorf("workspace %q already exists", name)
@@ -116,16 +186,53 @@ func (r *Repository) CreateWorkspace(name string) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
-	if err := os.MkdirAll(r.WorkspaceRoot, 0o755); err != nil {
+	if err := r.checkWorkspaceRefCollision(name); err != nil {
+		return err
+	}
+	if err := r.mkdirAll(r.WorkspaceRoot); err != nil {
 		return fmt.Errorf("create workspace root: %w", err)
 	}
-	_, err := r.runGit(r.Root, "worktree", "add", "-b", r.BranchForWorkspace(name), path, "main")
+	_, err := r.runGit(r.Root, "worktree", "add", "-b", r.BranchForWorkspace(name), path, r.BaseBranch)
 	if err != nil {
 		return err
 	}
 	return nil
 }
 
+var reservedWorkspaceNames = []string{"main", "head", "fetch_head", "orig_head", "merge_head"}
+
+func checkReservedWorkspaceName(name string) error {
+	lower := strings.ToLower(name)
+	if contains(reservedWorkspaceNames, lower) {
+		return fmt.Errorf("workspace name %q is reserved", name)
+	}
+	if strings.HasPrefix(name, ".") || strings.HasSuffix(name, ".") || strings.HasSuffix(lower, ".lock") || strings.Contains(name, "..") {
+		return fmt.Errorf("workspace name %q is not a valid git branch name", name)
+	}
+	return nil
+}
+
+// checkWorkspaceRefCollision rejects names whose workspace/<name> branch would
+// clash with an existing branch or tag, including prefix clashes that git
+// cannot store side by side (workspace/a vs workspace/a/b).
+func (r *Repository) checkWorkspaceRefCollision(name string) error {
+	out, err := r.runGit(r.Root, "for-each-ref", "--format=%(refname)", "refs/heads", "refs/tags")
+	if err != nil {
+		return err
+	}
+	branch := r.BranchForWorkspace(name)
+	for _, ref := range strings.Split(strings.TrimSpace(out), "\n") {
+		short := strings.TrimPrefix(strings.TrimPrefix(ref, "refs/heads/"), "refs/tags/")
+		if short == "" {
+			continue
+		}
+		if strings.EqualFold(short, branch) || strings.HasPrefix(short, branch+"/") || strings.HasPrefix(branch, short+"/") {
+			return fmt.Errorf("workspace name %q collides with existing ref %s", name, ref)
+		}
+	}
+	return nil
+}
+
 func (r *Repository) DeleteWorkspace(name string) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
@@ -150,6 +257,77 @@ func (r *Repository) deleteWorkspaceLocked(name string) error {
 	return nil
 }
 
+// RenameWorkspace renames the workspace/<oldName> branch and moves its
+// worktree directory, keeping saved commits and unsaved changes.
+func (r *Repository) RenameWorkspace(oldName, newName string) error {
+	if oldName == "main" {
+		return errors.New("main cannot be renamed")
+	}
+	if !workspaceNamePattern.MatchString(newName) {
+		return fmt.Errorf("workspace name %q is invalid", newName)
+	}
+	if err := checkReservedWorkspaceName(newName); err != nil {
+		return err
+	}
+	oldPath := r.WorkspacePath(oldName)
+	newPath := r.WorkspacePath(newName)
+	if _, err := os.Stat(oldPath); err != nil {
+		return fmt.Errorf("workspace %q not found", oldName)
+	}
+	if _, err := os.Stat(newPath); err == nil {
+		return fmt.Errorf("workspace %q already exists", newName)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.checkWorkspaceRefCollision(newName); err != nil {
+		return err
+	}
+	oldBranch := r.BranchForWorkspace(oldName)
+	newBranch := r.BranchForWorkspace(newName)
+	if _, err := r.runGit(r.Root, "branch", "-m", oldBranch, newBranch); err != nil {
+		return err
+	}
+	// A failed step undoes the earlier ones, so the workspace keeps its old
+	// name rather than ending up half renamed.
+	restoreBranch := func() {
+		_, _ = r.runGit(r.Root, "branch", "-m", newBranch, oldBranch)
+	}
+	if err := os.Rename(oldPath, newPath); err != nil {
+		restoreBranch()
+		return fmt.Errorf("move workspace: %w", err)
+	}
+	if _, err := r.runGit(r.Root, "worktree", "repair", newPath); err != nil {
+		if os.Rename(newPath, oldPath) == nil {
+			_, _ = r.runGit(r.Root, "worktree", "repair", oldPath)
+		}
+		restoreBranch()
+		return fmt.Errorf("repair worktree: %w", err)
+	}
+	return nil
+}
+
+func (r *Repository) WorkspaceSync(name string) (SyncStatus, error) {
+	out, err := r.runGit(r.Root, "rev-list", "--left-right", "--count", r.BaseBranch+"..."+r.BranchForWorkspace(name))
+	if err != nil {
+		return SyncStatus{}, err
+	}
+	fields := strings.Fields(out)
+	if len(fields) != 2 {
+		return SyncStatus{}, fmt.Errorf("unexpected rev-list output %q", strings.TrimSpace(out))
+	}
+	behind, err := strconv.Atoi(fields[0])
+	if err != nil {
+		return SyncStatus{}, err
+	}
+	ahead, err := strconv.Atoi(fields[1])
+	if err != nil {
+		return SyncStatus{}, err
+	}
+	return SyncStatus{Ahead: ahead, Behind: behind}, nil
+}
+
 func (r *Repository) ListWorkspaces() ([]Workspace, error) {
 	out, err := r.runGit(r.Root, "worktree", "list", "--porcelain")
 	if err != nil {
@@ -207,7 +385,7 @@ func (r *Repository) ChangedEntries(repoPath string) ([]ChangedEntry, error) {
 	if err != nil {
 		return nil, err
 	}
-	lines := strings.Split(strings.TrimSpace(out), "\n")
+	lines := strings.Split(strings.TrimRight(out, "\n"), "\n")
 	var changed []ChangedEntry
 	for _, line := range lines {
 		if strings.TrimSpace(line) == "" {
@@ -267,11 +445,11 @@ func (r *Repository) SaveWorkspace(name, message string) ([]string, error) {
 	if len(changed) == 0 {
 		return nil, errors.New("no changes to save")
 	}
-	if err := RewriteCanonicalFiles(path, changed); err != nil {
+	if _, err := r.RewriteCanonicalFiles(path, changed); err != nil {
 		return nil, err
 	}
 
-	result, err := ValidateRepository(path)
+	result, err := ValidateRepository(path, r.YAMLOptions)
 	if err != nil {
 		return nil, err
 	}
@@ -320,8 +498,105 @@ func (r *Repository) RestoreObject(workspace, typeName, id string) error {
 	if _, err := r.runGit(path, "checkout", "--", rel); err == nil {
 		return nil
 	}
-	if _, err := r.runGit(path, "checkout", "main", "--", rel); err != nil {
+	if _, err := r.runGit(path, "checkout", r.BaseBranch, "--", rel); err != nil {
 		return err
 	}
 	return nil
 }
+
+func (r *Repository) RevertField(workspace, typeName, id, field string) error {
+	if workspace == "" || workspace == "main" {
+		return errors.New("cannot revert in main workspace")
+	}
+	if field == "_id" || field == "_type" {
+		return fmt.Errorf("field %s cannot be reverted", field)
+	}
+	path := r.WorkspacePath(workspace)
+	obj, err := r.ReadObject(path, typeName, id)
+	if err != nil {
+		return err
+	}
+	mainObj, err := r.ReadObject(r.Root, typeName, id)
+	if err != nil {
+		return fmt.Errorf("object not found on main: %w", err)
+	}
+	if v, ok := mainObj.Data[field]; ok {
+		obj.Data[field] = v
+	} else {
+		delete(obj.Data, field)
+	}
+	return r.WriteObject(path, obj)
+}
+
+// ObjectCommit is one commit that changed an object file.
+type ObjectCommit struct {
+	Hash    string
+	Short   string
+	Date    string
+	Subject string
+}
+
+// ObjectHistory lists the most recent commits, newest first, that changed
+// the object's file on the branch checked out at repoPath.
+func (r *Repository) ObjectHistory(repoPath, typeName, id string, limit int) ([]ObjectCommit, error) {
+	rel := filepath.ToSlash(filepath.Join("data", typeName, id+".yaml"))
+	out, err := r.runGit(repoPath, "log", "-n", strconv.Itoa(limit), "--date=short", "--format=%H%x00%h%x00%ad%x00%s", "--", rel)
+	if err != nil {
+		return nil, err
+	}
+	commits := make([]ObjectCommit, 0)
+	for _, line := range strings.Split(strings.TrimSpace(out), "\n") {
+		parts := strings.SplitN(line, "\x00", 4)
+		if len(parts) != 4 {
+			continue
+		}
+		commits = append(commits, ObjectCommit{Hash: parts[0], Short: parts[1], Date: parts[2], Subject: parts[3]})
+	}
+	return commits, nil
+}
+
+// RevertObject replaces the object in the workspace with its content at
+// commit, as an unsaved change. The old version is checked against the
+// workspace's current schema first, so an object cannot be reverted into an
+// invalid state.
+func (r *Repository) RevertObject(workspace, typeName, id, commit string) error {
+	if workspace == "" || workspace == "main" {
+		return errors.New("cannot revert in main workspace")
+	}
+	if !commitHashPattern.MatchString(commit) {
+		return fmt.Errorf("invalid commit %q", commit)
+	}
+	path := r.WorkspacePath(workspace)
+	rel := filepath.ToSlash(filepath.Join("data", typeName, id+".yaml"))
+	content, err := r.runGit(path, "show", commit+":"+rel)
+	if err != nil {
+		return fmt.Errorf("object %s/%s does not exist at commit %s", typeName, id, commit)
+	}
+	obj, err := r.parseObjectContent([]byte(content), typeName, id)
+	if err != nil {
+		return fmt.Errorf("object at commit %s: %w", commit, err)
+	}
+	obj.Path = rel
+	schemas, err := LoadSchemas(path)
+	if err != nil {
+		return err
+	}
+	schema, ok := schemas[typeName]
+	if !ok {
+		return fmt.Errorf("type %q has no schema", typeName)
+	}
+	// The old version is checked against the enumRef values of today.
+	resolved, issues := ResolveEnumRefs(path, map[string]Schema{typeName: schema})
+	if len(issues) > 0 {
+		return fmt.Errorf("cannot revert: %s", issues[0].String())
+	}
+	schema = resolved[typeName]
+	obj.Data, _ = canonicalAliasData(obj.Data, schema)
+	check := ValidationResult{}
+	validateObjectInvariants(obj, &check)
+	validateObjectSchema(obj, schema, &check)
+	if !check.OK() {
+		return fmt.Errorf("version at commit %s no longer passes validation: %s", commit, check.Issues[0].String())
+	}
+	return r.WriteObject(path, obj)
+}
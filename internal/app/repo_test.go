@@ -0,0 +1,206 @@
+package app
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRevertObjectChecksEnumRefs(t *testing.T) {
+	repo := newTestRepository(t)
+	ws := newTestWorkspace(t, repo, "draft")
+	const id = "22222222-2222-4222-8222-222222222222"
+	service := func(tier string) Object {
+		return Object{ID: id, Type: "service", Data: map[string]any{
+			"name":   "edge-gateway",
+			"teamId": "11111111-1111-4111-8111-111111111111",
+			"tier":   tier,
+			"ports":  []any{float64(443), float64(8443)},
+		}}
+	}
+	head := func() string {
+		t.Helper()
+		out, err := repo.runGit(ws, "rev-parse", "HEAD")
+		if err != nil {
+			t.Fatal(err)
+		}
+		return strings.TrimSpace(out)
+	}
+
+	if err := repo.WriteObject(ws, service("batch")); err != nil {
+		t.Fatal(err)
+	}
+	saveTestWorkspace(t, repo, "draft")
+	batch := head()
+
+	// Batch services are retired: tier now reads its values from an enum file.
+	writeTestFile(t, filepath.Join(ws, "config", "enums", "tiers.json"), `["core", "edge"]`+"\n")
+	editTestJSON(t, filepath.Join(ws, "config", "schemas", "service.schema.json"), func(doc map[string]any) {
+		doc["properties"].(map[string]any)["tier"] = map[string]any{"type": "string", "enumRef": "tiers"}
+	})
+	if err := repo.WriteObject(ws, service("core")); err != nil {
+		t.Fatal(err)
+	}
+	saveTestWorkspace(t, repo, "draft")
+
+	err := repo.RevertObject("draft", "service", id, batch)
+	if err == nil || !strings.Contains(err.Error(), "no longer passes validation") {
+		t.Fatalf("revert to tier batch: error = %v, want a validation failure", err)
+	}
+	if err := repo.RevertObject("draft", "service", id, head()); err != nil {
+		t.Errorf("revert to the current version: %v", err)
+	}
+}
+
+func TestWorkspaceSyncCountsCommitsBehindMain(t *testing.T) {
+	repo := newTestRepository(t)
+	ws := newTestWorkspace(t, repo, "draft")
+	addTestSchemaField(t, ws, "team", "owner")
+	saveTestWorkspace(t, repo, "draft")
+	for i, field := range []string{"region", "budget"} {
+		addTestSchemaField(t, repo.Root, "service", field)
+		commitTestMain(t, repo, fmt.Sprintf("main change %d", i+1))
+	}
+
+	sync, err := repo.WorkspaceSync("draft")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sync.Behind != 2 || sync.Ahead != 1 {
+		t.Errorf("sync = %+v, want 2 behind and 1 ahead", sync)
+	}
+}
+
+func TestCreateWorkspaceRejectsCollidingNames(t *testing.T) {
+	repo := newTestRepository(t)
+	if _, err := repo.runGit(repo.Root, "tag", "workspace/release"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repo.runGit(repo.Root, "branch", "workspace/team/a"); err != nil {
+		t.Fatal(err)
+	}
+
+	for name, want := range map[string]string{
+		"release": "collides with existing ref refs/tags/workspace/release",
+		"team":    "collides with existing ref refs/heads/workspace/team/a",
+		"HEAD":    "is reserved",
+		"main":    "is reserved",
+	} {
+		if err := repo.CreateWorkspace(name); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("CreateWorkspace(%q) error = %v, want %q", name, err, want)
+		}
+	}
+	if err := repo.CreateWorkspace("fresh"); err != nil {
+		t.Errorf("CreateWorkspace(fresh): %v", err)
+	}
+}
+
+func TestMasterBasedRepository(t *testing.T) {
+	initial := newTestRepository(t)
+	if _, err := initial.runGit(initial.Root, "branch", "-m", "main", "master"); err != nil {
+		t.Fatal(err)
+	}
+	repo, err := OpenRepository(initial.Root, "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if repo.BaseBranch != "master" {
+		t.Fatalf("BaseBranch = %q, want master", repo.BaseBranch)
+	}
+
+	ws := newTestWorkspace(t, repo, "draft")
+	addTestSchemaField(t, ws, "team", "owner")
+	saveTestWorkspace(t, repo, "draft")
+	if sync, err := repo.WorkspaceSync("draft"); err != nil || sync.Ahead != 1 {
+		t.Errorf("sync = %+v, %v; want one commit ahead of master", sync, err)
+	}
+	result, err := repo.MergeWorkspace("draft", nil, nil, MergeOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.Merged {
+		t.Fatalf("result = %+v, want merged", result)
+	}
+	if out, err := repo.runGit(repo.Root, "log", "-1", "--format=%s", "master"); err != nil {
+		t.Fatal(err)
+	} else if got := strings.TrimSpace(out); got != "Merge workspace/draft into master" {
+		t.Errorf("master head = %q, want the merge commit", got)
+	}
+	if repo.branchExists("main") {
+		t.Error("operating on a master repository created a main branch")
+	}
+
+	// A configured base branch that does not exist is an error.
+	writeTestFile(t, filepath.Join(repo.Root, "config", "settings.json"), `{"baseBranch": "trunk"}`+"\n")
+	if _, err := OpenRepository(repo.Root, "", ""); err == nil || !strings.Contains(err.Error(), `base branch "trunk" not found`) {
+		t.Errorf("open with a missing base branch: error = %v", err)
+	}
+}
+
+func TestRenameWorkspaceMovesBranchAndWorktree(t *testing.T) {
+	repo := newTestRepository(t)
+	ws := newTestWorkspace(t, repo, "draft")
+	teamRel := filepath.Join("data", "team", testTeamID+".yaml")
+	replaceTestFile(t, filepath.Join(ws, teamRel), "code: PLAT", "code: PLT")
+	saveTestWorkspace(t, repo, "draft")
+	replaceTestFile(t, filepath.Join(ws, teamRel), "name: Platform", "name: Core")
+
+	if err := repo.RenameWorkspace("draft", "renamed"); err != nil {
+		t.Fatal(err)
+	}
+	if repo.WorkspaceExists("draft") || !repo.WorkspaceExists("renamed") {
+		t.Fatal("workspace not renamed")
+	}
+	if _, err := repo.runGit(repo.Root, "rev-parse", "--verify", repo.BranchForWorkspace("draft")); err == nil {
+		t.Error("old branch still exists")
+	}
+	path := repo.WorkspacePath("renamed")
+	if branch, err := repo.CurrentBranch(path); err != nil || branch != repo.BranchForWorkspace("renamed") {
+		t.Errorf("renamed worktree branch = %q, %v", branch, err)
+	}
+	if changed, err := repo.ChangedFiles(path); err != nil || len(changed) != 1 {
+		t.Errorf("renamed worktree changes = %v, %v; want the unsaved team edit", changed, err)
+	}
+	if out, err := repo.runGit(repo.Root, "worktree", "list", "--porcelain"); err != nil {
+		t.Fatal(err)
+	} else if !strings.Contains(out, "worktree "+path+"\n") {
+		t.Errorf("worktree list lacks %s:\n%s", path, out)
+	}
+	obj, err := repo.ReadObject(path, "team", testTeamID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if obj.Data["code"] != "PLT" || obj.Data["name"] != "Core" {
+		t.Errorf("renamed team = %v, want the saved and unsaved edits", obj.Data)
+	}
+}
+
+func TestRenameWorkspaceRefusesCollisions(t *testing.T) {
+	repo := newTestRepository(t)
+	newTestWorkspace(t, repo, "draft")
+	newTestWorkspace(t, repo, "other")
+	if _, err := repo.runGit(repo.Root, "tag", "workspace/release"); err != nil {
+		t.Fatal(err)
+	}
+
+	for name, want := range map[string]string{
+		"other":   `workspace "other" already exists`,
+		"release": "collides with existing ref refs/tags/workspace/release",
+		"main":    "is reserved",
+		"bad/../": "is invalid",
+	} {
+		if err := repo.RenameWorkspace("draft", name); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("RenameWorkspace(draft, %q) error = %v, want %q", name, err, want)
+		}
+	}
+	if !repo.WorkspaceExists("draft") || !repo.WorkspaceExists("other") {
+		t.Error("a refused rename changed the workspaces")
+	}
+	if branch, err := repo.CurrentBranch(repo.WorkspacePath("draft")); err != nil || branch != repo.BranchForWorkspace("draft") {
+		t.Errorf("draft branch = %q, %v after refused renames", branch, err)
+	}
+	if err := repo.RenameWorkspace("missing", "fresh"); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("rename of a missing workspace: error = %v", err)
+	}
+}
@@ -5,24 +5,49 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"reflect"
+	"regexp"
+	"slices"
 	"sort"
 	"strings"
 )
 
+// schemaDefsDir is the subdirectory of config/schemas holding the shared
+// property definitions that schema fields reference with "$ref".
+const schemaDefsDir = "_defs"
+
 type rawSchema struct {
-	Type       string                   `json:"type"`
-	Required   []string                 `json:"required"`
-	Properties map[string]rawSchemaProp `json:"properties"`
+	Type              string                   `json:"type"`
+	Required          []string                 `json:"required"`
+	Properties        map[string]rawSchemaProp `json:"properties"`
+	OneOfRequired     [][]string               `json:"oneOfRequired"`
+	MutuallyExclusive [][]string               `json:"mutuallyExclusive"`
 }
 
 type rawSchemaProp struct {
-	Type      string    `json:"type"`
-	Enum      []string  `json:"enum"`
-	MinLength *int      `json:"minLength"`
-	MaxLength *int      `json:"maxLength"`
-	Minimum   *float64  `json:"minimum"`
-	Maximum   *float64  `json:"maximum"`
-	Items     *rawItems `json:"items"`
+	Ref              string    `json:"$ref"`
+	Type             string    `json:"type"`
+	Enum             []any     `json:"enum"`
+	EnumRef          string    `json:"enumRef"`
+	MinLength        *int      `json:"minLength"`
+	MaxLength        *int      `json:"maxLength"`
+	Pattern          *string   `json:"pattern"`
+	Format           string    `json:"format"`
+	Default          any       `json:"default"`
+	Minimum          *float64  `json:"minimum"`
+	Maximum          *float64  `json:"maximum"`
+	ExclusiveMinimum *float64  `json:"exclusiveMinimum"`
+	ExclusiveMaximum *float64  `json:"exclusiveMaximum"`
+	MultipleOf       *float64  `json:"multipleOf"`
+	Items            *rawItems `json:"items"`
+	MinItems         *int      `json:"minItems"`
+	MaxItems         *int      `json:"maxItems"`
+	UniqueItems      bool      `json:"uniqueItems"`
+	ArrayMerge       string    `json:"arrayMerge"`
+	Aliases          []string  `json:"aliases"`
+
+	Properties map[string]rawSchemaProp `json:"properties"`
+	Required   []string                 `json:"required"`
 }
 
 type rawItems struct {
@@ -40,11 +65,16 @@ func LoadSchemas(root string) (map[string]Schema, error) {
 	}
 
 	schemas := make(map[string]Schema)
+	folded := make(map[string]string)
 	for _, entry := range entries {
-		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".schema.json") {
+		if entry.IsDir() || isSymlink(entry) || !strings.HasSuffix(entry.Name(), ".schema.json") {
 			continue
 		}
 		typeName := strings.TrimSuffix(entry.Name(), ".schema.json")
+		if prev, ok := folded[strings.ToLower(typeName)]; ok {
+			return nil, fmt.Errorf("schema %s conflicts with %s: type names must be unique ignoring case", entry.Name(), prev)
+		}
+		folded[strings.ToLower(typeName)] = entry.Name()
 		b, err := os.ReadFile(filepath.Join(schemaDir, entry.Name()))
 		if err != nil {
 			return nil, err
@@ -53,6 +83,9 @@ func LoadSchemas(root string) (map[string]Schema, error) {
 		if err := json.Unmarshal(b, &raw); err != nil {
 			return nil, fmt.Errorf("parse schema %s: %w", entry.Name(), err)
 		}
+		if err := resolveSchemaRefs(root, &raw); err != nil {
+			return nil, fmt.Errorf("schema %s: %w", entry.Name(), err)
+		}
 		schema, err := normalizeSchema(typeName, raw)
 		if err != nil {
 			return nil, fmt.Errorf("schema %s: %w", entry.Name(), err)
@@ -65,6 +98,77 @@ func LoadSchemas(root string) (map[string]Schema, error) {
 	return schemas, nil
 }
 
+// resolveSchemaRefs inlines every "$ref" in raw's properties, including the
+// children of object fields, with the shared definition it names under
+// config/schemas/_defs. A definition may itself use $ref; a chain that leads
+// back to a definition already being resolved is an error.
+func resolveSchemaRefs(root string, raw *rawSchema) error {
+	for _, field := range sortedKeys(raw.Properties) {
+		p, err := resolvePropertyRef(root, field, raw.Properties[field], nil)
+		if err != nil {
+			return err
+		}
+		raw.Properties[field] = p
+	}
+	return nil
+}
+
+func resolvePropertyRef(root, field string, p rawSchemaProp, chain []string) (rawSchemaProp, error) {
+	if p.Ref != "" {
+		if !reflect.DeepEqual(p, rawSchemaProp{Ref: p.Ref}) {
+			return rawSchemaProp{}, fmt.Errorf("field %s: $ref cannot be combined with other keywords", field)
+		}
+		if slices.Contains(chain, p.Ref) {
+			return rawSchemaProp{}, fmt.Errorf("field %s: $ref cycle: %s -> %s", field, strings.Join(chain, " -> "), p.Ref)
+		}
+		def, err := loadSchemaDef(root, p.Ref)
+		if err != nil {
+			return rawSchemaProp{}, fmt.Errorf("field %s: %w", field, err)
+		}
+		resolved, err := resolvePropertyRef(root, field, def, append(slices.Clone(chain), p.Ref))
+		if err != nil {
+			return rawSchemaProp{}, err
+		}
+		if _, err := normalizeProperty(field, resolved, false); err != nil {
+			return rawSchemaProp{}, fmt.Errorf("%s is not a valid property definition: %w", p.Ref, err)
+		}
+		return resolved, nil
+	}
+	if len(p.Properties) > 0 {
+		children := make(map[string]rawSchemaProp, len(p.Properties))
+		for _, name := range sortedKeys(p.Properties) {
+			child, err := resolvePropertyRef(root, field+"."+name, p.Properties[name], chain)
+			if err != nil {
+				return rawSchemaProp{}, err
+			}
+			children[name] = child
+		}
+		p.Properties = children
+	}
+	return p, nil
+}
+
+// loadSchemaDef reads a shared property definition. ref must have the form
+// _defs/<name>.json, relative to config/schemas.
+func loadSchemaDef(root, ref string) (rawSchemaProp, error) {
+	name, ok := strings.CutPrefix(ref, schemaDefsDir+"/")
+	if !ok || !strings.HasSuffix(name, ".json") || strings.ContainsAny(name, `/\`) || strings.HasPrefix(name, ".") {
+		return rawSchemaProp{}, fmt.Errorf("invalid $ref %q: must name a file %s/<name>.json", ref, schemaDefsDir)
+	}
+	b, err := os.ReadFile(filepath.Join(root, "config", "schemas", schemaDefsDir, name))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return rawSchemaProp{}, fmt.Errorf("missing definition file config/schemas/%s", ref)
+		}
+		return rawSchemaProp{}, err
+	}
+	var def rawSchemaProp
+	if err := json.Unmarshal(b, &def); err != nil {
+		return rawSchemaProp{}, fmt.Errorf("parse definition %s: %w", ref, err)
+	}
+	return def, nil
+}
+
 func normalizeSchema(typeName string, raw rawSchema) (Schema, error) {
 	if raw.Type != "object" {
 		return Schema{}, fmt.Errorf("root type must be object")
@@ -74,39 +178,42 @@ func normalizeSchema(typeName string, raw rawSchema) (Schema, error) {
 		required[r] = struct{}{}
 	}
 	props := make(map[string]SchemaProperty, len(raw.Properties))
-	for field, p := range raw.Properties {
-		sp := SchemaProperty{
-			Type:      p.Type,
-			Enum:      append([]string(nil), p.Enum...),
-			MinLength: p.MinLength,
-			MaxLength: p.MaxLength,
-			Minimum:   p.Minimum,
-			Maximum:   p.Maximum,
+	for _, field := range sortedKeys(raw.Properties) {
+		p := raw.Properties[field]
+		sp, err := normalizeProperty(field, p, false)
+		if err != nil {
+			return Schema{}, err
 		}
-		sort.Strings(sp.Enum)
-		switch p.Type {
-		case "string", "number", "integer", "boolean":
-		case "array":
-			if p.Items == nil {
-				return Schema{}, fmt.Errorf("field %s: array missing items.type", field)
-			}
-			if p.Items.Type != "string" && p.Items.Type != "number" && p.Items.Type != "integer" {
-				return Schema{}, fmt.Errorf("field %s: array items.type must be string/number/integer", field)
-			}
-			sp.ItemsType = p.Items.Type
-		default:
-			return Schema{}, fmt.Errorf("field %s: unsupported type %q", field, p.Type)
+		props[field] = sp
+	}
+	for _, field := range raw.Required {
+		if _, ok := props[field]; !ok {
+			return Schema{}, fmt.Errorf("required field %q is not defined in properties", field)
+		}
+	}
+	oneOf := make([][]string, 0, len(raw.OneOfRequired))
+	for i, group := range raw.OneOfRequired {
+		if len(group) < 2 {
+			return Schema{}, fmt.Errorf("oneOfRequired[%d]: group must list at least two fields", i)
 		}
-		if p.Type == "array" && len(p.Enum) > 0 {
-			return Schema{}, fmt.Errorf("field %s: enum not supported for array", field)
+		for _, field := range group {
+			if _, ok := props[field]; !ok {
+				return Schema{}, fmt.Errorf("oneOfRequired[%d]: unknown field %q", i, field)
+			}
 		}
-		if p.Type != "string" && (p.MinLength != nil || p.MaxLength != nil) {
-			return Schema{}, fmt.Errorf("field %s: minLength/maxLength only valid for string", field)
+		oneOf = append(oneOf, append([]string(nil), group...))
+	}
+	exclusive := make([][]string, 0, len(raw.MutuallyExclusive))
+	for i, group := range raw.MutuallyExclusive {
+		if len(group) < 2 {
+			return Schema{}, fmt.Errorf("mutuallyExclusive[%d]: group must list at least two fields", i)
 		}
-		if p.Type != "number" && p.Type != "integer" && (p.Minimum != nil || p.Maximum != nil) {
-			return Schema{}, fmt.Errorf("field %s: minimum/maximum only valid for number/integer", field)
+		for _, field := range group {
+			if _, ok := props[field]; !ok {
+				return Schema{}, fmt.Errorf("mutuallyExclusive[%d]: unknown field %q", i, field)
+			}
 		}
-		props[field] = sp
+		exclusive = append(exclusive, append([]string(nil), group...))
 	}
 	if _, ok := props["_id"]; ok {
 		return Schema{}, fmt.Errorf("_id must not appear in schema properties")
@@ -114,5 +221,277 @@ func normalizeSchema(typeName string, raw rawSchema) (Schema, error) {
 	if _, ok := props["_type"]; ok {
 		return Schema{}, fmt.Errorf("_type must not appear in schema properties")
 	}
-	return Schema{Type: typeName, Required: required, Properties: props}, nil
+	aliases := make(map[string]string)
+	for _, field := range sortedKeys(props) {
+		for _, alias := range props[field].Aliases {
+			switch {
+			case alias == "" || strings.Contains(alias, "."):
+				return Schema{}, fmt.Errorf("field %s: invalid alias %q", field, alias)
+			case alias == "_id" || alias == "_type":
+				return Schema{}, fmt.Errorf("field %s: alias %q is reserved", field, alias)
+			}
+			if _, ok := props[alias]; ok {
+				return Schema{}, fmt.Errorf("field %s: alias %q collides with a property", field, alias)
+			}
+			if prev, ok := aliases[alias]; ok {
+				return Schema{}, fmt.Errorf("field %s: alias %q is already an alias of %s", field, alias, prev)
+			}
+			aliases[alias] = field
+		}
+	}
+	return Schema{Type: typeName, Required: required, Properties: props, OneOfRequired: oneOf, MutuallyExclusive: exclusive, Aliases: aliases}, nil
+}
+
+// canonicalAliasData returns data with every aliased key renamed to its
+// property, leaving data itself untouched. An alias whose property is also
+// set is kept as is and returned in clashes, so validation can flag it.
+func canonicalAliasData(data map[string]any, schema Schema) (map[string]any, []string) {
+	if len(schema.Aliases) == 0 {
+		return data, nil
+	}
+	found := false
+	for k := range data {
+		if _, ok := schema.Aliases[k]; ok {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return data, nil
+	}
+	out := make(map[string]any, len(data))
+	for k, v := range data {
+		out[k] = v
+	}
+	var clashes []string
+	for _, alias := range sortedKeys(data) {
+		field, ok := schema.Aliases[alias]
+		if !ok {
+			continue
+		}
+		if _, set := data[field]; set {
+			clashes = append(clashes, alias)
+			continue
+		}
+		out[field] = out[alias]
+		delete(out, alias)
+	}
+	return out, clashes
+}
+
+// normalizeEnum stores the enum of a string property in Enum and that of a
+// number or integer property in EnumNumbers, rejecting values whose type does
+// not match the property.
+func normalizeEnum(field string, p rawSchemaProp, sp *SchemaProperty) error {
+	if len(p.Enum) == 0 {
+		return nil
+	}
+	switch p.Type {
+	case "string":
+		for _, v := range p.Enum {
+			s, ok := v.(string)
+			if !ok {
+				return fmt.Errorf("field %s: enum value %v does not match type string", field, v)
+			}
+			sp.Enum = append(sp.Enum, s)
+		}
+		sort.Strings(sp.Enum)
+	case "number", "integer":
+		for _, v := range p.Enum {
+			n, ok := v.(float64)
+			if !ok || (p.Type == "integer" && n != float64(int64(n))) {
+				return fmt.Errorf("field %s: enum value %v does not match type %s", field, v, p.Type)
+			}
+			sp.EnumNumbers = append(sp.EnumNumbers, n)
+		}
+		sort.Float64s(sp.EnumNumbers)
+	default:
+		return fmt.Errorf("field %s: enum only valid for string, number, or integer", field)
+	}
+	return nil
+}
+
+// normalizeProperty checks one schema property. Properties of an object field
+// are normalized with nested set, which rejects a further level of nesting.
+func normalizeProperty(field string, p rawSchemaProp, nested bool) (SchemaProperty, error) {
+	sp := SchemaProperty{
+		Type:             p.Type,
+		EnumRef:          p.EnumRef,
+		MinLength:        p.MinLength,
+		MaxLength:        p.MaxLength,
+		Minimum:          p.Minimum,
+		Maximum:          p.Maximum,
+		ExclusiveMinimum: p.ExclusiveMinimum,
+		ExclusiveMaximum: p.ExclusiveMaximum,
+		MultipleOf:       p.MultipleOf,
+		MinItems:         p.MinItems,
+		MaxItems:         p.MaxItems,
+		Aliases:          append([]string(nil), p.Aliases...),
+	}
+	switch p.Type {
+	case "string", "number", "integer", "boolean":
+	case "array":
+		if p.Items == nil {
+			return SchemaProperty{}, fmt.Errorf("field %s: array missing items.type", field)
+		}
+		if p.Items.Type != "string" && p.Items.Type != "number" && p.Items.Type != "integer" {
+			return SchemaProperty{}, fmt.Errorf("field %s: array items.type must be string/number/integer", field)
+		}
+		sp.ItemsType = p.Items.Type
+		sp.UniqueItems = p.UniqueItems
+		switch p.ArrayMerge {
+		case "", arrayMergeReplace:
+			sp.ArrayMerge = arrayMergeReplace
+		case arrayMergeUnion:
+			sp.ArrayMerge = arrayMergeUnion
+		default:
+			return SchemaProperty{}, fmt.Errorf("field %s: arrayMerge must be replace or union", field)
+		}
+	case "object":
+		if nested {
+			return SchemaProperty{}, fmt.Errorf("field %s: nested objects deeper than one level are not supported", field)
+		}
+		if len(p.Properties) == 0 {
+			return SchemaProperty{}, fmt.Errorf("field %s: object requires properties", field)
+		}
+		sp.Properties = make(map[string]SchemaProperty, len(p.Properties))
+		for _, name := range sortedKeys(p.Properties) {
+			child := p.Properties[name]
+			if name == "" || strings.Contains(name, ".") {
+				return SchemaProperty{}, fmt.Errorf("field %s: invalid property name %q", field, name)
+			}
+			cp, err := normalizeProperty(field+"."+name, child, true)
+			if err != nil {
+				return SchemaProperty{}, err
+			}
+			sp.Properties[name] = cp
+		}
+		sp.Required = make(map[string]struct{}, len(p.Required))
+		for _, name := range p.Required {
+			if _, ok := sp.Properties[name]; !ok {
+				return SchemaProperty{}, fmt.Errorf("field %s: required field %q is not defined in properties", field, name)
+			}
+			sp.Required[name] = struct{}{}
+		}
+	default:
+		return SchemaProperty{}, fmt.Errorf("field %s: unsupported type %q", field, p.Type)
+	}
+	if p.Type != "object" && (p.Properties != nil || p.Required != nil) {
+		return SchemaProperty{}, fmt.Errorf("field %s: properties/required only valid for object", field)
+	}
+	if nested && p.EnumRef != "" {
+		return SchemaProperty{}, fmt.Errorf("field %s: enumRef is not supported inside object fields", field)
+	}
+	if nested && p.ArrayMerge != "" {
+		return SchemaProperty{}, fmt.Errorf("field %s: arrayMerge is not supported inside object fields", field)
+	}
+	if nested && len(p.Aliases) > 0 {
+		return SchemaProperty{}, fmt.Errorf("field %s: aliases are not supported inside object fields", field)
+	}
+	if p.EnumRef != "" && p.Type != "string" {
+		return SchemaProperty{}, fmt.Errorf("field %s: enumRef only valid for string", field)
+	}
+	if p.EnumRef != "" && len(p.Enum) > 0 {
+		return SchemaProperty{}, fmt.Errorf("field %s: enum and enumRef cannot be combined", field)
+	}
+	if p.Type == "array" && len(p.Enum) > 0 {
+		return SchemaProperty{}, fmt.Errorf("field %s: enum not supported for array", field)
+	}
+	if err := normalizeEnum(field, p, &sp); err != nil {
+		return SchemaProperty{}, err
+	}
+	if p.Type != "string" && (p.MinLength != nil || p.MaxLength != nil) {
+		return SchemaProperty{}, fmt.Errorf("field %s: minLength/maxLength only valid for string", field)
+	}
+	if p.Pattern != nil {
+		if p.Type != "string" {
+			return SchemaProperty{}, fmt.Errorf("field %s: pattern only valid for string", field)
+		}
+		re, err := regexp.Compile(*p.Pattern)
+		if err != nil {
+			return SchemaProperty{}, fmt.Errorf("field %s: invalid pattern: %w", field, err)
+		}
+		sp.Pattern = p.Pattern
+		sp.patternRe = re
+	}
+	if p.Format != "" {
+		if p.Type != "string" {
+			return SchemaProperty{}, fmt.Errorf("field %s: format only valid for string", field)
+		}
+		if _, ok := stringFormats[p.Format]; !ok {
+			return SchemaProperty{}, fmt.Errorf("field %s: unsupported format %q", field, p.Format)
+		}
+		sp.Format = p.Format
+	}
+	if p.Type != "number" && p.Type != "integer" && (p.Minimum != nil || p.Maximum != nil || p.ExclusiveMinimum != nil || p.ExclusiveMaximum != nil || p.MultipleOf != nil) {
+		return SchemaProperty{}, fmt.Errorf("field %s: minimum/maximum/exclusiveMinimum/exclusiveMaximum/multipleOf only valid for number/integer", field)
+	}
+	if p.MultipleOf != nil && *p.MultipleOf <= 0 {
+		return SchemaProperty{}, fmt.Errorf("field %s: multipleOf must be > 0", field)
+	}
+	if p.Type != "array" && (p.MinItems != nil || p.MaxItems != nil || p.UniqueItems || p.ArrayMerge != "") {
+		return SchemaProperty{}, fmt.Errorf("field %s: minItems/maxItems/uniqueItems/arrayMerge only valid for array", field)
+	}
+	if (p.MinItems != nil && *p.MinItems < 0) || (p.MaxItems != nil && *p.MaxItems < 0) {
+		return SchemaProperty{}, fmt.Errorf("field %s: minItems/maxItems must be >= 0", field)
+	}
+	if p.MinItems != nil && p.MaxItems != nil && *p.MinItems > *p.MaxItems {
+		return SchemaProperty{}, fmt.Errorf("field %s: minItems must be <= maxItems", field)
+	}
+	if p.Default != nil {
+		value, err := normalizeObjectValue(p.Default)
+		if err != nil {
+			return SchemaProperty{}, fmt.Errorf("field %s: default: %w", field, err)
+		}
+		check := ValidationResult{}
+		validateProperty(field, value, sp, "", &check)
+		if !check.OK() {
+			return SchemaProperty{}, fmt.Errorf("field %s: default %s", field, check.Issues[0].Message)
+		}
+		sp.Default = value
+	}
+	return sp, nil
+}
+
+// applySchemaDefaults fills the fields of a new object that were left out with
+// their schema defaults. A field counts as left out only when submitted
+// reports false for it ("parent.child" for fields of an object property), so
+// a value the editor cleared on purpose stays empty.
+func applySchemaDefaults(data map[string]any, schema Schema, submitted func(field string) bool) {
+	for _, field := range sortedKeys(schema.Properties) {
+		prop := schema.Properties[field]
+		if prop.Type != "object" {
+			if _, ok := data[field]; !ok && prop.Default != nil && !submitted(field) {
+				data[field] = cloneDefault(prop.Default)
+			}
+			continue
+		}
+		nested, _ := data[field].(map[string]any)
+		fromParent, _ := prop.Default.(map[string]any)
+		filled := make(map[string]any, len(nested))
+		for k, v := range nested {
+			filled[k] = v
+		}
+		for _, child := range sortedKeys(prop.Properties) {
+			if _, ok := filled[child]; ok || submitted(field+"."+child) {
+				continue
+			}
+			if v := prop.Properties[child].Default; v != nil {
+				filled[child] = cloneDefault(v)
+			} else if v, ok := fromParent[child]; ok {
+				filled[child] = cloneDefault(v)
+			}
+		}
+		if len(filled) > 0 {
+			data[field] = filled
+		}
+	}
+}
+
+// cloneDefault copies array defaults so objects never share the schema's slice.
+func cloneDefault(v any) any {
+	if arr, ok := v.([]any); ok {
+		return append([]any{}, arr...)
+	}
+	return v
 }
@@ -0,0 +1,109 @@
+package app
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadSchemasRejectsCaseVariantTypeNames(t *testing.T) {
+	repo := newTestRepository(t)
+	dir := filepath.Join(repo.Root, "config", "schemas")
+	b, err := os.ReadFile(filepath.Join(dir, "team.schema.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeTestFile(t, filepath.Join(dir, "Team.schema.json"), string(b))
+
+	_, err = LoadSchemas(repo.Root)
+	if err == nil {
+		t.Fatal("LoadSchemas accepted Team.schema.json next to team.schema.json")
+	}
+	for _, want := range []string{"Team.schema.json", "team.schema.json", "ignoring case"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q does not mention %q", err, want)
+		}
+	}
+}
+
+func TestLoadSchemasRejectsRequiredFieldWithoutProperty(t *testing.T) {
+	repo := newTestRepository(t)
+	schemaPath := filepath.Join(repo.Root, "config", "schemas", "service.schema.json")
+	editTestJSON(t, schemaPath, func(doc map[string]any) {
+		doc["required"] = append(doc["required"].([]any), "owner")
+	})
+	_, err := LoadSchemas(repo.Root)
+	if err == nil || !strings.Contains(err.Error(), `schema service.schema.json: required field "owner" is not defined in properties`) {
+		t.Errorf("top-level: error = %v, want the undefined required field reported", err)
+	}
+
+	editTestJSON(t, schemaPath, func(doc map[string]any) {
+		doc["required"] = []any{"name", "teamId", "tier"}
+		doc["properties"].(map[string]any)["location"] = map[string]any{
+			"type":       "object",
+			"required":   []any{"zone"},
+			"properties": map[string]any{"rack": map[string]any{"type": "string"}},
+		}
+	})
+	_, err = LoadSchemas(repo.Root)
+	if err == nil || !strings.Contains(err.Error(), `field location: required field "zone" is not defined in properties`) {
+		t.Errorf("nested: error = %v, want the undefined required child reported", err)
+	}
+}
+
+func TestSchemaAliasesValidateAndRejectCollisions(t *testing.T) {
+	repo := newTestRepository(t)
+	schemaPath := filepath.Join(repo.Root, "config", "schemas", "service.schema.json")
+	setAliases := func(aliases map[string][]any) {
+		editTestJSON(t, schemaPath, func(doc map[string]any) {
+			props := doc["properties"].(map[string]any)
+			for field, list := range aliases {
+				props[field].(map[string]any)["aliases"] = list
+			}
+		})
+	}
+
+	setAliases(map[string][]any{"name": {"title"}, "tier": {"title"}})
+	if _, err := LoadSchemas(repo.Root); err == nil || !strings.Contains(err.Error(), `alias "title" is already an alias of`) {
+		t.Errorf("shared alias: error = %v, want a collision", err)
+	}
+	setAliases(map[string][]any{"name": {"tier"}, "tier": {}})
+	if _, err := LoadSchemas(repo.Root); err == nil || !strings.Contains(err.Error(), "collides with a property") {
+		t.Errorf("alias naming a property: error = %v, want a collision", err)
+	}
+
+	// An object still using the old key validates as the property.
+	setAliases(map[string][]any{"name": {"title"}})
+	servicePath := filepath.Join(repo.Root, "data", "service", testServiceID+".yaml")
+	replaceTestFile(t, servicePath, "name: edge-gateway", "title: edge-gateway")
+	result, err := ValidateRepository(repo.Root, repo.YAMLOptions)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.OK() {
+		t.Errorf("aliased object issues = %v, want none", result.Issues)
+	}
+
+	// Rewriting canonical files moves the value to the property name.
+	if _, err := repo.RewriteCanonicalFiles(repo.Root, []string{"data/service/" + testServiceID + ".yaml"}); err != nil {
+		t.Fatal(err)
+	}
+	obj, err := repo.ReadObject(repo.Root, "service", testServiceID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := obj.Data["title"]; ok || obj.Data["name"] != "edge-gateway" {
+		t.Errorf("rewritten data = %v, want name and no title", obj.Data)
+	}
+
+	// Setting both the alias and its property is an issue.
+	replaceTestFile(t, servicePath, "name: edge-gateway", "name: edge-gateway\ntitle: gateway")
+	result, err = ValidateRepository(repo.Root, repo.YAMLOptions)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Issues) != 1 || result.Issues[0].Field != "title" || result.Issues[0].Message != "alias of name, which is also set" {
+		t.Errorf("issues = %v, want the clashing alias flagged", result.Issues)
+	}
+}
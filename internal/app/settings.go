@@ -0,0 +1,59 @@
+package app
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Settings is config/settings.json: tool behavior that travels with the
+// repository. Environment variables and flags override each setting.
+type Settings struct {
+	BaseBranch   string `json:"baseBranch,omitempty"`
+	MergeWebhook string `json:"mergeWebhook,omitempty"`
+	YAMLQuotes   string `json:"yamlQuotes,omitempty"`
+	YAMLComments *bool  `json:"yamlComments,omitempty"`
+}
+
+func LoadSettings(root string) (Settings, error) {
+	path := filepath.Join(root, "config", "settings.json")
+	b, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return Settings{}, nil
+		}
+		return Settings{}, err
+	}
+	var s Settings
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&s); err != nil {
+		return Settings{}, fmt.Errorf("parse settings: %w", err)
+	}
+	s.BaseBranch = strings.TrimSpace(s.BaseBranch)
+	s.MergeWebhook = strings.TrimSpace(s.MergeWebhook)
+	s.YAMLQuotes = strings.TrimSpace(s.YAMLQuotes)
+	switch s.YAMLQuotes {
+	case "", YAMLQuoteAuto, YAMLQuoteAlways, YAMLQuoteMinimal:
+	default:
+		return Settings{}, fmt.Errorf("settings: yamlQuotes must be auto, always, or minimal (got %q)", s.YAMLQuotes)
+	}
+	if s.BaseBranch != "" && (strings.HasPrefix(s.BaseBranch, "-") || strings.ContainsAny(s.BaseBranch, " \t~^:?*[\\")) {
+		return Settings{}, fmt.Errorf("settings: baseBranch %q is not a valid branch name", s.BaseBranch)
+	}
+	return s, nil
+}
+
+// yamlOptions returns the YAML options the settings choose. Environment
+// variables override them; see yamlOptionsFromEnv.
+func (s Settings) yamlOptions() YAMLOptions {
+	y := YAMLOptions{YAMLQuotes: s.YAMLQuotes}
+	if s.YAMLComments != nil {
+		y.YAMLComments = *s.YAMLComments
+	}
+	return y
+}
@@ -0,0 +1,182 @@
+package app
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestYAMLOptionsPrecedence(t *testing.T) {
+	repo := newTestRepository(t)
+	writeTestFile(t, filepath.Join(repo.Root, "config", "settings.json"), `{"yamlQuotes": "always", "yamlComments": true}`+"\n")
+	const id = "33333333-3333-4333-8333-333333333333"
+	team := Object{ID: id, Type: "team", Data: map[string]any{"name": "Data team", "code": "DATA"}}
+	commented := "# owned by data\n_id: " + id + "\n_type: team\ncode: DATA\nname: Data\n"
+
+	tests := []struct {
+		name      string
+		env       map[string]string
+		wantCode  string
+		wantName  string
+		wantQuote string
+		comments  bool
+	}{
+		{"settings", nil, `code: "DATA"`, `name: "Data team"`, YAMLQuoteAlways, true},
+		{"env over settings", map[string]string{"WORKTREEFOUNDRY_YAML_QUOTES": "minimal", "WORKTREEFOUNDRY_YAML_COMMENTS": "false"}, "code: DATA", "name: Data team", YAMLQuoteMinimal, false},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			for k, v := range tc.env {
+				t.Setenv(k, v)
+			}
+			opened, err := OpenRepository(repo.Root, "", "")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if opened.YAMLQuotes != tc.wantQuote || opened.YAMLComments != tc.comments {
+				t.Errorf("options = %+v, want quotes %s and comments %v", opened.YAMLOptions, tc.wantQuote, tc.comments)
+			}
+			if err := opened.WriteObject(repo.Root, team); err != nil {
+				t.Fatal(err)
+			}
+			b, err := os.ReadFile(filepath.Join(repo.Root, "data", "team", id+".yaml"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, want := range []string{tc.wantCode, tc.wantName} {
+				if !strings.Contains(string(b), want+"\n") {
+					t.Errorf("written file lacks %q:\n%s", want, b)
+				}
+			}
+
+			writeTestFile(t, filepath.Join(repo.Root, "data", "team", id+".yaml"), commented)
+			_, err = opened.ReadObject(repo.Root, "team", id)
+			if tc.comments && err != nil {
+				t.Errorf("commented file rejected: %v", err)
+			}
+			if !tc.comments && err == nil {
+				t.Error("commented file accepted")
+			}
+		})
+	}
+}
+
+func TestYAMLOptionsArePerRepository(t *testing.T) {
+	quoted := newTestRepository(t)
+	writeTestFile(t, filepath.Join(quoted.Root, "config", "settings.json"), `{"yamlQuotes": "always"}`+"\n")
+	quoted, err := OpenRepository(quoted.Root, "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Opening a second repository must not change how the first writes.
+	plain := newTestRepository(t)
+
+	obj := Object{ID: "33333333-3333-4333-8333-333333333333", Type: "team", Data: map[string]any{"name": "Data", "code": "DATA"}}
+	for _, tc := range []struct {
+		repo *Repository
+		want string
+	}{{quoted, `code: "DATA"`}, {plain, "code: DATA"}} {
+		if err := tc.repo.WriteObject(tc.repo.Root, obj); err != nil {
+			t.Fatal(err)
+		}
+		b, err := os.ReadFile(filepath.Join(tc.repo.Root, "data", "team", obj.ID+".yaml"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(string(b), tc.want+"\n") {
+			t.Errorf("%s: written file lacks %q:\n%s", tc.repo.Root, tc.want, b)
+		}
+	}
+}
+
+func TestMergeWebhookPrecedence(t *testing.T) {
+	repo := newTestRepository(t)
+	hits := map[string]int{}
+	urls := map[string]string{}
+	for _, source := range []string{"settings", "env", "flag"} {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			hits[source]++
+		}))
+		defer server.Close()
+		urls[source] = server.URL
+	}
+	writeTestFile(t, filepath.Join(repo.Root, "config", "settings.json"), `{"mergeWebhook": "`+urls["settings"]+`"}`+"\n")
+	commitTestMain(t, repo, "settings")
+	t.Setenv("WORKTREEFOUNDRY_MERGE_WEBHOOK", "")
+
+	ids := []string{"33333333-3333-4333-8333-333333333333", "44444444-4444-4444-8444-444444444444", "55555555-5555-4555-8555-555555555555"}
+	steps := []struct {
+		want string
+		env  string
+		args []string
+	}{
+		{want: "settings"},
+		{want: "env", env: urls["env"]},
+		{want: "flag", env: urls["env"], args: []string{"--merge-webhook", urls["flag"]}},
+	}
+	for i, step := range steps {
+		name := "ws" + ids[i][:1]
+		ws := newTestWorkspace(t, repo, name)
+		code := "T" + ids[i][:1]
+		if err := repo.WriteObject(ws, Object{ID: ids[i], Type: "team", Data: map[string]any{"name": code, "code": code}}); err != nil {
+			t.Fatal(err)
+		}
+		saveTestWorkspace(t, repo, name)
+
+		t.Setenv("WORKTREEFOUNDRY_MERGE_WEBHOOK", step.env)
+		args := append([]string{"merge", "--repository", repo.Root, "--workspace", name}, step.args...)
+		if err := Run(context.Background(), args, "test"); err != nil {
+			t.Fatal(err)
+		}
+		if hits[step.want] != 1 {
+			t.Errorf("merge %d: hits = %v, want one request to the %s webhook", i+1, hits, step.want)
+		}
+		delete(hits, step.want)
+	}
+	if len(hits) != 0 {
+		t.Errorf("unexpected webhook requests: %v", hits)
+	}
+}
+
+func TestBaseBranchPrecedence(t *testing.T) {
+	repo := newTestRepository(t)
+	writeTestFile(t, filepath.Join(repo.Root, "config", "settings.json"), `{"baseBranch": "from-settings"}`+"\n")
+	commitTestMain(t, repo, "settings")
+
+	steps := []struct {
+		name string
+		env  string
+		args []string
+		want string
+	}{
+		{name: "settings", want: `base branch "from-settings" not found`},
+		{name: "env over settings", env: "from-env", want: `base branch "from-env" not found`},
+		{name: "flag over env", env: "from-env", args: []string{"--base-branch", "from-flag"}, want: `base branch "from-flag" not found`},
+		{name: "existing flag branch", env: "from-env", args: []string{"--base-branch", "main"}},
+	}
+	for _, step := range steps {
+		t.Run(step.name, func(t *testing.T) {
+			t.Setenv("WORKTREEFOUNDRY_BASE_BRANCH", step.env)
+			args := append([]string{"graph", "--repository", repo.Root, "--format", "json"}, step.args...)
+			err := Run(context.Background(), args, "test")
+			if step.want == "" && err != nil {
+				t.Errorf("error = %v, want none", err)
+			}
+			if step.want != "" && (err == nil || !strings.Contains(err.Error(), step.want)) {
+				t.Errorf("error = %v, want %q", err, step.want)
+			}
+		})
+	}
+
+	opened, err := OpenRepository(repo.Root, "", "main")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if opened.BaseBranch != "main" {
+		t.Errorf("BaseBranch = %q, want the argument over settings", opened.BaseBranch)
+	}
+}
@@ -0,0 +1,93 @@
+package app
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// snapshotTagPrefix namespaces the lightweight tags that mark snapshots of
+// the base branch.
+const snapshotTagPrefix = "snapshot/"
+
+// snapshotTimeFormat names snapshots by UTC creation time, so tag names sort
+// chronologically.
+const snapshotTimeFormat = "20060102T150405Z"
+
+type Snapshot struct {
+	Tag     string `json:"tag"`
+	Commit  string `json:"commit"`
+	Date    string `json:"date"`
+	Subject string `json:"subject"`
+}
+
+// CreateSnapshot tags the current base branch commit as
+// snapshot/<timestamp> and returns the tag. A second snapshot within the same
+// second gets a numeric suffix.
+func (r *Repository) CreateSnapshot() (string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.createSnapshotLocked()
+}
+
+func (r *Repository) createSnapshotLocked() (string, error) {
+	base := snapshotTagPrefix + time.Now().UTC().Format(snapshotTimeFormat)
+	tag := base
+	for n := 2; r.snapshotExists(tag); n++ {
+		tag = base + "-" + strconv.Itoa(n)
+	}
+	if _, err := r.runGit(r.Root, "tag", tag, r.BaseBranch); err != nil {
+		return "", fmt.Errorf("create snapshot: %w", err)
+	}
+	return tag, nil
+}
+
+// ListSnapshots returns the snapshot tags, newest first.
+func (r *Repository) ListSnapshots() ([]Snapshot, error) {
+	out, err := r.runGit(r.Root, "for-each-ref", "--format=%(refname:strip=2)%00%(objectname:short)%00%(creatordate:short)%00%(subject)", "refs/tags/"+snapshotTagPrefix)
+	if err != nil {
+		return nil, err
+	}
+	snapshots := make([]Snapshot, 0)
+	for _, line := range strings.Split(strings.TrimSpace(out), "\n") {
+		parts := strings.SplitN(line, "\x00", 4)
+		if len(parts) != 4 {
+			continue
+		}
+		snapshots = append(snapshots, Snapshot{Tag: parts[0], Commit: parts[1], Date: parts[2], Subject: parts[3]})
+	}
+	sort.Slice(snapshots, func(i, j int) bool { return snapshots[i].Tag > snapshots[j].Tag })
+	return snapshots, nil
+}
+
+// RestoreSnapshot resets the base branch to a snapshot. The main worktree
+// must be clean. The current commit is snapshotted first, so the restore can
+// itself be undone; that tag is returned. tag may omit the snapshot/ prefix.
+func (r *Repository) RestoreSnapshot(tag string) (string, error) {
+	if !strings.HasPrefix(tag, snapshotTagPrefix) {
+		tag = snapshotTagPrefix + tag
+	}
+	if !r.snapshotExists(tag) {
+		return "", fmt.Errorf("snapshot %q not found", tag)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err := r.requireCleanMain(); err != nil {
+		return "", err
+	}
+	previous, err := r.createSnapshotLocked()
+	if err != nil {
+		return "", err
+	}
+	if _, err := r.runGit(r.Root, "reset", "--keep", "refs/tags/"+tag); err != nil {
+		return "", fmt.Errorf("restore snapshot: %w", err)
+	}
+	return previous, nil
+}
+
+func (r *Repository) snapshotExists(tag string) bool {
+	_, err := r.runGit(r.Root, "rev-parse", "--verify", "--quiet", "refs/tags/"+tag+"^{commit}")
+	return err == nil
+}
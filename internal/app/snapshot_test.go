@@ -0,0 +1,68 @@
+package app
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSnapshotCreateListRestore(t *testing.T) {
+	repo := newTestRepository(t)
+	first, err := repo.CreateSnapshot()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// A second snapshot in the same second gets a distinct tag.
+	second, err := repo.CreateSnapshot()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(first, snapshotTagPrefix) || second == first {
+		t.Fatalf("snapshots = %q, %q; want two distinct snapshot/ tags", first, second)
+	}
+	snapshots, err := repo.ListSnapshots()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(snapshots) != 2 || snapshots[0].Tag != second || snapshots[1].Tag != first {
+		t.Errorf("snapshots = %+v, want %s then %s", snapshots, second, first)
+	}
+
+	servicePath := filepath.Join(repo.Root, "data", "service", testServiceID+".yaml")
+	replaceTestFile(t, servicePath, "tier: edge", "tier: core")
+	commitTestMain(t, repo, "bulk change")
+
+	// Restoring needs a clean main.
+	writeTestFile(t, filepath.Join(repo.Root, "scratch.txt"), "x\n")
+	if _, err := repo.RestoreSnapshot(first); err == nil {
+		t.Error("restore succeeded with uncommitted changes on main")
+	}
+	if err := os.Remove(filepath.Join(repo.Root, "scratch.txt")); err != nil {
+		t.Fatal(err)
+	}
+
+	undo, err := repo.RestoreSnapshot(strings.TrimPrefix(first, snapshotTagPrefix))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b, err := os.ReadFile(servicePath); err != nil {
+		t.Fatal(err)
+	} else if !strings.Contains(string(b), "tier: edge") {
+		t.Errorf("restored service =\n%s\nwant the snapshot version", b)
+	}
+
+	// The restore snapshotted the bulk change, so it can be undone.
+	if _, err := repo.RestoreSnapshot(undo); err != nil {
+		t.Fatal(err)
+	}
+	if b, err := os.ReadFile(servicePath); err != nil {
+		t.Fatal(err)
+	} else if !strings.Contains(string(b), "tier: core") {
+		t.Errorf("undone restore left service =\n%s\nwant the bulk change", b)
+	}
+
+	if _, err := repo.RestoreSnapshot("snapshot/missing"); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("restore of a missing snapshot: error = %v, want not found", err)
+	}
+}
@@ -0,0 +1,150 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"text/tabwriter"
+)
+
+// RepositoryStats summarizes a repository for operators: object counts per
+// type, uncommitted changes per workspace, constraint counts, and validation
+// issue counts by stage.
+type RepositoryStats struct {
+	Types       []TypeStats      `json:"types"`
+	Workspaces  []WorkspaceStats `json:"workspaces"`
+	Constraints ConstraintStats  `json:"constraints"`
+	Issues      IssueStats       `json:"issues"`
+}
+
+type TypeStats struct {
+	Type    string `json:"type"`
+	Objects int    `json:"objects"`
+	Schema  bool   `json:"schema"`
+}
+
+type WorkspaceStats struct {
+	Name         string `json:"name"`
+	ChangedFiles int    `json:"changedFiles"`
+}
+
+type ConstraintStats struct {
+	Unique      int `json:"unique"`
+	ForeignKeys int `json:"foreignKeys"`
+}
+
+type IssueStats struct {
+	Total   int            `json:"total"`
+	ByStage map[string]int `json:"byStage"`
+}
+
+// CollectStats gathers RepositoryStats for the main checkout. Types include
+// every schema and every data directory, so types without objects and objects
+// without a schema both show up.
+func (r *Repository) CollectStats() (RepositoryStats, error) {
+	schemas, err := LoadSchemas(r.Root)
+	if err != nil {
+		return RepositoryStats{}, err
+	}
+	objects, err := r.LoadObjects(r.Root)
+	if err != nil {
+		return RepositoryStats{}, err
+	}
+	constraints, err := LoadConstraints(r.Root)
+	if err != nil {
+		return RepositoryStats{}, err
+	}
+	workspaces, err := r.ListWorkspaces()
+	if err != nil {
+		return RepositoryStats{}, err
+	}
+	result, err := ValidateRepository(r.Root, r.YAMLOptions)
+	if err != nil {
+		return RepositoryStats{}, err
+	}
+
+	stats := RepositoryStats{
+		Types:       make([]TypeStats, 0),
+		Workspaces:  make([]WorkspaceStats, 0, len(workspaces)),
+		Constraints: ConstraintStats{Unique: len(constraints.Unique), ForeignKeys: len(constraints.ForeignKeys)},
+		Issues:      IssueStats{Total: len(result.Issues), ByStage: map[string]int{}},
+	}
+	types := map[string]struct{}{}
+	for name := range schemas {
+		types[name] = struct{}{}
+	}
+	for name := range objects {
+		types[name] = struct{}{}
+	}
+	for _, name := range sortedKeys(types) {
+		_, hasSchema := schemas[name]
+		stats.Types = append(stats.Types, TypeStats{Type: name, Objects: len(objects[name]), Schema: hasSchema})
+	}
+	for _, ws := range workspaces {
+		stats.Workspaces = append(stats.Workspaces, WorkspaceStats{Name: ws.Name, ChangedFiles: len(ws.ChangedFiles)})
+	}
+	for _, issue := range result.Issues {
+		stats.Issues.ByStage[issue.Stage]++
+	}
+	return stats, nil
+}
+
+// WriteStatsTable prints the stats as aligned sections.
+func WriteStatsTable(w io.Writer, stats RepositoryStats) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "TYPE\tOBJECTS\tSCHEMA")
+	for _, t := range stats.Types {
+		schema := "yes"
+		if !t.Schema {
+			schema = "no"
+		}
+		fmt.Fprintf(tw, "%s\t%d\t%s\n", t.Type, t.Objects, schema)
+	}
+	tw.Flush()
+
+	fmt.Fprintln(w)
+	if len(stats.Workspaces) == 0 {
+		fmt.Fprintln(w, "no workspaces")
+	} else {
+		fmt.Fprintln(tw, "WORKSPACE\tCHANGED FILES")
+		for _, ws := range stats.Workspaces {
+			fmt.Fprintf(tw, "%s\t%d\n", ws.Name, ws.ChangedFiles)
+		}
+		tw.Flush()
+	}
+
+	fmt.Fprintln(w)
+	fmt.Fprintln(tw, "CONSTRAINT\tCOUNT")
+	fmt.Fprintf(tw, "unique\t%d\n", stats.Constraints.Unique)
+	fmt.Fprintf(tw, "foreign key\t%d\n", stats.Constraints.ForeignKeys)
+	tw.Flush()
+
+	fmt.Fprintln(w)
+	stages := make([]string, 0, len(stats.Issues.ByStage))
+	for stage := range stats.Issues.ByStage {
+		stages = append(stages, stage)
+	}
+	sort.SliceStable(stages, func(i, j int) bool {
+		if stageRank(stages[i]) == stageRank(stages[j]) {
+			return stages[i] < stages[j]
+		}
+		return stageRank(stages[i]) < stageRank(stages[j])
+	})
+	fmt.Fprintln(tw, "ISSUE STAGE\tCOUNT")
+	for _, stage := range stages {
+		fmt.Fprintf(tw, "%s\t%d\n", stage, stats.Issues.ByStage[stage])
+	}
+	fmt.Fprintf(tw, "total\t%d\n", stats.Issues.Total)
+	tw.Flush()
+}
+
+func WriteStatsJSON(w io.Writer, stats RepositoryStats) error {
+	b, err := json.MarshalIndent(stats, "", "  ")
+	if err != nil {
+		return err
+	}
+	b = append(b, '\n')
+	_, err = w.Write(b)
+	return err
+}
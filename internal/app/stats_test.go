@@ -0,0 +1,66 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCollectStatsCountsTypesAndWorkspaces(t *testing.T) {
+	repo := newTestRepository(t)
+	writeTestServices(t, repo.Root, 3)
+	commitTestMain(t, repo, "add services")
+	newTestWorkspace(t, repo, "clean")
+	ws := newTestWorkspace(t, repo, "draft")
+	replaceTestFile(t, filepath.Join(ws, "data", "team", testTeamID+".yaml"), "code: PLAT", "code: PLT")
+	writeTestFile(t, filepath.Join(ws, "data", "team", testObjectID(3)+".yaml"), "_id: "+testObjectID(3)+"\n_type: team\ncode: DATA\nname: Data\n")
+
+	stats, err := repo.CollectStats()
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantTypes := []TypeStats{{Type: "service", Objects: 4, Schema: true}, {Type: "team", Objects: 1, Schema: true}}
+	if !reflect.DeepEqual(stats.Types, wantTypes) {
+		t.Errorf("types = %+v, want %+v", stats.Types, wantTypes)
+	}
+	wantWorkspaces := []WorkspaceStats{{Name: "clean", ChangedFiles: 0}, {Name: "draft", ChangedFiles: 2}}
+	if !reflect.DeepEqual(stats.Workspaces, wantWorkspaces) {
+		t.Errorf("workspaces = %+v, want %+v", stats.Workspaces, wantWorkspaces)
+	}
+	if stats.Constraints != (ConstraintStats{Unique: 2, ForeignKeys: 1}) || stats.Issues.Total != 0 {
+		t.Errorf("constraints = %+v, issues = %+v", stats.Constraints, stats.Issues)
+	}
+
+	var buf bytes.Buffer
+	WriteStatsTable(&buf, stats)
+	for _, want := range []string{"service  4        yes\n", "draft      2\n", "total        0\n"} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("table lacks %q:\n%s", want, buf.String())
+		}
+	}
+}
+
+func TestStatsCommandReportsIssues(t *testing.T) {
+	repo := newTestRepository(t)
+	writeTestFile(t, filepath.Join(repo.Root, "data", "widget", testObjectID(5)+".yaml"), "_id: "+testObjectID(5)+"\n_type: widget\nname: w\n")
+	commitTestMain(t, repo, "add widget")
+
+	stats, err := repo.CollectStats()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := stats.Types[len(stats.Types)-1]; got != (TypeStats{Type: "widget", Objects: 1, Schema: false}) {
+		t.Errorf("last type = %+v, want the widget without a schema", got)
+	}
+	if stats.Issues.Total == 0 || stats.Issues.Total != stats.Issues.ByStage["schema"] {
+		t.Errorf("issues = %+v, want only schema issues", stats.Issues)
+	}
+
+	err = Run(context.Background(), []string{"stats", "--repository", repo.Root, "--format", "json"}, "test")
+	if err == nil || !strings.Contains(err.Error(), "validation issue(s)") {
+		t.Errorf("stats with issues: error = %v", err)
+	}
+}
@@ -5,8 +5,9 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
-	"sort"
+	"regexp"
 	"strings"
+	"unicode/utf8"
 )
 
 type UIConfig struct {
@@ -15,10 +16,35 @@ type UIConfig struct {
 }
 
 type TypeUIConfig struct {
-	DisplayField string   `json:"displayField"`
-	Fields       []string `json:"fields"`
+	DisplayField       string         `json:"displayField"`
+	Fields             []string       `json:"fields"`
+	HiddenFields       []string       `json:"hiddenFields,omitempty"`
+	Color              string         `json:"color,omitempty"`
+	Icon               string         `json:"icon,omitempty"`
+	WarnDuplicateItems bool           `json:"warnDuplicateItems,omitempty"`
+	Presets            []ObjectPreset `json:"presets,omitempty"`
+	DefaultSortField   string         `json:"defaultSortField,omitempty"`
+	DefaultSortDir     string         `json:"defaultSortDir,omitempty"`
 }
 
+type ObjectPreset struct {
+	Name   string         `json:"name"`
+	Values map[string]any `json:"values"`
+}
+
+func (tc TypeUIConfig) Preset(name string) (ObjectPreset, bool) {
+	for _, p := range tc.Presets {
+		if p.Name == name {
+			return p, true
+		}
+	}
+	return ObjectPreset{}, false
+}
+
+var typeColorPattern = regexp.MustCompile(`^#(?:[0-9a-fA-F]{3}|[0-9a-fA-F]{6})$`)
+
+const maxTypeIconRunes = 4
+
 func LoadUIConfig(root string, schemas map[string]Schema) (UIConfig, error) {
 	cfg := DefaultUIConfig(root, schemas)
 	path := filepath.Join(root, "config", "ui.json")
@@ -39,7 +65,17 @@ func LoadUIConfig(root string, schemas map[string]Schema) (UIConfig, error) {
 	}
 	if parsed.Types != nil {
 		for typeName, tc := range parsed.Types {
-			normalized := TypeUIConfig{DisplayField: tc.DisplayField, Fields: dedupeOrdered(tc.Fields)}
+			normalized := TypeUIConfig{
+				DisplayField:       tc.DisplayField,
+				Fields:             dedupeOrdered(tc.Fields),
+				HiddenFields:       dedupeOrdered(tc.HiddenFields),
+				Color:              strings.TrimSpace(tc.Color),
+				Icon:               strings.TrimSpace(tc.Icon),
+				WarnDuplicateItems: tc.WarnDuplicateItems,
+				Presets:            tc.Presets,
+				DefaultSortField:   strings.TrimSpace(tc.DefaultSortField),
+				DefaultSortDir:     strings.TrimSpace(tc.DefaultSortDir),
+			}
 			if normalized.DisplayField == "" {
 				normalized.DisplayField = "_id"
 			}
@@ -54,18 +90,14 @@ func DefaultUIConfig(root string, schemas map[string]Schema) UIConfig {
 		RepoName: filepath.Base(root),
 		Types:    map[string]TypeUIConfig{},
 	}
-	types := make([]string, 0, len(schemas))
-	for t := range schemas {
-		types = append(types, t)
-	}
-	sort.Strings(types)
+	types := sortedKeys(schemas)
 	for _, t := range types {
 		cfg.Types[t] = TypeUIConfig{DisplayField: "_id", Fields: []string{}}
 	}
 	return cfg
 }
 
-func SaveUIConfig(root string, cfg UIConfig) error {
+func (o FileOptions) SaveUIConfig(root string, cfg UIConfig) error {
 	if strings.TrimSpace(cfg.RepoName) == "" {
 		return fmt.Errorf("repo name is required")
 	}
@@ -74,11 +106,7 @@ func SaveUIConfig(root string, cfg UIConfig) error {
 		cfg.Types = map[string]TypeUIConfig{}
 	}
 
-	types := make([]string, 0, len(cfg.Types))
-	for t := range cfg.Types {
-		types = append(types, t)
-	}
-	sort.Strings(types)
+	types := sortedKeys(cfg.Types)
 	normalized := UIConfig{RepoName: cfg.RepoName, Types: map[string]TypeUIConfig{}}
 	for _, t := range types {
 		tc := cfg.Types[t]
@@ -86,6 +114,11 @@ func SaveUIConfig(root string, cfg UIConfig) error {
 			tc.DisplayField = "_id"
 		}
 		tc.Fields = dedupeOrdered(tc.Fields)
+		tc.HiddenFields = dedupeOrdered(tc.HiddenFields)
+		tc.Color = strings.TrimSpace(tc.Color)
+		tc.Icon = strings.TrimSpace(tc.Icon)
+		tc.DefaultSortField = strings.TrimSpace(tc.DefaultSortField)
+		tc.DefaultSortDir = strings.TrimSpace(tc.DefaultSortDir)
 		normalized.Types[t] = tc
 	}
 
@@ -96,10 +129,7 @@ func SaveUIConfig(root string, cfg UIConfig) error {
 	b = append(b, '\n')
 
 	path := filepath.Join(root, "config", "ui.json")
-	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
-		return err
-	}
-	return os.WriteFile(path, b, 0o644)
+	return o.writeFileAll(path, b)
 }
 
 func ValidateUIConfig(cfg UIConfig, schemas map[string]Schema) []ValidationIssue {
@@ -107,7 +137,8 @@ func ValidateUIConfig(cfg UIConfig, schemas map[string]Schema) []ValidationIssue
 	if strings.TrimSpace(cfg.RepoName) == "" {
 		issues = append(issues, ValidationIssue{Stage: "config", Path: "config/ui.json", Field: "repoName", Message: "repoName is required"})
 	}
-	for typeName, tc := range cfg.Types {
+	for _, typeName := range sortedKeys(cfg.Types) {
+		tc := cfg.Types[typeName]
 		schema, ok := schemas[typeName]
 		if !ok {
 			issues = append(issues, ValidationIssue{Stage: "config", Path: "config/ui.json", Field: "types." + typeName, Message: "unknown type"})
@@ -141,6 +172,76 @@ func ValidateUIConfig(cfg UIConfig, schemas map[string]Schema) []ValidationIssue
 				issues = append(issues, ValidationIssue{Stage: "config", Path: "config/ui.json", Field: "types." + typeName + ".fields", Message: "field " + field + " not in schema"})
 			}
 		}
+		if tc.Color != "" && !typeColorPattern.MatchString(tc.Color) {
+			issues = append(issues, ValidationIssue{Stage: "config", Path: "config/ui.json", Field: "types." + typeName + ".color", Message: "color must be a hex value like #1f6db3"})
+		}
+		if utf8.RuneCountInString(tc.Icon) > maxTypeIconRunes {
+			issues = append(issues, ValidationIssue{Stage: "config", Path: "config/ui.json", Field: "types." + typeName + ".icon", Message: fmt.Sprintf("icon must be at most %d characters", maxTypeIconRunes)})
+		}
+		for _, field := range tc.HiddenFields {
+			if _, ok := schema.Properties[field]; !ok {
+				issues = append(issues, ValidationIssue{Stage: "config", Path: "config/ui.json", Field: "types." + typeName + ".hiddenFields", Message: "field " + field + " not in schema"})
+				continue
+			}
+			if field == display {
+				issues = append(issues, ValidationIssue{Stage: "config", Path: "config/ui.json", Field: "types." + typeName + ".hiddenFields", Message: "display field cannot be hidden"})
+			}
+		}
+		if tc.DefaultSortField != "" {
+			if _, ok := schema.Properties[tc.DefaultSortField]; !ok {
+				issues = append(issues, ValidationIssue{Stage: "config", Path: "config/ui.json", Field: "types." + typeName + ".defaultSortField", Message: "field " + tc.DefaultSortField + " not in schema"})
+			} else if !contains(tc.Fields, tc.DefaultSortField) {
+				issues = append(issues, ValidationIssue{Stage: "config", Path: "config/ui.json", Field: "types." + typeName + ".defaultSortField", Message: "default sort field must be listed in fields"})
+			}
+		}
+		switch tc.DefaultSortDir {
+		case "", "asc", "desc":
+			if tc.DefaultSortDir != "" && tc.DefaultSortField == "" {
+				issues = append(issues, ValidationIssue{Stage: "config", Path: "config/ui.json", Field: "types." + typeName + ".defaultSortDir", Message: "defaultSortDir requires defaultSortField"})
+			}
+		default:
+			issues = append(issues, ValidationIssue{Stage: "config", Path: "config/ui.json", Field: "types." + typeName + ".defaultSortDir", Message: "defaultSortDir must be asc or desc"})
+		}
+		issues = append(issues, validatePresets(typeName, tc.Presets, schema)...)
+	}
+	return issues
+}
+
+func validatePresets(typeName string, presets []ObjectPreset, schema Schema) []ValidationIssue {
+	issues := make([]ValidationIssue, 0)
+	names := map[string]struct{}{}
+	for i, preset := range presets {
+		field := fmt.Sprintf("types.%s.presets[%d]", typeName, i)
+		name := strings.TrimSpace(preset.Name)
+		if name == "" {
+			issues = append(issues, ValidationIssue{Stage: "config", Path: "config/ui.json", Field: field, Message: "preset name is required"})
+			continue
+		}
+		if _, dup := names[name]; dup {
+			issues = append(issues, ValidationIssue{Stage: "config", Path: "config/ui.json", Field: field, Message: "duplicate preset name " + name})
+			continue
+		}
+		names[name] = struct{}{}
+		check := ValidationResult{}
+		for _, key := range sortedKeys(preset.Values) {
+			raw := preset.Values[key]
+			prop, ok := schema.Properties[key]
+			if !ok {
+				issues = append(issues, ValidationIssue{Stage: "config", Path: "config/ui.json", Field: field + ".values." + key, Message: "field " + key + " not in schema"})
+				continue
+			}
+			value, err := normalizeObjectValue(raw)
+			if err != nil {
+				issues = append(issues, ValidationIssue{Stage: "config", Path: "config/ui.json", Field: field + ".values." + key, Message: err.Error()})
+				continue
+			}
+			validateProperty(key, value, prop, "config/ui.json", &check)
+		}
+		for _, issue := range check.Issues {
+			issue.Stage = "config"
+			issue.Field = field + ".values." + issue.Field
+			issues = append(issues, issue)
+		}
 	}
 	return issues
 }
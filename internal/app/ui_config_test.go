@@ -0,0 +1,184 @@
+package app
+
+import (
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+const (
+	testTeamID    = "11111111-1111-4111-8111-111111111111"
+	testServiceID = "22222222-2222-4222-8222-222222222222"
+)
+
+func TestHiddenFieldIsNotRenderedButSurvivesSave(t *testing.T) {
+	repo := newTestRepository(t)
+	ws := newTestWorkspace(t, repo, "draft")
+	addTestSchemaField(t, ws, "service", "internal")
+	obj, err := repo.ReadObject(ws, "service", testServiceID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	obj.Data["internal"] = "keep-me"
+	if err := repo.WriteObject(ws, obj); err != nil {
+		t.Fatal(err)
+	}
+	editTestJSON(t, filepath.Join(ws, "config", "ui.json"), func(doc map[string]any) {
+		types := doc["types"].(map[string]any)
+		tc, _ := types["service"].(map[string]any)
+		if tc == nil {
+			tc = map[string]any{"displayField": "name"}
+		}
+		tc["hiddenFields"] = []any{"internal"}
+		types["service"] = tc
+	})
+	h := newTestHandler(t, repo)
+
+	page := string(getTestPage(t, h, "/w/draft/types/service/objects/"+testServiceID))
+	if strings.Contains(page, `name="field.internal"`) {
+		t.Error("object form renders the hidden field")
+	}
+	if !strings.Contains(page, `name="field.name"`) {
+		t.Error("object form does not render the visible name field")
+	}
+
+	rec := postTestForm(t, h, "/w/draft/types/service/objects/write", url.Values{
+		"id":           {testServiceID},
+		"field.name":   {"edge-gateway-2"},
+		"field.teamId": {testTeamID},
+		"field.tier":   {"edge"},
+	})
+	if rec.Code != http.StatusSeeOther && rec.Code != http.StatusFound {
+		t.Fatalf("write: status %d\n%s", rec.Code, rec.Body.String())
+	}
+	saved, err := repo.ReadObject(ws, "service", testServiceID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if saved.Data["name"] != "edge-gateway-2" {
+		t.Errorf("name = %v, want the submitted value", saved.Data["name"])
+	}
+	if saved.Data["internal"] != "keep-me" {
+		t.Errorf("internal = %v, want the hidden value preserved", saved.Data["internal"])
+	}
+}
+
+func TestTypeColorPersistsAndLoads(t *testing.T) {
+	repo := newTestRepository(t)
+	schemas, err := LoadSchemas(repo.Root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := LoadUIConfig(repo.Root, schemas)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tc := cfg.Types["team"]
+	tc.Color = " #3366ff "
+	tc.Icon = "T"
+	cfg.Types["team"] = tc
+	if err := repo.SaveUIConfig(repo.Root, cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := LoadUIConfig(repo.Root, schemas)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := loaded.Types["team"]; got.Color != "#3366ff" || got.Icon != "T" {
+		t.Errorf("team = color %q icon %q, want #3366ff and T", got.Color, got.Icon)
+	}
+	if issues := ValidateUIConfig(loaded, schemas); len(issues) != 0 {
+		t.Errorf("issues = %v, want none", issues)
+	}
+	if !strings.Contains(string(getTestPage(t, newTestHandler(t, repo), "/w/main/types")), "#3366ff") {
+		t.Error("types home does not use the saved color")
+	}
+
+	tc.Color = "blue"
+	loaded.Types["team"] = tc
+	if issues := ValidateUIConfig(loaded, schemas); len(issues) != 1 || issues[0].Field != "types.team.color" {
+		t.Errorf("issues = %v, want the invalid color reported", issues)
+	}
+}
+
+func TestNewObjectFormPrefillsPreset(t *testing.T) {
+	repo := newTestRepository(t)
+	ws := newTestWorkspace(t, repo, "draft")
+	editTestJSON(t, filepath.Join(ws, "config", "ui.json"), func(doc map[string]any) {
+		doc["types"].(map[string]any)["service"] = map[string]any{
+			"displayField": "name",
+			"presets": []any{
+				map[string]any{"name": "batch service", "values": map[string]any{"tier": "batch", "ports": []any{9000, 9001}}},
+			},
+		}
+	})
+	h := newTestHandler(t, repo)
+
+	page := string(getTestPage(t, h, "/w/draft/types/service/new?preset="+url.QueryEscape("batch service")))
+	for _, want := range []string{`<option value="batch" selected`, `value="9000,9001"`} {
+		if !strings.Contains(page, want) {
+			t.Errorf("new form does not contain %s", want)
+		}
+	}
+	if plain := string(getTestPage(t, h, "/w/draft/types/service/new")); strings.Contains(plain, `value="9000,9001"`) {
+		t.Error("new form without a preset is prefilled")
+	}
+}
+
+func TestDefaultSortPersistsAndAppliesWithoutQuery(t *testing.T) {
+	repo := newTestRepository(t)
+	ws := newTestWorkspace(t, repo, "draft")
+	alpha, zulu := testObjectID(3), testObjectID(4)
+	for id, name := range map[string]string{alpha: "Alpha", zulu: "Zulu"} {
+		writeTestFile(t, filepath.Join(ws, "data", "team", id+".yaml"),
+			"_id: "+id+"\n_type: team\ncode: "+strings.ToUpper(name)+"\nname: "+name+"\n")
+	}
+	h := newTestHandler(t, repo)
+
+	rec := postTestForm(t, h, "/w/draft/config/types/team", url.Values{
+		"displayField":     {"name"},
+		"extraField":       {"code"},
+		"defaultSortField": {"code"},
+		"defaultSortDir":   {"desc"},
+	})
+	if loc := rec.Header().Get("Location"); rec.Code != http.StatusSeeOther || strings.Contains(loc, "error=1") {
+		t.Fatalf("save config: status %d, location %q", rec.Code, loc)
+	}
+	schemas, err := LoadSchemas(ws)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := LoadUIConfig(ws, schemas)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tc := cfg.Types["team"]; tc.DefaultSortField != "code" || tc.DefaultSortDir != "desc" {
+		t.Errorf("team config = %+v, want the default sort persisted", tc)
+	}
+
+	order := func(path string) []string {
+		t.Helper()
+		page := string(getTestPage(t, h, path))
+		ids := []string{alpha, testTeamID, zulu}
+		sort.Slice(ids, func(i, j int) bool {
+			return strings.Index(page, "/objects/"+ids[i]) < strings.Index(page, "/objects/"+ids[j])
+		})
+		return ids
+	}
+	if got, want := order("/w/draft/types/team"), []string{zulu, testTeamID, alpha}; !reflect.DeepEqual(got, want) {
+		t.Errorf("default order = %v, want code descending %v", got, want)
+	}
+	if got, want := order("/w/draft/types/team?sort=code&dir=asc"), []string{alpha, testTeamID, zulu}; !reflect.DeepEqual(got, want) {
+		t.Errorf("explicit order = %v, want code ascending %v", got, want)
+	}
+
+	rec = postTestForm(t, h, "/w/draft/config/types/team", url.Values{"defaultSortField": {"budget"}})
+	if !strings.Contains(rec.Header().Get("Location"), "error=1") {
+		t.Error("a default sort on an unknown field was accepted")
+	}
+}
@@ -2,27 +2,55 @@ package app
 
 import (
 	"fmt"
+	"math"
 	"os"
 	"path/filepath"
+	"slices"
 	"sort"
 	"strings"
 )
 
-func ValidateRepository(root string) (ValidationResult, error) {
-	result := ValidationResult{}
+// ValidateOptions tunes ValidateRepositoryWithOptions.
+type ValidateOptions struct {
+	// ExitOnFirst stops the walk at the first issue, which is then the only
+	// one reported.
+	ExitOnFirst bool
+	// YAML is how object files are parsed.
+	YAML YAMLOptions
+}
+
+func ValidateRepository(root string, yaml YAMLOptions) (ValidationResult, error) {
+	return ValidateRepositoryWithOptions(root, ValidateOptions{YAML: yaml})
+}
+
+func ValidateRepositoryWithOptions(root string, opts ValidateOptions) (ValidationResult, error) {
+	result := ValidationResult{exitOnFirst: opts.ExitOnFirst}
 
 	validateLayout(root, &result)
+	if result.stopped() {
+		return result, nil
+	}
 
 	schemas, err := LoadSchemas(root)
 	if err != nil {
 		result.Add(ValidationIssue{Stage: "schema", Message: err.Error()})
 		return result, nil
 	}
+	schemas, enumIssues := ResolveEnumRefs(root, schemas)
+	for _, issue := range enumIssues {
+		result.Add(issue)
+	}
+	if result.stopped() {
+		return result, nil
+	}
 	constraints, err := LoadConstraints(root)
 	if err != nil {
 		result.Add(ValidationIssue{Stage: "constraints", Path: "config/constraints.json", Message: err.Error()})
 		return result, nil
 	}
+	for _, issue := range ValidateConstraintFields(constraints, schemas) {
+		result.Add(issue)
+	}
 	uiConfig, err := LoadUIConfig(root, schemas)
 	if err != nil {
 		result.Add(ValidationIssue{Stage: "config", Path: "config/ui.json", Message: err.Error()})
@@ -31,30 +59,175 @@ func ValidateRepository(root string) (ValidationResult, error) {
 	for _, issue := range ValidateUIConfig(uiConfig, schemas) {
 		result.Add(issue)
 	}
+	// LoadOverrides ignores the file outside workspaces, so main never
+	// validates it.
+	if _, err := LoadOverrides(root); err != nil {
+		result.Add(ValidationIssue{Stage: "config", Path: overridesFile, Message: err.Error()})
+	}
+	if _, err := LoadSettings(root); err != nil {
+		result.Add(ValidationIssue{Stage: "config", Path: "config/settings.json", Message: err.Error()})
+	}
+	if result.stopped() {
+		return result, nil
+	}
 
-	objectsByType, parseIssues := loadObjectsWithIssues(root)
+	objectsByType, parseIssues := opts.YAML.loadObjectsWithIssues(root)
 	for _, issue := range parseIssues {
 		result.Add(issue)
 	}
 
-	for typeName, objects := range objectsByType {
+	for _, typeName := range sortedKeys(objectsByType) {
+		if result.stopped() {
+			return result, nil
+		}
 		schema, ok := schemas[typeName]
 		if !ok {
 			result.Add(ValidationIssue{Stage: "schema", Path: filepath.ToSlash(filepath.Join("data", typeName)), Message: "missing schema file config/schemas/" + typeName + ".schema.json"})
 			continue
 		}
-		for _, obj := range objects {
+		for i, obj := range objectsByType[typeName] {
 			validateObjectInvariants(obj, &result)
 			validateObjectSchema(obj, schema, &result)
+			if result.stopped() {
+				return result, nil
+			}
+			objectsByType[typeName][i].Data, _ = canonicalAliasData(obj.Data, schema)
+		}
+	}
+
+	validateConstraints(objectsByType, constraints, &result)
+	return result, nil
+}
+
+// ValidateScope validates one type, or one object when id is set, without
+// walking unrelated types. Constraint checks still see every object of the
+// type and of the types it references.
+func ValidateScope(root, typeName, id string, yaml YAMLOptions) (ValidationResult, error) {
+	result := ValidationResult{}
+	typeDir := filepath.Join(root, "data", typeName)
+	schemas, err := LoadSchemas(root)
+	if err != nil {
+		result.Add(ValidationIssue{Stage: "schema", Message: err.Error()})
+		return result, nil
+	}
+	schema, hasSchema := schemas[typeName]
+	if st, err := os.Stat(typeDir); err != nil || !st.IsDir() {
+		if !hasSchema {
+			return result, fmt.Errorf("unknown type %q", typeName)
+		}
+	}
+	targetPath := ""
+	if id != "" {
+		targetPath = filepath.ToSlash(filepath.Join("data", typeName, id+".yaml"))
+		if _, err := os.Stat(filepath.Join(root, filepath.FromSlash(targetPath))); err != nil {
+			return result, fmt.Errorf("object %s/%s not found", typeName, id)
+		}
+	}
+	inScope := func(path string) bool {
+		if targetPath != "" {
+			return path == targetPath
+		}
+		return strings.HasPrefix(path, "data/"+typeName+"/")
+	}
+
+	if !hasSchema {
+		result.Add(ValidationIssue{Stage: "schema", Path: filepath.ToSlash(filepath.Join("data", typeName)), Message: "missing schema file config/schemas/" + typeName + ".schema.json"})
+		return result, nil
+	}
+	resolved, enumIssues := ResolveEnumRefs(root, map[string]Schema{typeName: schema})
+	for _, issue := range enumIssues {
+		result.Add(issue)
+	}
+	schema = resolved[typeName]
+	constraints, err := LoadConstraints(root)
+	if err != nil {
+		result.Add(ValidationIssue{Stage: "constraints", Path: "config/constraints.json", Message: err.Error()})
+		return result, nil
+	}
+
+	related := []string{typeName}
+	for _, fk := range constraints.ForeignKeys {
+		if fk.FromType == typeName && !contains(related, fk.ToType) {
+			related = append(related, fk.ToType)
+		}
+	}
+	objectsByType := make(map[string][]Object, len(related))
+	for _, t := range related {
+		objects, issues := yaml.loadTypeObjectsWithIssues(root, t)
+		for _, issue := range issues {
+			if inScope(issue.Path) {
+				result.Add(issue)
+			}
+		}
+		for i := range objects {
+			objects[i].Data, _ = canonicalAliasData(objects[i].Data, schemas[t])
 		}
+		objectsByType[t] = objects
 	}
-	for schemaType := range schemas {
-		if _, ok := objectsByType[schemaType]; !ok {
+
+	for _, obj := range objectsByType[typeName] {
+		if !inScope(obj.Path) {
 			continue
 		}
+		validateObjectInvariants(obj, &result)
+		validateObjectSchema(obj, schema, &result)
 	}
 
-	validateConstraints(objectsByType, constraints, &result)
+	scoped := ValidationResult{}
+	validateConstraints(objectsByType, constraints, &scoped)
+	for _, issue := range scoped.Issues {
+		if inScope(issue.Path) || (targetPath != "" && contains(issue.Related, targetPath)) {
+			result.Add(issue)
+		}
+	}
+	return result, nil
+}
+
+func ValidateObjectFile(root, path string, yaml YAMLOptions) (ValidationResult, error) {
+	result := ValidationResult{}
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return result, err
+	}
+	absRoot, err := filepath.Abs(root)
+	if err != nil {
+		return result, err
+	}
+	// The type is inferred from the path, so it must lie under this
+	// repository's data/ directory rather than any directory named data.
+	display := filepath.ToSlash(path)
+	rel, err := filepath.Rel(absRoot, abs)
+	parts := strings.Split(filepath.ToSlash(rel), "/")
+	if err != nil || len(parts) != 3 || parts[0] != "data" || !strings.HasSuffix(parts[2], ".yaml") {
+		result.Add(ValidationIssue{Stage: "layout", Path: display, Message: "object file must be data/<type>/<uuid>.yaml in the repository"})
+		return result, nil
+	}
+	display = filepath.ToSlash(rel)
+	typeName := parts[1]
+	id := strings.TrimSuffix(parts[2], ".yaml")
+
+	schemas, err := LoadSchemas(root)
+	if err != nil {
+		result.Add(ValidationIssue{Stage: "schema", Message: err.Error()})
+		return result, nil
+	}
+	schemas, enumIssues := ResolveEnumRefs(root, schemas)
+	for _, issue := range enumIssues {
+		result.Add(issue)
+	}
+	schema, ok := schemas[typeName]
+	if !ok {
+		result.Add(ValidationIssue{Stage: "schema", Path: display, Message: "missing schema file config/schemas/" + typeName + ".schema.json"})
+		return result, nil
+	}
+	obj, err := yaml.ParseObjectFile(abs, typeName, id)
+	if err != nil {
+		result.Add(ValidationIssue{Stage: "parse", Path: display, Message: err.Error()})
+		return result, nil
+	}
+	obj.Path = display
+	validateObjectInvariants(obj, &result)
+	validateObjectSchema(obj, schema, &result)
 	return result, nil
 }
 
@@ -68,6 +241,10 @@ func validateLayout(root string, result *ValidationResult) {
 			typePath := filepath.Join(dataDir, typeEntry.Name())
 			rel, _ := filepath.Rel(root, typePath)
 			rel = filepath.ToSlash(rel)
+			if isSymlink(typeEntry) {
+				result.Add(ValidationIssue{Stage: "layout", Path: rel, Message: "symlinks are not allowed under data/"})
+				continue
+			}
 			if !typeEntry.IsDir() {
 				result.Add(ValidationIssue{Stage: "layout", Path: rel, Message: "only type directories are allowed directly under data/"})
 				continue
@@ -77,6 +254,10 @@ func validateLayout(root string, result *ValidationResult) {
 				fp := filepath.Join(typePath, f.Name())
 				relFile, _ := filepath.Rel(root, fp)
 				relFile = filepath.ToSlash(relFile)
+				if isSymlink(f) {
+					result.Add(ValidationIssue{Stage: "layout", Path: relFile, Message: "symlinks are not allowed under data/"})
+					continue
+				}
 				if f.IsDir() {
 					result.Add(ValidationIssue{Stage: "layout", Path: relFile, Message: "nested directories under data/<type>/ are not allowed"})
 					continue
@@ -100,10 +281,17 @@ func validateLayout(root string, result *ValidationResult) {
 		entries, _ := os.ReadDir(configDir)
 		for _, entry := range entries {
 			switch {
+			case isSymlink(entry):
+				p := filepath.ToSlash(filepath.Join("config", entry.Name()))
+				result.Add(ValidationIssue{Stage: "layout", Path: p, Message: "symlinks are not allowed under config/"})
 			case entry.IsDir() && entry.Name() == "schemas":
 				validateSchemaLayout(root, result)
+			case entry.IsDir() && entry.Name() == "enums":
+				validateEnumLayout(root, result)
 			case !entry.IsDir() && entry.Name() == "constraints.json":
 			case !entry.IsDir() && entry.Name() == "ui.json":
+			case !entry.IsDir() && entry.Name() == "overrides.json":
+			case !entry.IsDir() && entry.Name() == "settings.json":
 			default:
 				p := filepath.ToSlash(filepath.Join("config", entry.Name()))
 				result.Add(ValidationIssue{Stage: "layout", Path: p, Message: "file is not allowed under config/"})
@@ -121,6 +309,14 @@ func validateSchemaLayout(root string, result *ValidationResult) {
 	}
 	for _, entry := range entries {
 		p := filepath.ToSlash(filepath.Join("config", "schemas", entry.Name()))
+		if isSymlink(entry) {
+			result.Add(ValidationIssue{Stage: "layout", Path: p, Message: "symlinks are not allowed under config/"})
+			continue
+		}
+		if entry.IsDir() && entry.Name() == schemaDefsDir {
+			validateSchemaDefsLayout(root, result)
+			continue
+		}
 		if entry.IsDir() {
 			result.Add(ValidationIssue{Stage: "layout", Path: p, Message: "nested directories are not allowed in config/schemas"})
 			continue
@@ -131,7 +327,43 @@ func validateSchemaLayout(root string, result *ValidationResult) {
 	}
 }
 
-func loadObjectsWithIssues(root string) (map[string][]Object, []ValidationIssue) {
+func validateSchemaDefsLayout(root string, result *ValidationResult) {
+	entries, err := os.ReadDir(filepath.Join(root, "config", "schemas", schemaDefsDir))
+	if err != nil {
+		result.Add(ValidationIssue{Stage: "layout", Path: "config/schemas/" + schemaDefsDir, Message: "cannot read definitions directory"})
+		return
+	}
+	for _, entry := range entries {
+		p := filepath.ToSlash(filepath.Join("config", "schemas", schemaDefsDir, entry.Name()))
+		if isSymlink(entry) {
+			result.Add(ValidationIssue{Stage: "layout", Path: p, Message: "symlinks are not allowed under config/"})
+			continue
+		}
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".json") {
+			result.Add(ValidationIssue{Stage: "layout", Path: p, Message: "only .json files are allowed in config/schemas/" + schemaDefsDir})
+		}
+	}
+}
+
+func validateEnumLayout(root string, result *ValidationResult) {
+	entries, err := os.ReadDir(filepath.Join(root, "config", "enums"))
+	if err != nil {
+		result.Add(ValidationIssue{Stage: "layout", Path: "config/enums", Message: "cannot read enums directory"})
+		return
+	}
+	for _, entry := range entries {
+		p := filepath.ToSlash(filepath.Join("config", "enums", entry.Name()))
+		if isSymlink(entry) {
+			result.Add(ValidationIssue{Stage: "layout", Path: p, Message: "symlinks are not allowed under config/"})
+			continue
+		}
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".json") {
+			result.Add(ValidationIssue{Stage: "layout", Path: p, Message: "only .json files are allowed in config/enums"})
+		}
+	}
+}
+
+func (y YAMLOptions) loadObjectsWithIssues(root string) (map[string][]Object, []ValidationIssue) {
 	issues := make([]ValidationIssue, 0)
 	objects := make(map[string][]Object)
 
@@ -147,32 +379,34 @@ func loadObjectsWithIssues(root string) (map[string][]Object, []ValidationIssue)
 			continue
 		}
 		typeName := typeEntry.Name()
-		typeDir := filepath.Join(dataDir, typeName)
-		files, err := os.ReadDir(typeDir)
-		if err != nil {
-			issues = append(issues, ValidationIssue{Stage: "parse", Path: filepath.ToSlash(filepath.Join("data", typeName)), Message: err.Error()})
-			continue
+		typeObjects, typeIssues := y.loadTypeObjectsWithIssues(root, typeName)
+		issues = append(issues, typeIssues...)
+		if typeObjects != nil {
+			objects[typeName] = typeObjects
 		}
-		for _, file := range files {
-			if file.IsDir() || !strings.HasSuffix(file.Name(), ".yaml") {
-				continue
-			}
-			id := strings.TrimSuffix(file.Name(), ".yaml")
-			path := filepath.Join(typeDir, file.Name())
-			obj, err := ParseObjectFile(path, typeName, id)
-			rel, _ := filepath.Rel(root, path)
-			rel = filepath.ToSlash(rel)
-			if err != nil {
-				issues = append(issues, ValidationIssue{Stage: "parse", Path: rel, Message: err.Error()})
-				continue
-			}
-			obj.Path = rel
-			objects[typeName] = append(objects[typeName], obj)
+	}
+	return objects, issues
+}
+
+func (y YAMLOptions) loadTypeObjectsWithIssues(root, typeName string) ([]Object, []ValidationIssue) {
+	issues := make([]ValidationIssue, 0)
+	typeDir := filepath.Join(root, "data", typeName)
+	files, err := os.ReadDir(typeDir)
+	if err != nil {
+		issues = append(issues, ValidationIssue{Stage: "parse", Path: filepath.ToSlash(filepath.Join("data", typeName)), Message: err.Error()})
+		return nil, issues
+	}
+	var objects []Object
+	for _, parsed := range y.parseObjectFiles(root, typeName, files) {
+		if parsed.err != nil {
+			issues = append(issues, ValidationIssue{Stage: "parse", Path: parsed.rel, Message: parsed.err.Error()})
+			continue
 		}
-		sort.Slice(objects[typeName], func(i, j int) bool {
-			return objects[typeName][i].ID < objects[typeName][j].ID
-		})
+		objects = append(objects, parsed.obj)
 	}
+	sort.Slice(objects, func(i, j int) bool {
+		return objects[i].ID < objects[j].ID
+	})
 	return objects, issues
 }
 
@@ -183,37 +417,78 @@ func validateObjectInvariants(obj Object, result *ValidationResult) {
 	if obj.Type == "" {
 		result.Add(ValidationIssue{Stage: "parse", Path: obj.Path, Field: "_type", Message: "must be non-empty"})
 	}
-	for field, v := range obj.Data {
-		switch t := v.(type) {
-		case map[string]any:
-			_ = t
-			result.Add(ValidationIssue{Stage: "parse", Path: obj.Path, Field: field, Message: "nested objects are not supported in v1"})
-		case []any:
-			for _, item := range t {
-				switch item.(type) {
-				case string, float64:
-				default:
-					result.Add(ValidationIssue{Stage: "parse", Path: obj.Path, Field: field, Message: "arrays may contain only strings or numbers"})
-				}
+	for _, field := range sortedKeys(obj.Data) {
+		validateValueShape(obj.Path, field, obj.Data[field], false, result)
+	}
+}
+
+func validateValueShape(path, field string, v any, nested bool, result *ValidationResult) {
+	switch t := v.(type) {
+	case map[string]any:
+		if nested {
+			result.Add(ValidationIssue{Stage: "parse", Path: path, Field: field, Message: "nested objects deeper than one level are not supported"})
+			return
+		}
+		for _, k := range sortedKeys(t) {
+			validateValueShape(path, field+"."+k, t[k], true, result)
+		}
+	case []any:
+		for _, item := range t {
+			switch item.(type) {
+			case string, float64:
+			default:
+				result.Add(ValidationIssue{Stage: "parse", Path: path, Field: field, Message: "arrays may contain only strings or numbers"})
 			}
 		}
 	}
 }
 
 func validateObjectSchema(obj Object, schema Schema, result *ValidationResult) {
-	for req := range schema.Required {
-		v, ok := obj.Data[req]
+	data, clashes := canonicalAliasData(obj.Data, schema)
+	for _, alias := range clashes {
+		result.Add(ValidationIssue{Stage: "schema", Path: obj.Path, Field: alias, Message: fmt.Sprintf("alias of %s, which is also set", schema.Aliases[alias])})
+	}
+	for _, req := range sortedKeys(schema.Required) {
+		v, ok := data[req]
 		if !ok || v == nil {
 			result.Add(ValidationIssue{Stage: "schema", Path: obj.Path, Field: req, Message: "required field is missing"})
 		}
 	}
 
-	for field, value := range obj.Data {
+	for _, group := range schema.OneOfRequired {
+		present := 0
+		for _, field := range group {
+			if v, ok := data[field]; ok && v != nil {
+				present++
+			}
+		}
+		if present != 1 {
+			result.Add(ValidationIssue{Stage: "schema", Path: obj.Path, Field: strings.Join(group, ","), Message: fmt.Sprintf("exactly one of %s must be set (found %d)", strings.Join(group, ", "), present)})
+		}
+	}
+
+	for _, group := range schema.MutuallyExclusive {
+		set := make([]string, 0, len(group))
+		for _, field := range group {
+			if v, ok := data[field]; ok && v != nil {
+				set = append(set, field)
+			}
+		}
+		if len(set) > 1 {
+			result.Add(ValidationIssue{Stage: "schema", Path: obj.Path, Field: strings.Join(group, ","), Message: fmt.Sprintf("at most one of %s may be set (found %s)", strings.Join(group, ", "), strings.Join(set, ", "))})
+		}
+	}
+
+	for _, field := range sortedKeys(data) {
+		value := data[field]
 		if field == "_id" || field == "_type" {
 			continue
 		}
 		prop, ok := schema.Properties[field]
 		if !ok {
+			if _, clash := schema.Aliases[field]; clash {
+				continue
+			}
 			result.Add(ValidationIssue{Stage: "schema", Path: obj.Path, Field: field, Message: "field is not defined in schema"})
 			continue
 		}
@@ -225,6 +500,10 @@ func validateProperty(field string, value any, prop SchemaProperty, path string,
 	if value == nil {
 		return
 	}
+	if _, isArray := value.([]any); isArray && prop.Type != "array" {
+		result.Add(ValidationIssue{Stage: "schema", Path: path, Field: field, Message: fmt.Sprintf("scalar field contains an array (expected %s)", prop.Type)})
+		return
+	}
 	switch prop.Type {
 	case "string":
 		s, ok := value.(string)
@@ -233,10 +512,18 @@ func validateProperty(field string, value any, prop SchemaProperty, path string,
 			return
 		}
 		if prop.MinLength != nil && len(s) < *prop.MinLength {
-			result.Add(ValidationIssue{Stage: "schema", Path: path, Field: field, Message: fmt.Sprintf("length must be >= %d", *prop.MinLength)})
+			result.Add(ValidationIssue{Stage: "schema", Path: path, Field: field, Message: fmt.Sprintf("length %d must be >= %d", len(s), *prop.MinLength)})
 		}
 		if prop.MaxLength != nil && len(s) > *prop.MaxLength {
-			result.Add(ValidationIssue{Stage: "schema", Path: path, Field: field, Message: fmt.Sprintf("length must be <= %d", *prop.MaxLength)})
+			result.Add(ValidationIssue{Stage: "schema", Path: path, Field: field, Message: fmt.Sprintf("length %d must be <= %d", len(s), *prop.MaxLength)})
+		}
+		if check := stringFormats[prop.Format]; check != nil && s != "" {
+			if err := check(s); err != nil {
+				result.Add(ValidationIssue{Stage: "schema", Path: path, Field: field, Message: fmt.Sprintf("value %q %s (format %s)", s, err.Error(), prop.Format)})
+			}
+		}
+		if prop.patternRe != nil && !prop.patternRe.MatchString(s) {
+			result.Add(ValidationIssue{Stage: "schema", Path: path, Field: field, Message: fmt.Sprintf("value does not match pattern %s", *prop.Pattern)})
 		}
 		if len(prop.Enum) > 0 {
 			matched := false
@@ -260,10 +547,22 @@ func validateProperty(field string, value any, prop SchemaProperty, path string,
 			result.Add(ValidationIssue{Stage: "schema", Path: path, Field: field, Message: "must be an integer"})
 		}
 		if prop.Minimum != nil && n < *prop.Minimum {
-			result.Add(ValidationIssue{Stage: "schema", Path: path, Field: field, Message: fmt.Sprintf("must be >= %g", *prop.Minimum)})
+			result.Add(ValidationIssue{Stage: "schema", Path: path, Field: field, Message: fmt.Sprintf("value %s must be >= %g", formatNumber(n), *prop.Minimum)})
 		}
 		if prop.Maximum != nil && n > *prop.Maximum {
-			result.Add(ValidationIssue{Stage: "schema", Path: path, Field: field, Message: fmt.Sprintf("must be <= %g", *prop.Maximum)})
+			result.Add(ValidationIssue{Stage: "schema", Path: path, Field: field, Message: fmt.Sprintf("value %s must be <= %g", formatNumber(n), *prop.Maximum)})
+		}
+		if prop.ExclusiveMinimum != nil && n <= *prop.ExclusiveMinimum {
+			result.Add(ValidationIssue{Stage: "schema", Path: path, Field: field, Message: fmt.Sprintf("value %s must be > %g", formatNumber(n), *prop.ExclusiveMinimum)})
+		}
+		if prop.ExclusiveMaximum != nil && n >= *prop.ExclusiveMaximum {
+			result.Add(ValidationIssue{Stage: "schema", Path: path, Field: field, Message: fmt.Sprintf("value %s must be < %g", formatNumber(n), *prop.ExclusiveMaximum)})
+		}
+		if prop.MultipleOf != nil && !isMultipleOf(n, *prop.MultipleOf) {
+			result.Add(ValidationIssue{Stage: "schema", Path: path, Field: field, Message: fmt.Sprintf("value %s must be a multiple of %g", formatNumber(n), *prop.MultipleOf)})
+		}
+		if len(prop.EnumNumbers) > 0 && !slices.Contains(prop.EnumNumbers, n) {
+			result.Add(ValidationIssue{Stage: "schema", Path: path, Field: field, Message: "value must be one of enum values"})
 		}
 	case "boolean":
 		if _, ok := value.(bool); !ok {
@@ -272,25 +571,60 @@ func validateProperty(field string, value any, prop SchemaProperty, path string,
 	case "array":
 		arr, ok := value.([]any)
 		if !ok {
-			result.Add(ValidationIssue{Stage: "schema", Path: path, Field: field, Message: "must be an array"})
+			result.Add(ValidationIssue{Stage: "schema", Path: path, Field: field, Message: fmt.Sprintf("array field contains a scalar value (expected array of %s)", prop.ItemsType)})
 			return
 		}
-		for _, item := range arr {
+		if prop.MinItems != nil && len(arr) < *prop.MinItems {
+			result.Add(ValidationIssue{Stage: "schema", Path: path, Field: field, Message: fmt.Sprintf("array has %d item(s), must have at least %d", len(arr), *prop.MinItems)})
+		}
+		if prop.MaxItems != nil && len(arr) > *prop.MaxItems {
+			result.Add(ValidationIssue{Stage: "schema", Path: path, Field: field, Message: fmt.Sprintf("array has %d item(s), must have at most %d", len(arr), *prop.MaxItems)})
+		}
+		seen := make(map[string]int, len(arr))
+		for i, item := range arr {
 			switch prop.ItemsType {
 			case "string":
 				if _, ok := item.(string); !ok {
-					result.Add(ValidationIssue{Stage: "schema", Path: path, Field: field, Message: "array items must be strings"})
+					result.Add(ValidationIssue{Stage: "schema", Path: path, Field: field, Message: fmt.Sprintf("array item %d must be a string", i)})
+					continue
 				}
 			case "number", "integer":
 				n, ok := item.(float64)
 				if !ok {
-					result.Add(ValidationIssue{Stage: "schema", Path: path, Field: field, Message: "array items must be numbers"})
+					result.Add(ValidationIssue{Stage: "schema", Path: path, Field: field, Message: fmt.Sprintf("array item %d must be a number", i)})
 					continue
 				}
 				if prop.ItemsType == "integer" && n != float64(int64(n)) {
-					result.Add(ValidationIssue{Stage: "schema", Path: path, Field: field, Message: "array items must be integers"})
+					result.Add(ValidationIssue{Stage: "schema", Path: path, Field: field, Message: fmt.Sprintf("array item %d must be an integer", i)})
 				}
 			}
+			if prop.UniqueItems {
+				key := constraintValueKey(item)
+				if first, dup := seen[key]; dup {
+					result.Add(ValidationIssue{Stage: "schema", Path: path, Field: field, Message: fmt.Sprintf("array item %d duplicates item %d (uniqueItems)", i, first)})
+					continue
+				}
+				seen[key] = i
+			}
+		}
+	case "object":
+		m, ok := value.(map[string]any)
+		if !ok {
+			result.Add(ValidationIssue{Stage: "schema", Path: path, Field: field, Message: "must be an object"})
+			return
+		}
+		for _, name := range sortedKeys(prop.Required) {
+			if v, ok := m[name]; !ok || v == nil {
+				result.Add(ValidationIssue{Stage: "schema", Path: path, Field: field + "." + name, Message: "required field is missing"})
+			}
+		}
+		for _, name := range sortedKeys(m) {
+			child, ok := prop.Properties[name]
+			if !ok {
+				result.Add(ValidationIssue{Stage: "schema", Path: path, Field: field + "." + name, Message: "field is not defined in schema"})
+				continue
+			}
+			validateProperty(field+"."+name, m[name], child, path, result)
 		}
 	}
 }
@@ -309,7 +643,7 @@ func validateConstraints(objects map[string][]Object, constraints Constraints, r
 				continue
 			}
 			if prev, ok := seen[key]; ok {
-				result.Add(ValidationIssue{Stage: "constraints", Path: obj.Path, Field: c.Field, Message: fmt.Sprintf("duplicate value also used by %s", prev)})
+				result.Add(ValidationIssue{Stage: "constraints", Path: obj.Path, Field: c.Field, Message: fmt.Sprintf("duplicate value also used by %s", prev), Related: []string{prev}})
 			} else {
 				seen[key] = obj.Path
 			}
@@ -345,6 +679,14 @@ func validateConstraints(objects map[string][]Object, constraints Constraints, r
 	}
 }
 
+// multipleOfEpsilon absorbs float rounding, so 0.3 counts as a multiple of 0.1.
+const multipleOfEpsilon = 1e-9
+
+func isMultipleOf(n, step float64) bool {
+	r := math.Abs(math.Mod(n, step))
+	return r < multipleOfEpsilon || step-r < multipleOfEpsilon
+}
+
 func constraintValueKey(v any) string {
 	switch t := v.(type) {
 	case string:
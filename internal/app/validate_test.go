@@ -0,0 +1,749 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestValidateScopeReportsDuplicateToEveryHolder(t *testing.T) {
+	repo := newTestRepository(t)
+	const (
+		first  = "11111111-1111-4111-8111-111111111111"
+		second = "33333333-3333-4333-8333-333333333333"
+	)
+	writeTestFile(t, filepath.Join(repo.Root, "data", "team", second+".yaml"),
+		"_id: "+second+"\n_type: team\ncode: PLAT\nname: Copy\n")
+	firstPath := "data/team/" + first + ".yaml"
+	secondPath := "data/team/" + second + ".yaml"
+
+	for _, id := range []string{first, second} {
+		result, err := ValidateScope(repo.Root, "team", id, repo.YAMLOptions)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(result.Issues) != 1 {
+			t.Fatalf("%s: issues = %v, want the duplicate code", id, result.Issues)
+		}
+		issue := result.Issues[0]
+		if issue.Stage != "constraints" || issue.Field != "code" || issue.Path != secondPath {
+			t.Errorf("%s: issue = %+v, want duplicate code on %s", id, issue, secondPath)
+		}
+		if len(issue.Related) != 1 || issue.Related[0] != firstPath {
+			t.Errorf("%s: related = %v, want [%s]", id, issue.Related, firstPath)
+		}
+	}
+
+	// The service referencing the first team is unaffected by the duplicate.
+	result, err := ValidateScope(repo.Root, "service", "22222222-2222-4222-8222-222222222222", repo.YAMLOptions)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Issues) != 0 {
+		t.Errorf("service issues = %v, want none", result.Issues)
+	}
+}
+
+func TestValidateObjectFileChecksOnlyRepositoryDataFiles(t *testing.T) {
+	repo := newTestRepository(t)
+	valid := filepath.Join(repo.Root, "data", "team", "11111111-1111-4111-8111-111111111111.yaml")
+	result, err := ValidateObjectFile(repo.Root, valid, repo.YAMLOptions)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.OK() {
+		t.Errorf("valid file issues = %v", result.Issues)
+	}
+
+	// A data/<type>/ layout outside the repository does not name a type.
+	outside := filepath.Join(filepath.Dir(repo.Root), "x", "data", "team", "33333333-3333-4333-8333-333333333333.yaml")
+	writeTestFile(t, outside, "_id: 33333333-3333-4333-8333-333333333333\n_type: team\ncode: X\nname: X\n")
+	invalid := []string{
+		outside,
+		filepath.Join(repo.Root, "data", "..", "..", "x", "data", "team", "33333333-3333-4333-8333-333333333333.yaml"),
+		filepath.Join(repo.Root, "config", "schemas", "team.schema.json"),
+		filepath.Join(repo.Root, "data", "team", "nested", "33333333-3333-4333-8333-333333333333.yaml"),
+	}
+	for _, path := range invalid {
+		result, err := ValidateObjectFile(repo.Root, path, repo.YAMLOptions)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(result.Issues) != 1 || result.Issues[0].Stage != "layout" {
+			t.Errorf("%s: issues = %v, want one layout issue", path, result.Issues)
+		}
+	}
+}
+
+func TestValidateExitOnFirstReportsOneIssue(t *testing.T) {
+	repo := newTestRepository(t)
+	for _, id := range []string{"33333333-3333-4333-8333-333333333333", "44444444-4444-4444-8444-444444444444"} {
+		writeTestFile(t, filepath.Join(repo.Root, "data", "team", id+".yaml"), "_id: "+id+"\n_type: team\n")
+	}
+
+	all, err := ValidateRepository(repo.Root, repo.YAMLOptions)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all.Issues) < 2 {
+		t.Fatalf("issues = %v, want at least two", all.Issues)
+	}
+	first, err := ValidateRepositoryWithOptions(repo.Root, ValidateOptions{ExitOnFirst: true, YAML: repo.YAMLOptions})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(first.Issues) != 1 || first.Issues[0].String() != all.Issues[0].String() {
+		t.Errorf("exit-on-first issues = %v, want only %v", first.Issues, all.Issues[0])
+	}
+
+	// WORKTREEFOUNDRY_VALIDATE_EXIT_ON_FIRST sets the flag's default.
+	t.Setenv("WORKTREEFOUNDRY_VALIDATE_EXIT_ON_FIRST", "true")
+	err = Run(context.Background(), []string{"validate", "--repository", repo.Root}, "test")
+	if err == nil || !strings.Contains(err.Error(), "with 1 issue(s)") {
+		t.Errorf("validate with env: error = %v, want 1 issue", err)
+	}
+	err = Run(context.Background(), []string{"validate", "--repository", repo.Root, "--exit-on-first=false"}, "test")
+	if want := fmt.Sprintf("with %d issue(s)", len(all.Issues)); err == nil || !strings.Contains(err.Error(), want) {
+		t.Errorf("validate with --exit-on-first=false: error = %v, want %s", err, want)
+	}
+}
+
+func TestValidatePropertyNamesArrayScalarMismatches(t *testing.T) {
+	cases := []struct {
+		name  string
+		value any
+		prop  SchemaProperty
+		want  string
+	}{
+		{"scalar in array field", "443", SchemaProperty{Type: "array", ItemsType: "integer"}, "array field contains a scalar value (expected array of integer)"},
+		{"array in string field", []any{"a", "b"}, SchemaProperty{Type: "string"}, "scalar field contains an array (expected string)"},
+		{"array in integer field", []any{1.0}, SchemaProperty{Type: "integer"}, "scalar field contains an array (expected integer)"},
+		{"other type error", "yes", SchemaProperty{Type: "boolean"}, "must be a boolean"},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			var result ValidationResult
+			validateProperty("f", c.value, c.prop, "data/x.yaml", &result)
+			if len(result.Issues) != 1 || result.Issues[0].Message != c.want {
+				t.Errorf("issues = %v, want one %q", result.Issues, c.want)
+			}
+		})
+	}
+}
+
+func TestValidateRepositoryReportsSymlinkedDataFile(t *testing.T) {
+	repo := newTestRepository(t)
+	const id = "33333333-3333-4333-8333-333333333333"
+	outside := filepath.Join(t.TempDir(), "team.yaml")
+	writeTestFile(t, outside, "_id: "+id+"\n_type: team\ncode: EXT\nname: Outside\n")
+	link := filepath.Join(repo.Root, "data", "team", id+".yaml")
+	if err := os.Symlink(outside, link); err != nil {
+		t.Skipf("symlinks unavailable: %v", err)
+	}
+
+	result, err := ValidateRepository(repo.Root, repo.YAMLOptions)
+	if err != nil {
+		t.Fatal(err)
+	}
+	found := false
+	for _, issue := range result.Issues {
+		if issue.Stage == "layout" && issue.Path == "data/team/"+id+".yaml" && strings.Contains(issue.Message, "symlinks are not allowed") {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("issues = %v, want a layout issue for the symlink", result.Issues)
+	}
+
+	objects, err := repo.LoadObjects(repo.Root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, obj := range objects["team"] {
+		if obj.ID == id {
+			t.Error("LoadObjects followed the symlink into a file outside the repository")
+		}
+	}
+}
+
+func TestValidatePropertyRangeMessagesIncludeValue(t *testing.T) {
+	five, ten := 5.0, 10.0
+	two, four := 2, 4
+	cases := []struct {
+		name  string
+		value any
+		prop  SchemaProperty
+		want  string
+	}{
+		{"below minimum", 3.0, SchemaProperty{Type: "integer", Minimum: &five}, "value 3 must be >= 5"},
+		{"above maximum", 12.5, SchemaProperty{Type: "number", Maximum: &ten}, "value 12.5 must be <= 10"},
+		{"too short", "a", SchemaProperty{Type: "string", MinLength: &two}, "length 1 must be >= 2"},
+		{"too long", "abcdef", SchemaProperty{Type: "string", MaxLength: &four}, "length 6 must be <= 4"},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			var result ValidationResult
+			validateProperty("f", c.value, c.prop, "data/x.yaml", &result)
+			if len(result.Issues) != 1 || result.Issues[0].Message != c.want {
+				t.Errorf("issues = %v, want one %q", result.Issues, c.want)
+			}
+		})
+	}
+}
+
+func TestOneOfRequiredNeedsExactlyOneField(t *testing.T) {
+	repo := newTestRepository(t)
+	schemaPath := filepath.Join(repo.Root, "config", "schemas", "team.schema.json")
+	addTestSchemaField(t, repo.Root, "team", "email")
+	addTestSchemaField(t, repo.Root, "team", "slack")
+	editTestJSON(t, schemaPath, func(doc map[string]any) {
+		doc["oneOfRequired"] = []any{[]any{"email", "slack"}}
+	})
+	schemas, err := LoadSchemas(repo.Root)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		name string
+		set  map[string]any
+		want string
+	}{
+		{"none", map[string]any{"slack": nil}, "exactly one of email, slack must be set (found 0)"},
+		{"one", map[string]any{"email": "a@example.com"}, ""},
+		{"both", map[string]any{"email": "a@example.com", "slack": "#plat"}, "exactly one of email, slack must be set (found 2)"},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			data := map[string]any{"_id": testTeamID, "_type": "team", "name": "Platform", "code": "PLAT"}
+			for k, v := range c.set {
+				data[k] = v
+			}
+			var result ValidationResult
+			validateObjectSchema(Object{ID: testTeamID, Type: "team", Path: "data/team/x.yaml", Data: data}, schemas["team"], &result)
+			var got []string
+			for _, issue := range result.Issues {
+				got = append(got, issue.Message)
+			}
+			if c.want == "" && len(got) != 0 {
+				t.Errorf("issues = %v, want none", got)
+			}
+			if c.want != "" && (len(got) != 1 || got[0] != c.want || result.Issues[0].Field != "email,slack") {
+				t.Errorf("issues = %v, want one %q on email,slack", result.Issues, c.want)
+			}
+		})
+	}
+
+	editTestJSON(t, schemaPath, func(doc map[string]any) {
+		doc["oneOfRequired"] = []any{[]any{"email", "pager"}}
+	})
+	if _, err := LoadSchemas(repo.Root); err == nil || !strings.Contains(err.Error(), `unknown field "pager"`) {
+		t.Errorf("LoadSchemas error = %v, want the unknown field rejected", err)
+	}
+}
+
+func TestValidatePropertyArrayAggregates(t *testing.T) {
+	one, three := 1, 3
+	ports := SchemaProperty{Type: "array", ItemsType: "integer", MinItems: &one, MaxItems: &three, UniqueItems: true}
+	cases := []struct {
+		name  string
+		value []any
+		want  []string
+	}{
+		{"valid", []any{443.0, 8443.0}, nil},
+		{"too few", []any{}, []string{"array has 0 item(s), must have at least 1"}},
+		{"too many", []any{1.0, 2.0, 3.0, 4.0}, []string{"array has 4 item(s), must have at most 3"}},
+		{"duplicate", []any{443.0, 80.0, 443.0}, []string{"array item 2 duplicates item 0 (uniqueItems)"}},
+		{"bad element", []any{443.0, 1.5}, []string{"array item 1 must be an integer"}},
+		{"count and duplicate", []any{1.0, 1.0, 2.0, 3.0}, []string{
+			"array has 4 item(s), must have at most 3",
+			"array item 1 duplicates item 0 (uniqueItems)",
+		}},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			var result ValidationResult
+			validateProperty("ports", c.value, ports, "data/x.yaml", &result)
+			var got []string
+			for _, issue := range result.Issues {
+				got = append(got, issue.Message)
+			}
+			if fmt.Sprint(got) != fmt.Sprint(c.want) {
+				t.Errorf("issues = %q, want %q", got, c.want)
+			}
+		})
+	}
+}
+
+func TestValidateRepositoryReportsInvalidUTF8(t *testing.T) {
+	repo := newTestRepository(t)
+	id := testObjectID(3)
+	rel := "data/team/" + id + ".yaml"
+	content := "_id: " + id + "\n_type: team\ncode: BAD\nname: Caf\xe9\n"
+	writeTestFile(t, filepath.Join(repo.Root, filepath.FromSlash(rel)), content)
+
+	result, err := ValidateRepository(repo.Root, repo.YAMLOptions)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := fmt.Sprintf("file is not valid UTF-8 (invalid byte at offset %d)", strings.Index(content, "\xe9"))
+	found := false
+	for _, issue := range result.Issues {
+		if issue.Path == rel && issue.Message == want {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("issues = %v, want %q for %s", result.Issues, want, rel)
+	}
+
+	if _, err := repo.ParseObjectFile(filepath.Join(repo.Root, filepath.FromSlash(rel)), "team", id); err == nil || err.Error() != want {
+		t.Errorf("ParseObjectFile error = %v, want %q", err, want)
+	}
+}
+
+func TestMutuallyExclusiveFlagsGroupsWithSeveralFields(t *testing.T) {
+	repo := newTestRepository(t)
+	schemaPath := filepath.Join(repo.Root, "config", "schemas", "team.schema.json")
+	addTestSchemaField(t, repo.Root, "team", "legacyId")
+	addTestSchemaField(t, repo.Root, "team", "newId")
+	editTestJSON(t, schemaPath, func(doc map[string]any) {
+		doc["mutuallyExclusive"] = []any{[]any{"legacyId", "newId"}}
+	})
+	schemas, err := LoadSchemas(repo.Root)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		name string
+		set  map[string]any
+		want string
+	}{
+		{"neither", nil, ""},
+		{"one", map[string]any{"newId": "n-1"}, ""},
+		{"one and a null", map[string]any{"newId": "n-1", "legacyId": nil}, ""},
+		{"both", map[string]any{"legacyId": "l-1", "newId": "n-1"}, "at most one of legacyId, newId may be set (found legacyId, newId)"},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			data := map[string]any{"_id": testTeamID, "_type": "team", "name": "Platform", "code": "PLAT"}
+			for k, v := range c.set {
+				data[k] = v
+			}
+			var result ValidationResult
+			validateObjectSchema(Object{ID: testTeamID, Type: "team", Path: "data/team/x.yaml", Data: data}, schemas["team"], &result)
+			switch {
+			case c.want == "" && len(result.Issues) != 0:
+				t.Errorf("issues = %v, want none", result.Issues)
+			case c.want != "" && (len(result.Issues) != 1 || result.Issues[0].Message != c.want):
+				t.Errorf("issues = %v, want one %q", result.Issues, c.want)
+			}
+		})
+	}
+
+	editTestJSON(t, schemaPath, func(doc map[string]any) {
+		doc["mutuallyExclusive"] = []any{[]any{"legacyId", "oldId"}}
+	})
+	if _, err := LoadSchemas(repo.Root); err == nil || !strings.Contains(err.Error(), `"oldId"`) {
+		t.Errorf("LoadSchemas error = %v, want the unknown field rejected", err)
+	}
+}
+
+func TestValidatePropertyArrayItemRules(t *testing.T) {
+	one := 1
+	cases := []struct {
+		name  string
+		value []any
+		prop  SchemaProperty
+		want  []string
+	}{
+		{"empty against minItems 1", []any{}, SchemaProperty{Type: "array", ItemsType: "integer", MinItems: &one}, []string{"array has 0 item(s), must have at least 1"}},
+		{"443 and \"443\" are distinct", []any{"443", 443.0, true, "true"}, SchemaProperty{Type: "array", UniqueItems: true}, nil},
+		{"repeated number", []any{443.0, 443.0}, SchemaProperty{Type: "array", ItemsType: "integer", UniqueItems: true}, []string{"array item 1 duplicates item 0 (uniqueItems)"}},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			var result ValidationResult
+			validateProperty("ports", c.value, c.prop, "data/x.yaml", &result)
+			var got []string
+			for _, issue := range result.Issues {
+				got = append(got, issue.Message)
+			}
+			if fmt.Sprint(got) != fmt.Sprint(c.want) {
+				t.Errorf("issues = %q, want %q", got, c.want)
+			}
+		})
+	}
+}
+
+func TestLoadSchemasRejectsArrayKeywordsOnScalars(t *testing.T) {
+	repo := newTestRepository(t)
+	for _, keyword := range []string{"minItems", "maxItems", "uniqueItems"} {
+		editTestJSON(t, filepath.Join(repo.Root, "config", "schemas", "team.schema.json"), func(doc map[string]any) {
+			name := map[string]any{"type": "string"}
+			if keyword == "uniqueItems" {
+				name[keyword] = true
+			} else {
+				name[keyword] = 1
+			}
+			doc["properties"].(map[string]any)["name"] = name
+		})
+		if _, err := LoadSchemas(repo.Root); err == nil || !strings.Contains(err.Error(), keyword) {
+			t.Errorf("%s on a string: error = %v, want it rejected", keyword, err)
+		}
+	}
+}
+
+func TestValidatePropertyExclusiveBoundsAndMultipleOf(t *testing.T) {
+	zero, port, tenth, five := 0.0, 65536.0, 0.1, 5.0
+	ports := SchemaProperty{Type: "integer", ExclusiveMinimum: &zero, ExclusiveMaximum: &port}
+	cases := []struct {
+		name  string
+		value float64
+		prop  SchemaProperty
+		want  string
+	}{
+		{"at exclusive minimum", 0, ports, "value 0 must be > 0"},
+		{"just above exclusive minimum", 1, ports, ""},
+		{"just below exclusive maximum", 65535, ports, ""},
+		{"at exclusive maximum", 65536, ports, "value 65536 must be < 65536"},
+		{"rounded multiple", 0.3, SchemaProperty{Type: "number", MultipleOf: &tenth}, ""},
+		{"not a multiple", 0.35, SchemaProperty{Type: "number", MultipleOf: &tenth}, "value 0.35 must be a multiple of 0.1"},
+		{"negative multiple", -10, SchemaProperty{Type: "integer", MultipleOf: &five}, ""},
+		{"one past a multiple", 11, SchemaProperty{Type: "integer", MultipleOf: &five}, "value 11 must be a multiple of 5"},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			var result ValidationResult
+			validateProperty("f", c.value, c.prop, "data/x.yaml", &result)
+			if c.want == "" && len(result.Issues) != 0 {
+				t.Errorf("issues = %v, want none", result.Issues)
+			}
+			if c.want != "" && (len(result.Issues) != 1 || result.Issues[0].Message != c.want) {
+				t.Errorf("issues = %v, want one %q", result.Issues, c.want)
+			}
+		})
+	}
+}
+
+func TestLoadSchemasChecksNumericKeywords(t *testing.T) {
+	repo := newTestRepository(t)
+	for _, c := range []struct {
+		prop map[string]any
+		want string
+	}{
+		{map[string]any{"type": "integer", "multipleOf": 0}, "multipleOf must be > 0"},
+		{map[string]any{"type": "number", "multipleOf": -1.5}, "multipleOf must be > 0"},
+		{map[string]any{"type": "string", "exclusiveMinimum": 0}, "only valid for number/integer"},
+		{map[string]any{"type": "string", "multipleOf": 2}, "only valid for number/integer"},
+	} {
+		editTestJSON(t, filepath.Join(repo.Root, "config", "schemas", "team.schema.json"), func(doc map[string]any) {
+			doc["properties"].(map[string]any)["size"] = c.prop
+		})
+		if _, err := LoadSchemas(repo.Root); err == nil || !strings.Contains(err.Error(), c.want) {
+			t.Errorf("%v: error = %v, want %q", c.prop, err, c.want)
+		}
+	}
+}
+
+func TestValidateRepositoryPattern(t *testing.T) {
+	repo := newTestRepository(t)
+	schemaPath := filepath.Join(repo.Root, "config", "schemas", "team.schema.json")
+	teamPath := filepath.Join(repo.Root, "data", "team", testTeamID+".yaml")
+	setPattern := func(pattern string) {
+		editTestJSON(t, schemaPath, func(doc map[string]any) {
+			doc["properties"].(map[string]any)["code"].(map[string]any)["pattern"] = pattern
+		})
+	}
+	validate := func() ValidationResult {
+		t.Helper()
+		result, err := ValidateRepository(repo.Root, repo.YAMLOptions)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return result
+	}
+
+	setPattern("^[A-Z]{2,5}$")
+	if result := validate(); !result.OK() {
+		t.Errorf("matching code: issues %v, want none", result.Issues)
+	}
+
+	replaceTestFile(t, teamPath, "code: PLAT", "code: plat")
+	if result := validate(); len(result.Issues) != 1 || result.Issues[0].Field != "code" || result.Issues[0].Message != "value does not match pattern ^[A-Z]{2,5}$" {
+		t.Errorf("non-matching code: issues %v, want the pattern issue", result.Issues)
+	}
+
+	// A pattern that does not compile is a schema issue, not a panic.
+	setPattern("^[A-Z")
+	result := validate()
+	if len(result.Issues) != 1 || result.Issues[0].Stage != "schema" || !strings.Contains(result.Issues[0].Message, "field code: invalid pattern") {
+		t.Errorf("invalid pattern: issues %v, want one schema issue", result.Issues)
+	}
+}
+
+func TestValidatePropertyFormats(t *testing.T) {
+	cases := []struct {
+		format string
+		value  string
+		want   string
+	}{
+		{"email", "ops@example.com", ""},
+		{"email", "Ops <ops@example.com>", "must be an email address"},
+		{"email", "not-an-email", "must be an email address"},
+		{"date", "2026-02-28", ""},
+		{"date", "2026-02-30", "must be a date (YYYY-MM-DD)"},
+		{"date", "28/02/2026", "must be a date (YYYY-MM-DD)"},
+		{"date-time", "2026-02-28T09:30:00Z", ""},
+		{"date-time", "2026-02-28T09:30:00+02:00", ""},
+		{"date-time", "2026-02-28 09:30", "must be an RFC 3339 date-time"},
+		{"uri", "https://example.com/path", ""},
+		{"uri", "mailto:ops@example.com", ""},
+		{"uri", "example.com/path", "must be an absolute URI"},
+		{"uuid", testServiceID, ""},
+		{"uuid", "22222222-2222-2222-2222", "must be a UUID"},
+	}
+	for _, c := range cases {
+		t.Run(c.format+" "+c.value, func(t *testing.T) {
+			var result ValidationResult
+			validateProperty("f", c.value, SchemaProperty{Type: "string", Format: c.format}, "data/x.yaml", &result)
+			want := ""
+			if c.want != "" {
+				want = fmt.Sprintf("value %q %s (format %s)", c.value, c.want, c.format)
+			}
+			if want == "" && len(result.Issues) != 0 {
+				t.Errorf("issues = %v, want none", result.Issues)
+			}
+			if want != "" && (len(result.Issues) != 1 || result.Issues[0].Message != want) {
+				t.Errorf("issues = %v, want one %q", result.Issues, want)
+			}
+		})
+	}
+
+	// An empty string is left to required checks.
+	var result ValidationResult
+	validateProperty("f", "", SchemaProperty{Type: "string", Format: "email"}, "data/x.yaml", &result)
+	if len(result.Issues) != 0 {
+		t.Errorf("empty value: issues %v, want none", result.Issues)
+	}
+}
+
+func TestLoadSchemasRejectsUnknownFormat(t *testing.T) {
+	repo := newTestRepository(t)
+	editTestJSON(t, filepath.Join(repo.Root, "config", "schemas", "team.schema.json"), func(doc map[string]any) {
+		doc["properties"].(map[string]any)["code"].(map[string]any)["format"] = "ipv4"
+	})
+	if _, err := LoadSchemas(repo.Root); err == nil || !strings.Contains(err.Error(), `unsupported format "ipv4"`) {
+		t.Errorf("error = %v, want the unknown format rejected", err)
+	}
+}
+
+// testSchemaProperty normalizes the JSON schema property raw of field.
+func testSchemaProperty(t *testing.T, field, raw string) SchemaProperty {
+	t.Helper()
+	var p rawSchemaProp
+	if err := json.Unmarshal([]byte(raw), &p); err != nil {
+		t.Fatal(err)
+	}
+	prop, err := normalizeProperty(field, p, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return prop
+}
+
+func TestValidatePropertyNestedObject(t *testing.T) {
+	prop := testSchemaProperty(t, "endpoint", `{
+  "type": "object",
+  "required": ["host"],
+  "properties": {
+    "host": {"type": "string", "minLength": 3},
+    "port": {"type": "integer", "minimum": 1},
+    "tags": {"type": "array", "items": {"type": "string"}}
+  }
+}`)
+	cases := []struct {
+		name  string
+		value any
+		want  []string
+	}{
+		{"valid", map[string]any{"host": "edge.local", "port": 443.0, "tags": []any{"a"}}, nil},
+		{"only required child", map[string]any{"host": "edge.local"}, nil},
+		{"scalar instead of object", "edge.local", []string{"endpoint: must be an object"}},
+		{"array instead of object", []any{"edge.local"}, []string{"endpoint: scalar field contains an array (expected object)"}},
+		{"missing required child", map[string]any{"port": 443.0}, []string{"endpoint.host: required field is missing"}},
+		{"unknown child", map[string]any{"host": "edge.local", "scheme": "https"}, []string{"endpoint.scheme: field is not defined in schema"}},
+		{"child rules", map[string]any{"host": "ab", "port": 0.0, "tags": "a"}, []string{
+			"endpoint.host: length 2 must be >= 3",
+			"endpoint.port: value 0 must be >= 1",
+			"endpoint.tags: array field contains a scalar value (expected array of string)",
+		}},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			var result ValidationResult
+			validateProperty("endpoint", c.value, prop, "data/service/x.yaml", &result)
+			got := make([]string, 0, len(result.Issues))
+			for _, issue := range result.Issues {
+				got = append(got, issue.Field+": "+issue.Message)
+			}
+			if strings.Join(got, "\n") != strings.Join(c.want, "\n") {
+				t.Errorf("issues =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(c.want, "\n"))
+			}
+		})
+	}
+}
+
+func TestNormalizeObjectPropertyErrors(t *testing.T) {
+	cases := []struct {
+		raw  string
+		want string
+	}{
+		{`{"type": "object"}`, "object requires properties"},
+		{`{"type": "object", "properties": {"a": {"type": "object", "properties": {"b": {"type": "string"}}}}}`, "nested objects deeper than one level are not supported"},
+		{`{"type": "object", "required": ["b"], "properties": {"a": {"type": "string"}}}`, `required field "b" is not defined in properties`},
+		{`{"type": "object", "properties": {"a.b": {"type": "string"}}}`, `invalid property name "a.b"`},
+		{`{"type": "string", "properties": {"a": {"type": "string"}}}`, "properties/required only valid for object"},
+	}
+	for _, c := range cases {
+		var p rawSchemaProp
+		if err := json.Unmarshal([]byte(c.raw), &p); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := normalizeProperty("endpoint", p, false); err == nil || !strings.Contains(err.Error(), c.want) {
+			t.Errorf("%s: error = %v, want %q", c.raw, err, c.want)
+		}
+	}
+}
+
+func TestValidatePropertyEnumNumbers(t *testing.T) {
+	integer := testSchemaProperty(t, "replicas", `{"type": "integer", "enum": [3, 1, 2]}`)
+	number := testSchemaProperty(t, "ratio", `{"type": "number", "enum": [0.5, 1]}`)
+	if !reflect.DeepEqual(integer.EnumNumbers, []float64{1, 2, 3}) {
+		t.Errorf("integer EnumNumbers = %v, want sorted [1 2 3]", integer.EnumNumbers)
+	}
+	cases := []struct {
+		name  string
+		prop  SchemaProperty
+		value any
+		want  []string
+	}{
+		{"integer match", integer, 2.0, nil},
+		{"integer mismatch", integer, 4.0, []string{"value must be one of enum values"}},
+		{"fraction of an integer enum", integer, 2.5, []string{"must be an integer", "value must be one of enum values"}},
+		{"number match", number, 0.5, nil},
+		{"whole number matches a float enum", number, 1.0, nil},
+		{"number mismatch", number, 0.25, []string{"value must be one of enum values"}},
+		{"string for a numeric enum", number, "1", []string{"must be a number"}},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			var result ValidationResult
+			validateProperty(c.prop.Type, c.value, c.prop, "data/service/x.yaml", &result)
+			var got []string
+			for _, issue := range result.Issues {
+				got = append(got, issue.Message)
+			}
+			if !reflect.DeepEqual(got, c.want) {
+				t.Errorf("issues = %v, want %v", got, c.want)
+			}
+		})
+	}
+
+	for _, raw := range []string{`{"type": "integer", "enum": [1, 1.5]}`, `{"type": "number", "enum": [1, "2"]}`} {
+		var p rawSchemaProp
+		if err := json.Unmarshal([]byte(raw), &p); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := normalizeProperty("n", p, false); err == nil || !strings.Contains(err.Error(), "does not match type") {
+			t.Errorf("%s: error = %v, want a type mismatch", raw, err)
+		}
+	}
+}
+
+func TestValidateRepositoryEnumNumbersFromYAML(t *testing.T) {
+	repo := newTestRepository(t)
+	editTestJSON(t, filepath.Join(repo.Root, "config", "schemas", "service.schema.json"), func(doc map[string]any) {
+		doc["properties"].(map[string]any)["replicas"] = map[string]any{"type": "integer", "enum": []any{1, 2, 3}}
+	})
+	servicePath := filepath.Join(repo.Root, "data", "service", testServiceID+".yaml")
+	for raw, wantIssues := range map[string]int{"2": 0, "2.0": 0, "5": 1} {
+		writeTestFile(t, servicePath, "_id: "+testServiceID+"\n_type: service\nname: edge-gateway\nreplicas: "+raw+"\nteamId: "+testTeamID+"\ntier: edge\n")
+		result, err := ValidateRepository(repo.Root, repo.YAMLOptions)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(result.Issues) != wantIssues {
+			t.Errorf("replicas: %s: issues %v, want %d", raw, result.Issues, wantIssues)
+		}
+	}
+}
+
+func TestLoadSchemasResolvesDefsRefs(t *testing.T) {
+	repo := newTestRepository(t)
+	defs := filepath.Join(repo.Root, "config", "schemas", schemaDefsDir)
+	writeTestFile(t, filepath.Join(defs, "code.json"), `{"type": "string", "pattern": "^[A-Z]+$", "maxLength": 8}`)
+	writeTestFile(t, filepath.Join(defs, "owner.json"), `{"type": "object", "properties": {"code": {"$ref": "_defs/code.json"}}}`)
+	editTestJSON(t, filepath.Join(repo.Root, "config", "schemas", "team.schema.json"), func(doc map[string]any) {
+		props := doc["properties"].(map[string]any)
+		props["code"] = map[string]any{"$ref": "_defs/code.json"}
+		props["owner"] = map[string]any{"$ref": "_defs/owner.json"}
+	})
+	schemas, err := LoadSchemas(repo.Root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	code := schemas["team"].Properties["code"]
+	if code.Type != "string" || code.Pattern == nil || *code.Pattern != "^[A-Z]+$" || code.MaxLength == nil || *code.MaxLength != 8 {
+		t.Errorf("code = %+v, want the _defs/code.json definition", code)
+	}
+	if nested := schemas["team"].Properties["owner"].Properties["code"]; nested.Pattern == nil || *nested.Pattern != "^[A-Z]+$" {
+		t.Errorf("owner.code = %+v, want the definition resolved through owner.json", nested)
+	}
+
+	teamPath := filepath.Join(repo.Root, "data", "team", testTeamID+".yaml")
+	writeTestFile(t, teamPath, "_id: "+testTeamID+"\n_type: team\ncode: plat\nname: Platform\nowner:\n  code: OPS\n")
+	result, err := ValidateRepository(repo.Root, repo.YAMLOptions)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Issues) != 1 || !strings.Contains(result.Issues[0].Message, "pattern") {
+		t.Errorf("issues = %v, want only the lowercase code rejected by the referenced pattern", result.Issues)
+	}
+}
+
+func TestLoadSchemasRejectsMissingDefsTarget(t *testing.T) {
+	repo := newTestRepository(t)
+	editTestJSON(t, filepath.Join(repo.Root, "config", "schemas", "team.schema.json"), func(doc map[string]any) {
+		doc["properties"].(map[string]any)["code"] = map[string]any{"$ref": "_defs/missing.json"}
+	})
+	_, err := LoadSchemas(repo.Root)
+	if err == nil || !strings.Contains(err.Error(), "missing definition file config/schemas/_defs/missing.json") {
+		t.Errorf("LoadSchemas error = %v, want the missing definition reported", err)
+	}
+}
+
+func TestLoadSchemasRejectsRefCycles(t *testing.T) {
+	repo := newTestRepository(t)
+	defs := filepath.Join(repo.Root, "config", "schemas", schemaDefsDir)
+	writeTestFile(t, filepath.Join(defs, "self.json"), `{"$ref": "_defs/self.json"}`)
+	writeTestFile(t, filepath.Join(defs, "a.json"), `{"type": "object", "properties": {"next": {"$ref": "_defs/b.json"}}}`)
+	writeTestFile(t, filepath.Join(defs, "b.json"), `{"$ref": "_defs/a.json"}`)
+	for ref, want := range map[string]string{
+		"_defs/self.json": "$ref cycle: _defs/self.json -> _defs/self.json",
+		"_defs/a.json":    "$ref cycle: _defs/a.json -> _defs/b.json -> _defs/a.json",
+	} {
+		editTestJSON(t, filepath.Join(repo.Root, "config", "schemas", "team.schema.json"), func(doc map[string]any) {
+			doc["properties"].(map[string]any)["code"] = map[string]any{"$ref": ref}
+		})
+		if _, err := LoadSchemas(repo.Root); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("%s: LoadSchemas error = %v, want %q", ref, err, want)
+		}
+	}
+}
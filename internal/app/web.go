@@ -5,10 +5,13 @@ import (
 	"errors"
 	"fmt"
 	"html/template"
+	"io"
 	"io/fs"
+	"math"
 	"net/http"
 	"net/url"
 	"os"
+	"path/filepath"
 	"sort"
 	"strconv"
 	"strings"
@@ -18,6 +21,15 @@ import (
 type webServer struct {
 	repo      *Repository
 	templates *template.Template
+	readOnly  bool
+
+	baseWorkspace string
+	configs       configCache
+}
+
+type WebOptions struct {
+	ReadOnly      bool
+	BaseWorkspace string
 }
 
 type workspaceOption struct {
@@ -29,16 +41,20 @@ type topBarData struct {
 	RepoName       string
 	Workspace      string
 	WorkspaceDirty bool
+	Sync           SyncStatus
 	OnMain         bool
+	ServerReadOnly bool
 	Workspaces     []workspaceOption
 	CurrentPath    string
+	CanPush        bool
 }
 
 type pageBase struct {
-	Top        topBarData
-	Crumbs     []breadcrumb
-	Flash      string
-	FlashError bool
+	Top          topBarData
+	Crumbs       []breadcrumb
+	Flash        string
+	FlashError   bool
+	FlashWarning bool
 }
 
 type breadcrumb struct {
@@ -53,10 +69,14 @@ type typesPageData struct {
 }
 
 type typeSummary struct {
-	Name       string
-	Count      int
-	DirtyCount int
-	ConfigURL  string
+	Name         string
+	Count        int
+	DirtyCount   int
+	InvalidCount int
+	ListURL      string
+	ConfigURL    string
+	Color        string
+	Icon         string
 }
 
 type typePageData struct {
@@ -66,9 +86,33 @@ type typePageData struct {
 	DisplayField   string
 	PrimaryHeading string
 	ExtraFields    []string
+	Columns        []sortColumn
 	Items          []objectListItem
 	TypeConfigURL  string
 	NewItemURL     string
+	BulkURL        string
+	ImportURL      string
+	Color          string
+	Icon           string
+	Query          string
+	Sort           string
+	Dir            string
+	PageSize       int
+	Page           int
+	PageCount      int
+	Total          int
+	First          int
+	Last           int
+	PrevURL        string
+	NextURL        string
+}
+
+// sortColumn is a sortable extra-field header on the type listing. Dir is the
+// column's active sort direction, empty when the list is not sorted by it.
+type sortColumn struct {
+	Name string
+	URL  string
+	Dir  string
 }
 
 type objectListItem struct {
@@ -87,23 +131,36 @@ type objectListItem struct {
 type namedValue struct {
 	Name  string
 	Value string
+	Kind  string
+	Bool  bool
 }
 
 type objectPageData struct {
 	pageBase
-	TypeName      string
-	ID            string
-	ReadOnly      bool
-	Missing       bool
-	MissingReason string
-	CanRestore    bool
-	RestoreURL    string
-	WriteURL      string
-	DeleteURL     string
-	Fields        []fieldData
-	FieldValues   map[string]string
-	Diffs         []fieldDiff
-	InvalidIssues []ValidationIssue
+	TypeName        string
+	ID              string
+	ReadOnly        bool
+	Missing         bool
+	MissingReason   string
+	CanRestore      bool
+	RestoreURL      string
+	WriteURL        string
+	DeleteURL       string
+	RevertURL       string
+	RevertToURL     string
+	History         []ObjectCommit
+	Fields          []fieldData
+	FieldValues     map[string]string
+	Presets         []string
+	Preset          string
+	PresetURL       string
+	Diffs           []fieldDiff
+	ChangesOnly     bool
+	ChangesURL      string
+	CarriedValues   []namedValue
+	InvalidIssues   []ValidationIssue
+	FieldIssueCount int
+	Form            formDescriptor
 }
 
 type fieldData struct {
@@ -111,12 +168,16 @@ type fieldData struct {
 	Type       string
 	ItemsType  string
 	Required   bool
+	Integer    bool
 	Enum       []string
 	MinLength  string
 	MaxLength  string
+	Pattern    string
 	Minimum    string
 	Maximum    string
 	ForeignKey *foreignKeyField
+	Issues     []ValidationIssue
+	Children   []fieldData
 }
 
 type foreignKeyField struct {
@@ -131,22 +192,77 @@ type foreignKeyOption struct {
 }
 
 type fieldDiff struct {
-	Field     string
-	Main      string
-	Workspace string
-	Status    string
+	Field     string `json:"field"`
+	Main      string `json:"main"`
+	Workspace string `json:"workspace"`
+	Status    string `json:"status"`
+}
+
+type recentPageData struct {
+	pageBase
+	ReadOnly bool
+	Items    []recentItem
+}
+
+type recentItem struct {
+	TypeName string
+	ID       string
+	Display  string
+	Status   string
+	Modified string
+	URL      string
+	modTime  time.Time
+}
+
+type searchPageData struct {
+	pageBase
+	Query     string
+	Groups    []searchGroup
+	Total     int
+	Truncated bool
+}
+
+type searchGroup struct {
+	TypeName string
+	Items    []searchResult
 }
 
+type searchResult struct {
+	Display string
+	URL     string
+	Matched []namedValue
+}
+
+const searchResultLimit = 100
+
+// objectHistoryLimit caps the commits listed in an object's History panel.
+const objectHistoryLimit = 20
+
+const (
+	defaultTypePageSize = 50
+	maxTypePageSize     = 500
+)
+
 type workspaceNewPageData struct {
 	pageBase
 	CreateURL string
 }
 
+type workspaceDeletePageData struct {
+	pageBase
+	Workspace    string
+	ChangedFiles []string
+	DeleteURL    string
+	BackURL      string
+}
+
 type configPageData struct {
 	pageBase
 	ReadOnly     bool
 	RepoName     string
 	SaveURL      string
+	Workspace    string
+	RenameURL    string
 	TypeSettings []typeSettingLink
 }
 
@@ -157,13 +273,17 @@ type typeSettingLink struct {
 
 type typeConfigPageData struct {
 	pageBase
-	ReadOnly        bool
-	TypeName        string
-	DisplayOptions  []displayOption
-	ExtraOptions    []extraOption
-	SaveURL         string
-	BackURL         string
-	CurrentRepoName string
+	ReadOnly         bool
+	TypeName         string
+	DisplayOptions   []displayOption
+	ExtraOptions     []extraOption
+	SaveURL          string
+	BackURL          string
+	CurrentRepoName  string
+	Color            string
+	Icon             string
+	DefaultSortField string
+	DefaultSortDir   string
 }
 
 type displayOption struct {
@@ -179,10 +299,16 @@ type extraOption struct {
 
 type conflictView struct {
 	pageBase
-	Workspace string
-	Conflicts []conflictRow
-	PostURL   string
-	BackURL   string
+	Workspace   string
+	Conflicts   []conflictRow
+	PostURL     string
+	CheckURL    string
+	BackURL     string
+	Checked     bool
+	CheckIssues []ValidationIssue
+	Unresolved  int
+	Push        bool
+	Sync        bool
 }
 
 type conflictRow struct {
@@ -192,6 +318,19 @@ type conflictRow struct {
 	Base           string
 	Main           string
 	WorkspaceValue string
+	Choice         string
+	Manual         string
+	WholeFile      bool
+	Elements       []conflictElement
+}
+
+// conflictElement is one distinct item of an array field in conflict, marking
+// which versions hold it.
+type conflictElement struct {
+	Value       string
+	InBase      bool
+	InMain      bool
+	InWorkspace bool
 }
 
 type workspaceContext struct {
@@ -203,16 +342,39 @@ type workspaceContext struct {
 	UI             UIConfig
 	Workspaces     []Workspace
 	WorkspaceDirty bool
+	Sync           SyncStatus
 	DirtyByType    map[string]map[string]string
 	ObjectIssues   map[string]map[string][]ValidationIssue
+
+	// objectCache holds the objects of each type listed during the request.
+	objectCache map[string][]Object
+	// yaml is how the repository's object files are read.
+	yaml YAMLOptions
+}
+
+// objects lists the objects of a type, reading them from disk only on the
+// first call of the request. Callers must not modify the returned slice.
+func (ctx *workspaceContext) objects(typeName string) ([]Object, error) {
+	if objs, ok := ctx.objectCache[typeName]; ok {
+		return objs, nil
+	}
+	objs, err := ctx.yaml.ListObjectsForType(ctx.RepoPath, typeName)
+	if err != nil {
+		return nil, err
+	}
+	if ctx.objectCache == nil {
+		ctx.objectCache = map[string][]Object{}
+	}
+	ctx.objectCache[typeName] = objs
+	return objs, nil
 }
 
-func StartWebServer(ctx context.Context, repo *Repository, addr string) error {
+func StartWebServer(ctx context.Context, repo *Repository, addr string, opts WebOptions) error {
 	tmpl, err := template.ParseFS(webAssets, "templates/*.html")
 	if err != nil {
 		return err
 	}
-	server := &webServer{repo: repo, templates: tmpl}
+	server := &webServer{repo: repo, templates: tmpl, readOnly: opts.ReadOnly, baseWorkspace: opts.BaseWorkspace}
 	mux := http.NewServeMux()
 	server.routes(mux)
 
@@ -242,6 +404,7 @@ func (s *webServer) routes(mux *http.ServeMux) {
 	})
 	mux.HandleFunc("/", s.handleRoot)
 	mux.HandleFunc("/w/", s.handleWorkspace)
+	mux.HandleFunc("/api/", s.handleAPI)
 }
 
 func (s *webServer) handleRoot(w http.ResponseWriter, r *http.Request) {
@@ -249,7 +412,11 @@ func (s *webServer) handleRoot(w http.ResponseWriter, r *http.Request) {
 		http.NotFound(w, r)
 		return
 	}
-	http.Redirect(w, r, "/w/main/types", http.StatusSeeOther)
+	base := "main"
+	if s.baseWorkspace != "" && s.baseWorkspace != "main" && s.repo.WorkspaceExists(s.baseWorkspace) {
+		base = s.baseWorkspace
+	}
+	http.Redirect(w, r, "/w/"+url.PathEscape(base)+"/types", http.StatusSeeOther)
 }
 
 func (s *webServer) handleWorkspace(w http.ResponseWriter, r *http.Request) {
@@ -258,6 +425,16 @@ func (s *webServer) handleWorkspace(w http.ResponseWriter, r *http.Request) {
 		http.NotFound(w, r)
 		return
 	}
+	if len(tail) > 0 && tail[0] == "api" {
+		s.serveAPI(w, r, ws, tail[1:])
+		return
+	}
+
+	if s.readOnly && r.Method == http.MethodPost && !(len(tail) == 1 && tail[0] == "validate") {
+		returnPath := firstNonEmpty(r.FormValue("return"), "/w/"+url.PathEscape(ws)+"/types")
+		s.redirectWithFlash(w, r, returnPath, "server is running in read-only mode", true)
+		return
+	}
 
 	switch {
 	case len(tail) == 0:
@@ -266,21 +443,39 @@ func (s *webServer) handleWorkspace(w http.ResponseWriter, r *http.Request) {
 	case len(tail) == 1 && tail[0] == "types" && r.Method == http.MethodGet:
 		s.handleTypesHome(w, r, ws)
 		return
+	case len(tail) == 1 && tail[0] == "search" && r.Method == http.MethodGet:
+		s.handleSearchPage(w, r, ws)
+		return
+	case len(tail) == 1 && tail[0] == "recent" && r.Method == http.MethodGet:
+		s.handleRecentPage(w, r, ws)
+		return
 	case len(tail) == 2 && tail[0] == "types" && r.Method == http.MethodGet:
 		s.handleTypeList(w, r, ws, tail[1])
 		return
 	case len(tail) == 3 && tail[0] == "types" && tail[2] == "new" && r.Method == http.MethodGet:
 		s.handleObjectPage(w, r, ws, tail[1], "")
 		return
+	case len(tail) == 3 && tail[0] == "types" && tail[2] == "import" && r.Method == http.MethodPost:
+		s.handleObjectImport(w, r, ws, tail[1])
+		return
 	case len(tail) == 4 && tail[0] == "types" && tail[2] == "objects" && r.Method == http.MethodGet:
 		s.handleObjectPage(w, r, ws, tail[1], tail[3])
 		return
 	case len(tail) == 4 && tail[0] == "types" && tail[2] == "objects" && tail[3] == "write" && r.Method == http.MethodPost:
 		s.handleObjectWrite(w, r, ws, tail[1])
 		return
+	case len(tail) == 4 && tail[0] == "types" && tail[2] == "objects" && tail[3] == "bulk" && r.Method == http.MethodPost:
+		s.handleObjectBulk(w, r, ws, tail[1])
+		return
 	case len(tail) == 5 && tail[0] == "types" && tail[2] == "objects" && tail[4] == "delete" && r.Method == http.MethodPost:
 		s.handleObjectDelete(w, r, ws, tail[1], tail[3])
 		return
+	case len(tail) == 5 && tail[0] == "types" && tail[2] == "objects" && tail[4] == "revert" && r.Method == http.MethodPost:
+		s.handleObjectRevertField(w, r, ws, tail[1], tail[3])
+		return
+	case len(tail) == 5 && tail[0] == "types" && tail[2] == "objects" && tail[4] == "revert-to" && r.Method == http.MethodPost:
+		s.handleObjectRevertTo(w, r, ws, tail[1], tail[3])
+		return
 	case len(tail) == 5 && tail[0] == "types" && tail[2] == "objects" && tail[4] == "restore" && r.Method == http.MethodPost:
 		s.handleObjectRestore(w, r, ws, tail[1], tail[3])
 		return
@@ -293,15 +488,24 @@ func (s *webServer) handleWorkspace(w http.ResponseWriter, r *http.Request) {
 	case len(tail) == 2 && tail[0] == "workspace" && tail[1] == "delete" && r.Method == http.MethodPost:
 		s.handleWorkspaceDelete(w, r, ws)
 		return
+	case len(tail) == 2 && tail[0] == "workspace" && tail[1] == "rename" && r.Method == http.MethodPost:
+		s.handleWorkspaceRename(w, r, ws)
+		return
 	case len(tail) == 1 && tail[0] == "save" && r.Method == http.MethodPost:
 		s.handleWorkspaceSave(w, r, ws)
 		return
 	case len(tail) == 1 && tail[0] == "promote" && r.Method == http.MethodPost:
 		s.handleWorkspacePromote(w, r, ws)
 		return
+	case len(tail) == 2 && tail[0] == "promote" && tail[1] == "check" && r.Method == http.MethodPost:
+		s.handleWorkspacePromoteCheck(w, r, ws)
+		return
 	case len(tail) == 1 && tail[0] == "validate" && r.Method == http.MethodPost:
 		s.handleWorkspaceValidate(w, r, ws)
 		return
+	case len(tail) == 2 && tail[0] == "maintenance" && tail[1] == "canonicalize" && r.Method == http.MethodPost:
+		s.handleWorkspaceCanonicalize(w, r, ws)
+		return
 	case len(tail) == 1 && tail[0] == "config" && r.Method == http.MethodGet:
 		s.handleConfigPage(w, r, ws)
 		return
@@ -344,15 +548,7 @@ func (s *webServer) loadContext(workspace string) (workspaceContext, error) {
 	if err != nil {
 		return workspaceContext{}, err
 	}
-	schemas, err := LoadSchemas(repoPath)
-	if err != nil {
-		return workspaceContext{}, err
-	}
-	constraints, err := LoadConstraints(repoPath)
-	if err != nil {
-		return workspaceContext{}, err
-	}
-	ui, err := LoadUIConfig(repoPath, schemas)
+	schemas, constraints, ui, err := s.configs.load(repoPath)
 	if err != nil {
 		return workspaceContext{}, err
 	}
@@ -370,8 +566,9 @@ func (s *webServer) loadContext(workspace string) (workspaceContext, error) {
 		Workspaces:   workspaces,
 		DirtyByType:  map[string]map[string]string{},
 		ObjectIssues: map[string]map[string][]ValidationIssue{},
+		yaml:         s.repo.YAMLOptions,
 	}
-	objectIssues, err := collectObjectIssues(repoPath)
+	objectIssues, err := s.collectObjectIssues(repoPath)
 	if err != nil {
 		return workspaceContext{}, err
 	}
@@ -382,6 +579,9 @@ func (s *webServer) loadContext(workspace string) (workspaceContext, error) {
 			return workspaceContext{}, err
 		}
 		ctx.DirtyByType = mapDirtyEntries(entries)
+		if sync, err := s.repo.WorkspaceSync(workspace); err == nil {
+			ctx.Sync = sync
+		}
 	}
 	for _, ws := range workspaces {
 		if ws.Name == workspace {
@@ -389,6 +589,9 @@ func (s *webServer) loadContext(workspace string) (workspaceContext, error) {
 			break
 		}
 	}
+	if s.readOnly {
+		ctx.ReadOnly = true
+	}
 	return ctx, nil
 }
 
@@ -401,9 +604,12 @@ func (s *webServer) topBar(ctx workspaceContext, currentPath string) topBarData
 		RepoName:       ctx.UI.RepoName,
 		Workspace:      ctx.Workspace,
 		WorkspaceDirty: ctx.WorkspaceDirty,
+		Sync:           ctx.Sync,
 		OnMain:         ctx.ReadOnly,
+		ServerReadOnly: s.readOnly,
 		Workspaces:     options,
 		CurrentPath:    currentPath,
+		CanPush:        !ctx.ReadOnly && s.repo.HasPushRemote(),
 	}
 }
 
@@ -414,25 +620,25 @@ func (s *webServer) handleTypesHome(w http.ResponseWriter, r *http.Request, work
 		return
 	}
 
-	types := make([]string, 0, len(ctx.Schemas))
-	for t := range ctx.Schemas {
-		types = append(types, t)
-	}
-	sort.Strings(types)
+	types := sortedKeys(ctx.Schemas)
 
 	summaries := make([]typeSummary, 0, len(types))
 	for _, t := range types {
-		objs, err := ListObjectsForType(ctx.RepoPath, t)
+		objs, err := s.repo.ListObjectsForType(ctx.RepoPath, t)
 		if err != nil {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
 		}
 		dirtyCount := len(ctx.DirtyByType[t])
 		summaries = append(summaries, typeSummary{
-			Name:       t,
-			Count:      len(objs),
-			DirtyCount: dirtyCount,
-			ConfigURL:  "/w/" + url.PathEscape(workspace) + "/config/types/" + url.PathEscape(t),
+			Name:         t,
+			Count:        len(objs),
+			DirtyCount:   dirtyCount,
+			InvalidCount: len(ctx.ObjectIssues[t]),
+			ListURL:      "/w/" + url.PathEscape(workspace) + "/types/" + url.PathEscape(t),
+			ConfigURL:    "/w/" + url.PathEscape(workspace) + "/config/types/" + url.PathEscape(t),
+			Color:        ctx.UI.Types[t].Color,
+			Icon:         ctx.UI.Types[t].Icon,
 		})
 	}
 
@@ -448,6 +654,139 @@ func (s *webServer) handleTypesHome(w http.ResponseWriter, r *http.Request, work
 	s.renderTemplate(w, "types.html", data)
 }
 
+func (s *webServer) handleRecentPage(w http.ResponseWriter, r *http.Request, workspace string) {
+	ctx, err := s.loadContext(workspace)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	items := make([]recentItem, 0)
+	for typeName, byID := range ctx.DirtyByType {
+		typeCfg := ctx.UI.Types[typeName]
+		for id, status := range byID {
+			item := recentItem{
+				TypeName: typeName,
+				ID:       id,
+				Display:  id,
+				Status:   status,
+				URL:      "/w/" + url.PathEscape(workspace) + "/types/" + url.PathEscape(typeName) + "/objects/" + url.PathEscape(id),
+			}
+			if status != "D" {
+				path := filepath.Join(ctx.RepoPath, "data", typeName, id+".yaml")
+				if st, err := os.Stat(path); err == nil {
+					item.modTime = st.ModTime()
+					item.Modified = item.modTime.Format("2006-01-02 15:04:05")
+				}
+				if obj, err := s.repo.ReadObject(ctx.RepoPath, typeName, id); err == nil {
+					item.Display = displayValue(obj.Data, typeCfg.DisplayField, id)
+				}
+			} else if baseObj, err := s.repo.ReadObject(s.repo.Root, typeName, id); err == nil {
+				item.Display = displayValue(baseObj.Data, typeCfg.DisplayField, id)
+			}
+			items = append(items, item)
+		}
+	}
+	sort.Slice(items, func(i, j int) bool {
+		if !items[i].modTime.Equal(items[j].modTime) {
+			return items[i].modTime.After(items[j].modTime)
+		}
+		if items[i].TypeName != items[j].TypeName {
+			return items[i].TypeName < items[j].TypeName
+		}
+		return items[i].ID < items[j].ID
+	})
+
+	data := recentPageData{
+		pageBase: pageBase{
+			Top: s.topBar(ctx, r.URL.Path),
+			Crumbs: []breadcrumb{
+				{Label: "Types", URL: "/w/" + url.PathEscape(workspace) + "/types"},
+				{Label: "Recent", URL: r.URL.Path, Current: true},
+			},
+			Flash:      r.URL.Query().Get("flash"),
+			FlashError: r.URL.Query().Get("error") == "1",
+		},
+		ReadOnly: ctx.ReadOnly,
+		Items:    items,
+	}
+	s.renderTemplate(w, "recent.html", data)
+}
+
+func (s *webServer) handleSearchPage(w http.ResponseWriter, r *http.Request, workspace string) {
+	ctx, err := s.loadContext(workspace)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	query := strings.TrimSpace(r.URL.Query().Get("q"))
+	data := searchPageData{
+		pageBase: pageBase{
+			Top: s.topBar(ctx, r.URL.Path),
+			Crumbs: []breadcrumb{
+				{Label: "Types", URL: "/w/" + url.PathEscape(workspace) + "/types"},
+				{Label: "Search", URL: r.URL.Path, Current: true},
+			},
+			Flash:      r.URL.Query().Get("flash"),
+			FlashError: r.URL.Query().Get("error") == "1",
+		},
+		Query: query,
+	}
+	if query == "" {
+		s.renderTemplate(w, "search.html", data)
+		return
+	}
+
+	needle := strings.ToLower(query)
+	types := sortedKeys(ctx.Schemas)
+
+	for _, typeName := range types {
+		if data.Truncated {
+			break
+		}
+		objects, err := s.repo.ListObjectsForType(ctx.RepoPath, typeName)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		typeCfg := ctx.UI.Types[typeName]
+		searchFields := append([]string{typeCfg.DisplayField}, selectedExtraFields(typeCfg.Fields, ctx.Schemas[typeName], typeCfg.DisplayField)...)
+		group := searchGroup{TypeName: typeName}
+		for _, obj := range objects {
+			matched := make([]namedValue, 0)
+			if strings.Contains(strings.ToLower(obj.ID), needle) {
+				matched = append(matched, namedValue{Name: "_id", Value: obj.ID})
+			}
+			for _, field := range searchFields {
+				if field == "" || field == "_id" {
+					continue
+				}
+				text := valueToText(obj.Data[field])
+				if strings.Contains(strings.ToLower(text), needle) {
+					matched = append(matched, namedValue{Name: field, Value: text})
+				}
+			}
+			if len(matched) == 0 {
+				continue
+			}
+			if data.Total == searchResultLimit {
+				data.Truncated = true
+				break
+			}
+			group.Items = append(group.Items, searchResult{
+				Display: displayValue(obj.Data, typeCfg.DisplayField, obj.ID),
+				URL:     "/w/" + url.PathEscape(workspace) + "/types/" + url.PathEscape(typeName) + "/objects/" + url.PathEscape(obj.ID),
+				Matched: matched,
+			})
+			data.Total++
+		}
+		if len(group.Items) > 0 {
+			data.Groups = append(data.Groups, group)
+		}
+	}
+	s.renderTemplate(w, "search.html", data)
+}
+
 func (s *webServer) handleTypeList(w http.ResponseWriter, r *http.Request, workspace, typeName string) {
 	ctx, err := s.loadContext(workspace)
 	if err != nil {
@@ -459,7 +798,7 @@ func (s *webServer) handleTypeList(w http.ResponseWriter, r *http.Request, works
 		http.NotFound(w, r)
 		return
 	}
-	objects, err := ListObjectsForType(ctx.RepoPath, typeName)
+	objects, err := s.repo.ListObjectsForType(ctx.RepoPath, typeName)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -482,7 +821,7 @@ func (s *webServer) handleTypeList(w http.ResponseWriter, r *http.Request, works
 		dirty := ctx.DirtyByType[typeName][obj.ID]
 		fields := make([]namedValue, 0, len(extraFields))
 		for _, field := range extraFields {
-			fields = append(fields, namedValue{Name: field, Value: valueToText(obj.Data[field])})
+			fields = append(fields, listCell(field, obj.Data[field]))
 		}
 		issues := ctx.ObjectIssues[typeName][obj.ID]
 		invalid := len(issues) > 0
@@ -515,10 +854,10 @@ func (s *webServer) handleTypeList(w http.ResponseWriter, r *http.Request, works
 		}
 		deletedDisplay := id
 		deletedFields := make([]namedValue, 0, len(extraFields))
-		if baseObj, err := ReadObject(s.repo.Root, typeName, id); err == nil {
+		if baseObj, err := s.repo.ReadObject(s.repo.Root, typeName, id); err == nil {
 			deletedDisplay = displayValue(baseObj.Data, typeCfg.DisplayField, id)
 			for _, field := range extraFields {
-				deletedFields = append(deletedFields, namedValue{Name: field, Value: valueToText(baseObj.Data[field])})
+				deletedFields = append(deletedFields, listCell(field, baseObj.Data[field]))
 			}
 		}
 		typePath := url.PathEscape(typeName)
@@ -545,6 +884,50 @@ func (s *webServer) handleTypeList(w http.ResponseWriter, r *http.Request, works
 		return items[i].Display < items[j].Display
 	})
 
+	list := typeListQuery{
+		Query:    strings.TrimSpace(r.URL.Query().Get("q")),
+		PageSize: queryInt(r, "pageSize", defaultTypePageSize),
+	}
+	sortField, sortDir := r.URL.Query().Get("sort"), r.URL.Query().Get("dir")
+	if !r.URL.Query().Has("sort") {
+		sortField, sortDir = typeCfg.DefaultSortField, typeCfg.DefaultSortDir
+	}
+	if contains(extraFields, sortField) {
+		list.Sort = sortField
+		list.Dir = "asc"
+		if sortDir == "desc" {
+			list.Dir = "desc"
+		}
+		sortObjectListItems(items, sortField, schema.Properties[sortField], list.Dir == "desc")
+	}
+	if list.Query != "" {
+		items = filterObjectListItems(items, list.Query)
+	}
+	if list.PageSize < 1 || list.PageSize > maxTypePageSize {
+		list.PageSize = defaultTypePageSize
+	}
+	total := len(items)
+	pageCount := max(1, (total+list.PageSize-1)/list.PageSize)
+	page := min(max(1, queryInt(r, "page", 1)), pageCount)
+	first := (page - 1) * list.PageSize
+	last := min(first+list.PageSize, total)
+	items = items[first:last]
+
+	columns := make([]sortColumn, 0, len(extraFields))
+	for _, field := range extraFields {
+		target := list
+		target.Sort, target.Dir = field, "asc"
+		col := sortColumn{Name: field}
+		if field == list.Sort {
+			col.Dir = list.Dir
+			if list.Dir == "asc" {
+				target.Dir = "desc"
+			}
+		}
+		col.URL = target.url(r.URL.Path, 1)
+		columns = append(columns, col)
+	}
+
 	data := typePageData{
 		pageBase: pageBase{
 			Top:        s.topBar(ctx, r.URL.Path),
@@ -557,13 +940,133 @@ func (s *webServer) handleTypeList(w http.ResponseWriter, r *http.Request, works
 		DisplayField:   typeCfg.DisplayField,
 		PrimaryHeading: primaryHeading,
 		ExtraFields:    extraFields,
+		Columns:        columns,
 		Items:          items,
 		TypeConfigURL:  "/w/" + url.PathEscape(workspace) + "/config/types/" + url.PathEscape(typeName),
 		NewItemURL:     "/w/" + url.PathEscape(workspace) + "/types/" + url.PathEscape(typeName) + "/new",
+		BulkURL:        "/w/" + url.PathEscape(workspace) + "/types/" + url.PathEscape(typeName) + "/objects/bulk",
+		ImportURL:      "/w/" + url.PathEscape(workspace) + "/types/" + url.PathEscape(typeName) + "/import",
+		Color:          typeCfg.Color,
+		Icon:           typeCfg.Icon,
+		Query:          list.Query,
+		Sort:           list.Sort,
+		Dir:            list.Dir,
+		PageSize:       list.PageSize,
+		Page:           page,
+		PageCount:      pageCount,
+		Total:          total,
+		First:          first + 1,
+		Last:           last,
+	}
+	if page > 1 {
+		data.PrevURL = list.url(r.URL.Path, page-1)
+	}
+	if page < pageCount {
+		data.NextURL = list.url(r.URL.Path, page+1)
 	}
 	s.renderTemplate(w, "type.html", data)
 }
 
+// filterObjectListItems keeps items whose display value or any listed field
+// contains query, ignoring case. Deleted items are matched on their values
+// from main.
+func filterObjectListItems(items []objectListItem, query string) []objectListItem {
+	needle := strings.ToLower(query)
+	out := make([]objectListItem, 0, len(items))
+	for _, item := range items {
+		match := strings.Contains(strings.ToLower(item.Display), needle)
+		for _, f := range item.Fields {
+			if match {
+				break
+			}
+			match = strings.Contains(strings.ToLower(f.Value), needle)
+		}
+		if match {
+			out = append(out, item)
+		}
+	}
+	return out
+}
+
+// sortObjectListItems stably orders items by an extra field, numerically for
+// number and integer fields. Empty values sort last in either direction, and
+// deleted items stay after live ones; ties keep the display/id order.
+func sortObjectListItems(items []objectListItem, field string, prop SchemaProperty, desc bool) {
+	numeric := prop.Type == "number" || prop.Type == "integer"
+	cell := func(item objectListItem) string {
+		for _, f := range item.Fields {
+			if f.Name == field {
+				return f.Value
+			}
+		}
+		return ""
+	}
+	sort.SliceStable(items, func(i, j int) bool {
+		if items[i].Deleted != items[j].Deleted {
+			return !items[i].Deleted
+		}
+		a, b := cell(items[i]), cell(items[j])
+		if (a == "") != (b == "") {
+			return b == ""
+		}
+		if numeric {
+			x, errX := strconv.ParseFloat(a, 64)
+			y, errY := strconv.ParseFloat(b, 64)
+			if errX == nil && errY == nil {
+				if desc {
+					return x > y
+				}
+				return x < y
+			}
+		}
+		if desc {
+			return a > b
+		}
+		return a < b
+	})
+}
+
+// typeListQuery is the filter, sort, and page size of a type listing.
+type typeListQuery struct {
+	Query    string
+	Sort     string
+	Dir      string
+	PageSize int
+}
+
+// url links to one page of the listing, leaving out parameters that hold
+// their defaults.
+func (q typeListQuery) url(path string, page int) string {
+	values := url.Values{}
+	if q.Query != "" {
+		values.Set("q", q.Query)
+	}
+	if q.Sort != "" {
+		values.Set("sort", q.Sort)
+		values.Set("dir", q.Dir)
+	}
+	if page > 1 {
+		values.Set("page", strconv.Itoa(page))
+	}
+	if q.PageSize != defaultTypePageSize {
+		values.Set("pageSize", strconv.Itoa(q.PageSize))
+	}
+	if len(values) == 0 {
+		return path
+	}
+	return path + "?" + values.Encode()
+}
+
+// queryInt reads an integer query parameter, returning fallback when it is
+// missing or malformed.
+func queryInt(r *http.Request, name string, fallback int) int {
+	v, err := strconv.Atoi(r.URL.Query().Get(name))
+	if err != nil {
+		return fallback
+	}
+	return v
+}
+
 func (s *webServer) handleObjectPage(w http.ResponseWriter, r *http.Request, workspace, typeName, id string) {
 	ctx, err := s.loadContext(workspace)
 	if err != nil {
@@ -575,34 +1078,66 @@ func (s *webServer) handleObjectPage(w http.ResponseWriter, r *http.Request, wor
 		http.NotFound(w, r)
 		return
 	}
-	fields := schemaToFieldData(schema)
+	fields := schemaToFieldData(schema, ctx.UI.Types[typeName].HiddenFields)
 	s.enrichForeignKeys(&ctx, typeName, fields)
 
 	data := objectPageData{
 		pageBase: pageBase{
-			Top:        s.topBar(ctx, r.URL.Path),
-			Crumbs:     buildCrumbs(workspace, typeName, firstNonEmpty(id, "new")),
-			Flash:      r.URL.Query().Get("flash"),
-			FlashError: r.URL.Query().Get("error") == "1",
+			Top:          s.topBar(ctx, r.URL.Path),
+			Crumbs:       buildCrumbs(workspace, typeName, firstNonEmpty(id, "new")),
+			Flash:        r.URL.Query().Get("flash"),
+			FlashError:   r.URL.Query().Get("error") == "1",
+			FlashWarning: r.URL.Query().Get("warn") == "1",
 		},
 		TypeName:    typeName,
 		ID:          id,
 		ReadOnly:    ctx.ReadOnly,
 		Fields:      fields,
 		FieldValues: map[string]string{},
+		Form:        buildFormDescriptor(typeName, schema, fields),
 		WriteURL:    "/w/" + url.PathEscape(workspace) + "/types/" + url.PathEscape(typeName) + "/objects/write",
 	}
 	if id != "" {
 		data.DeleteURL = "/w/" + url.PathEscape(workspace) + "/types/" + url.PathEscape(typeName) + "/objects/" + url.PathEscape(id) + "/delete"
 		data.RestoreURL = "/w/" + url.PathEscape(workspace) + "/types/" + url.PathEscape(typeName) + "/objects/" + url.PathEscape(id) + "/restore"
+		data.RevertURL = "/w/" + url.PathEscape(workspace) + "/types/" + url.PathEscape(typeName) + "/objects/" + url.PathEscape(id) + "/revert"
+		data.RevertToURL = "/w/" + url.PathEscape(workspace) + "/types/" + url.PathEscape(typeName) + "/objects/" + url.PathEscape(id) + "/revert-to"
+		if history, err := s.repo.ObjectHistory(ctx.RepoPath, typeName, id, objectHistoryLimit); err == nil {
+			data.History = history
+		}
 	}
 
 	if id == "" {
+		typeCfg := ctx.UI.Types[typeName]
+		for _, p := range typeCfg.Presets {
+			data.Presets = append(data.Presets, p.Name)
+		}
+		data.PresetURL = r.URL.Path
+		defaults := map[string]any{}
+		applySchemaDefaults(defaults, schema, func(string) bool { return false })
+		for _, k := range sortedKeys(defaults) {
+			setFormValue(data.FieldValues, k, defaults[k])
+		}
+		if name := r.URL.Query().Get("preset"); name != "" {
+			preset, ok := typeCfg.Preset(name)
+			if !ok {
+				data.Flash = "unknown preset " + name
+				data.FlashError = true
+			} else {
+				data.Preset = name
+				for k, v := range preset.Values {
+					if nv, err := normalizeObjectValue(v); err == nil {
+						setFormValue(data.FieldValues, k, nv)
+					}
+				}
+				ensureForeignKeyCurrentOptions(data.Fields, data.FieldValues)
+			}
+		}
 		s.renderTemplate(w, "object.html", data)
 		return
 	}
 
-	obj, err := ReadObject(ctx.RepoPath, typeName, id)
+	obj, err := s.repo.ReadObject(ctx.RepoPath, typeName, id)
 	if err != nil {
 		data.Missing = true
 		data.MissingReason = "Object was not found in this workspace."
@@ -613,22 +1148,64 @@ func (s *webServer) handleObjectPage(w http.ResponseWriter, r *http.Request, wor
 		s.renderTemplate(w, "object.html", data)
 		return
 	}
-	for k, v := range obj.Data {
+	// Values stored under an alias fill the property's input, so the next
+	// write stores them under the property name.
+	formData, _ := canonicalAliasData(obj.Data, schema)
+	for k, v := range formData {
 		if k == "_id" || k == "_type" {
 			continue
 		}
-		data.FieldValues[k] = valueToForm(v)
+		setFormValue(data.FieldValues, k, v)
 	}
 	ensureForeignKeyCurrentOptions(data.Fields, data.FieldValues)
 	if workspace != "main" {
-		if mainObj, err := ReadObject(s.repo.Root, typeName, id); err == nil {
+		if mainObj, err := s.repo.ReadObject(s.repo.Root, typeName, id); err == nil {
 			data.Diffs = computeDiffs(mainObj.Data, obj.Data)
+			data.ChangesOnly = r.URL.Query().Get("changes") == "1"
+			data.ChangesURL = r.URL.Path + "?changes=1"
+			if data.ChangesOnly {
+				data.ChangesURL = r.URL.Path
+				data.Fields, data.CarriedValues = changedFieldsOnly(data.Fields, data.Diffs, schema.Aliases, data.FieldValues)
+			}
 		}
 	}
-	data.InvalidIssues = ctx.ObjectIssues[typeName][id]
+	issues := ctx.ObjectIssues[typeName][id]
+	data.InvalidIssues = attachFieldIssues(data.Fields, issues)
+	data.FieldIssueCount = len(issues) - len(data.InvalidIssues)
 	s.renderTemplate(w, "object.html", data)
 }
 
+// changedFieldsOnly keeps the fields that differ from main and returns the
+// form values of the others, which the page submits as hidden inputs so that
+// updating a draft from the changes-only view does not drop them.
+func changedFieldsOnly(fields []fieldData, diffs []fieldDiff, aliases map[string]string, values map[string]string) ([]fieldData, []namedValue) {
+	changed := make(map[string]bool, len(diffs))
+	for _, d := range diffs {
+		changed[firstNonEmpty(aliases[d.Field], d.Field)] = true
+	}
+	kept := make([]fieldData, 0, len(diffs))
+	carried := make([]namedValue, 0)
+	for _, f := range fields {
+		if changed[f.Name] {
+			kept = append(kept, f)
+			continue
+		}
+		names := []string{f.Name}
+		if f.Type == "object" {
+			names = names[:0]
+			for _, child := range f.Children {
+				names = append(names, child.Name)
+			}
+		}
+		for _, name := range names {
+			if v, ok := values[name]; ok {
+				carried = append(carried, namedValue{Name: name, Value: v})
+			}
+		}
+	}
+	return kept, carried
+}
+
 func (s *webServer) handleObjectWrite(w http.ResponseWriter, r *http.Request, workspace, typeName string) {
 	ctx, err := s.loadContext(workspace)
 	if err != nil {
@@ -645,16 +1222,65 @@ func (s *webServer) handleObjectWrite(w http.ResponseWriter, r *http.Request, wo
 		return
 	}
 	id := strings.TrimSpace(r.FormValue("id"))
-	if id == "" {
+	creating := id == ""
+	if creating {
 		id, err = NewUUID()
 		if err != nil {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
 		}
+	} else if !uuidPattern.MatchString(id) {
+		s.redirectWithFlash(w, r, "/w/"+url.PathEscape(workspace)+"/types/"+url.PathEscape(typeName), "invalid object id", true)
+		return
 	}
 
-	obj := Object{ID: id, Type: typeName, Data: map[string]any{"_id": id, "_type": typeName}}
-	for field, prop := range schema.Properties {
+	obj := Object{ID: id, Type: typeName, Data: map[string]any{}}
+	for _, reserved := range []string{"_id", "_type"} {
+		if raw := strings.TrimSpace(r.FormValue("field." + reserved)); raw != "" {
+			obj.Data[reserved] = raw
+		}
+	}
+	notes := enforceReservedFields(&obj)
+	hidden := ctx.UI.Types[typeName].HiddenFields
+	if len(hidden) > 0 {
+		if existing, err := s.repo.ReadObject(ctx.RepoPath, typeName, id); err == nil {
+			existingData, _ := canonicalAliasData(existing.Data, schema)
+			for _, field := range hidden {
+				if v, ok := existingData[field]; ok {
+					obj.Data[field] = v
+				}
+			}
+		}
+	}
+	for _, field := range sortedKeys(schema.Properties) {
+		prop := schema.Properties[field]
+		if contains(hidden, field) {
+			continue
+		}
+		if prop.Type == "object" {
+			nested := map[string]any{}
+			for _, child := range sortedKeys(prop.Properties) {
+				childProp := prop.Properties[child]
+				raw := strings.TrimSpace(r.FormValue("field." + field + "." + child))
+				if raw == "" {
+					continue
+				}
+				v, err := parseFormField(raw, childProp)
+				if err != nil {
+					path := "/w/" + url.PathEscape(workspace) + "/types/" + url.PathEscape(typeName) + "/objects/" + url.PathEscape(id)
+					s.redirectWithFlash(w, r, path, fmt.Sprintf("invalid %s.%s: %v", field, child, err), true)
+					return
+				}
+				if isFractionalInteger(v, childProp) {
+					notes = append(notes, fmt.Sprintf("%s.%s must be a whole number (got %s)", field, child, raw))
+				}
+				nested[child] = v
+			}
+			if len(nested) > 0 {
+				obj.Data[field] = nested
+			}
+			continue
+		}
 		raw := strings.TrimSpace(r.FormValue("field." + field))
 		if raw == "" {
 			continue
@@ -665,14 +1291,48 @@ func (s *webServer) handleObjectWrite(w http.ResponseWriter, r *http.Request, wo
 			s.redirectWithFlash(w, r, path, fmt.Sprintf("invalid %s: %v", field, err), true)
 			return
 		}
+		if isFractionalInteger(v, prop) {
+			notes = append(notes, fmt.Sprintf("%s must be a whole number (got %s)", field, raw))
+		}
 		obj.Data[field] = v
 	}
+	if creating {
+		applySchemaDefaults(obj.Data, schema, func(field string) bool {
+			_, ok := r.Form["field."+field]
+			return ok
+		})
+	}
+	if ctx.UI.Types[typeName].WarnDuplicateItems {
+		for _, field := range sortedKeys(obj.Data) {
+			arr, ok := obj.Data[field].([]any)
+			if !ok {
+				continue
+			}
+			if dups := duplicateArrayItems(arr); len(dups) > 0 {
+				notes = append(notes, fmt.Sprintf("duplicate entries in %s: %s", field, strings.Join(dups, ", ")))
+			}
+		}
+	}
+
+	references, err := CheckForeignKeyReferences(obj, ctx.Constraints, ctx.Schemas, ctx.objects)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	for _, issue := range references {
+		notes = append(notes, fmt.Sprintf("%s: %s", issue.Field, issue.Message))
+	}
 
-	if err := WriteObject(ctx.RepoPath, obj); err != nil {
+	if err := s.repo.WriteObject(ctx.RepoPath, obj); err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 	path := "/w/" + url.PathEscape(workspace) + "/types/" + url.PathEscape(typeName) + "/objects/" + url.PathEscape(id)
+	// The draft was written, so notes are warnings rather than errors.
+	if len(notes) > 0 {
+		s.redirectWithWarning(w, r, path, "Draft updated; "+strings.Join(notes, "; "))
+		return
+	}
 	s.redirectWithFlash(w, r, path, "Draft updated", false)
 }
 
@@ -686,11 +1346,37 @@ func (s *webServer) handleObjectDelete(w http.ResponseWriter, r *http.Request, w
 		s.redirectWithFlash(w, r, "/w/main/types/"+url.PathEscape(typeName), "main is read-only", true)
 		return
 	}
-	if err := DeleteObject(ctx.RepoPath, typeName, id); err != nil {
+	deleted, err := s.repo.DeleteObjectWithReferences(ctx.RepoPath, typeName, id, ctx.Constraints)
+	if err != nil {
 		s.redirectWithFlash(w, r, "/w/"+url.PathEscape(workspace)+"/types/"+url.PathEscape(typeName), err.Error(), true)
 		return
 	}
-	s.redirectWithFlash(w, r, "/w/"+url.PathEscape(workspace)+"/types/"+url.PathEscape(typeName), "Object deleted in draft", false)
+	msg := "Object deleted in draft"
+	if len(deleted) > 1 {
+		msg = fmt.Sprintf("Object and %d dependent object(s) deleted in draft", len(deleted)-1)
+	}
+	s.redirectWithFlash(w, r, "/w/"+url.PathEscape(workspace)+"/types/"+url.PathEscape(typeName), msg, false)
+}
+
+func (s *webServer) handleObjectRevertTo(w http.ResponseWriter, r *http.Request, workspace, typeName, id string) {
+	path := "/w/" + url.PathEscape(workspace) + "/types/" + url.PathEscape(typeName) + "/objects/" + url.PathEscape(id)
+	if workspace == "main" {
+		s.redirectWithFlash(w, r, path, "main is read-only", true)
+		return
+	}
+	commit := strings.TrimSpace(r.FormValue("commit"))
+	if err := s.repo.RevertObject(workspace, typeName, id, commit); err != nil {
+		s.redirectWithFlash(w, r, path, err.Error(), true)
+		return
+	}
+	s.redirectWithFlash(w, r, path, "Object reverted to commit "+shortCommit(commit)+"; save the workspace to keep it", false)
+}
+
+func shortCommit(hash string) string {
+	if len(hash) > 7 {
+		return hash[:7]
+	}
+	return hash
 }
 
 func (s *webServer) handleObjectRestore(w http.ResponseWriter, r *http.Request, workspace, typeName, id string) {
@@ -705,6 +1391,153 @@ func (s *webServer) handleObjectRestore(w http.ResponseWriter, r *http.Request,
 	s.redirectWithFlash(w, r, "/w/"+url.PathEscape(workspace)+"/types/"+url.PathEscape(typeName), "Object restored", false)
 }
 
+// handleObjectBulk deletes or restores every selected object, continuing past
+// objects that are skipped or fail and summarising all outcomes in one flash.
+func (s *webServer) handleObjectBulk(w http.ResponseWriter, r *http.Request, workspace, typeName string) {
+	listPath := "/w/" + url.PathEscape(workspace) + "/types/" + url.PathEscape(typeName)
+	ctx, err := s.loadContext(workspace)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if ctx.ReadOnly {
+		s.redirectWithFlash(w, r, listPath, "main is read-only", true)
+		return
+	}
+	if err := r.ParseForm(); err != nil {
+		s.redirectWithFlash(w, r, listPath, err.Error(), true)
+		return
+	}
+	action := r.FormValue("action")
+	var verb, skipReason string
+	switch action {
+	case "delete":
+		verb, skipReason = "deleted", "already deleted"
+	case "restore":
+		verb, skipReason = "restored", "not deleted"
+	default:
+		s.redirectWithFlash(w, r, listPath, fmt.Sprintf("unknown bulk action %q", action), true)
+		return
+	}
+	ids := r.Form["id"]
+	if len(ids) == 0 {
+		s.redirectWithFlash(w, r, listPath, "No objects selected", true)
+		return
+	}
+
+	done, dependents, skipped := 0, 0, 0
+	failures := make([]string, 0)
+	for _, id := range ids {
+		status := ctx.DirtyByType[typeName][id]
+		switch action {
+		case "delete":
+			if status == "D" {
+				skipped++
+				continue
+			}
+			deleted, err := s.repo.DeleteObjectWithReferences(ctx.RepoPath, typeName, id, ctx.Constraints)
+			if err != nil {
+				failures = append(failures, id+": "+err.Error())
+				continue
+			}
+			done++
+			dependents += len(deleted) - 1
+		case "restore":
+			if status != "D" {
+				skipped++
+				continue
+			}
+			if err := s.repo.RestoreObject(workspace, typeName, id); err != nil {
+				failures = append(failures, id+": "+err.Error())
+				continue
+			}
+			done++
+		}
+	}
+
+	parts := []string{fmt.Sprintf("%d %s", done, verb)}
+	if dependents > 0 {
+		parts[0] += fmt.Sprintf(" (plus %d dependent object(s))", dependents)
+	}
+	if skipped > 0 {
+		parts = append(parts, fmt.Sprintf("%d skipped (%s)", skipped, skipReason))
+	}
+	if len(failures) > 0 {
+		parts = append(parts, fmt.Sprintf("%d failed (%s)", len(failures), strings.Join(failures, "; ")))
+	}
+	s.redirectWithFlash(w, r, listPath, strings.Join(parts, ", ")+".", len(failures) > 0)
+}
+
+// maxImportUploadBytes bounds the file accepted by the import form.
+const maxImportUploadBytes = 10 << 20
+
+func (s *webServer) handleObjectImport(w http.ResponseWriter, r *http.Request, workspace, typeName string) {
+	listPath := "/w/" + url.PathEscape(workspace) + "/types/" + url.PathEscape(typeName)
+	ctx, err := s.loadContext(workspace)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if ctx.ReadOnly {
+		s.redirectWithFlash(w, r, listPath, "main is read-only", true)
+		return
+	}
+	r.Body = http.MaxBytesReader(w, r.Body, maxImportUploadBytes)
+	if err := r.ParseMultipartForm(maxImportUploadBytes); err != nil {
+		s.redirectWithFlash(w, r, listPath, "import: "+err.Error(), true)
+		return
+	}
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		s.redirectWithFlash(w, r, listPath, "import: choose a JSON or CSV file", true)
+		return
+	}
+	defer file.Close()
+	b, err := io.ReadAll(file)
+	if err != nil {
+		s.redirectWithFlash(w, r, listPath, "import: "+err.Error(), true)
+		return
+	}
+	rows, err := ParseImportRows(header.Filename, "", b)
+	if err != nil {
+		s.redirectWithFlash(w, r, listPath, "import: "+err.Error(), true)
+		return
+	}
+	opts := ImportOptions{Upsert: r.FormValue("upsert") == "on", Strict: r.FormValue("strict") == "on"}
+	result, issues, err := s.repo.ImportObjects(ctx.RepoPath, typeName, rows, opts)
+	if err != nil {
+		msg := "import: " + err.Error()
+		if len(issues.Issues) > 0 {
+			msg += " (first: " + issues.Issues[0].String() + ")"
+		}
+		s.redirectWithFlash(w, r, listPath, msg, true)
+		return
+	}
+	msg := fmt.Sprintf("Imported %d created, %d updated", len(result.Created), len(result.Updated))
+	if len(result.Rejected) > 0 {
+		rejected := make([]string, 0, len(result.Rejected))
+		for _, rej := range result.Rejected {
+			rejected = append(rejected, rej.String())
+		}
+		msg += fmt.Sprintf(", %d rejected (%s)", len(result.Rejected), strings.Join(rejected, "; "))
+	}
+	s.redirectWithFlash(w, r, listPath, msg+".", len(result.Rejected) > 0)
+}
+
+func (s *webServer) handleObjectRevertField(w http.ResponseWriter, r *http.Request, workspace, typeName, id string) {
+	path := "/w/" + url.PathEscape(workspace) + "/types/" + url.PathEscape(typeName) + "/objects/" + url.PathEscape(id)
+	if workspace == "main" {
+		s.redirectWithFlash(w, r, path, "main is read-only", true)
+		return
+	}
+	field := strings.TrimSpace(r.FormValue("field"))
+	if err := s.repo.RevertField(workspace, typeName, id, field); err != nil {
+		s.redirectWithFlash(w, r, path, err.Error(), true)
+		return
+	}
+	s.redirectWithFlash(w, r, path, "Field "+field+" reverted to main", false)
+}
+
 func (s *webServer) handleWorkspaceNewPage(w http.ResponseWriter, r *http.Request, workspace string) {
 	ctx, err := s.loadContext(workspace)
 	if err != nil {
@@ -744,6 +1577,35 @@ func (s *webServer) handleWorkspaceDelete(w http.ResponseWriter, r *http.Request
 		s.redirectWithFlash(w, r, "/w/main/types", "main cannot be deleted", true)
 		return
 	}
+	if r.FormValue("confirm") != "1" {
+		changed, err := s.repo.ChangedFiles(s.repo.WorkspacePath(workspace))
+		if err != nil {
+			s.redirectWithFlash(w, r, "/w/"+url.PathEscape(workspace)+"/types", err.Error(), true)
+			return
+		}
+		if len(changed) > 0 {
+			ctx, err := s.loadContext(workspace)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			data := workspaceDeletePageData{
+				pageBase: pageBase{
+					Top: s.topBar(ctx, r.URL.Path),
+					Crumbs: []breadcrumb{
+						{Label: "Types", URL: "/w/" + url.PathEscape(workspace) + "/types"},
+						{Label: "Delete Workspace", URL: r.URL.Path, Current: true},
+					},
+				},
+				Workspace:    workspace,
+				ChangedFiles: changed,
+				DeleteURL:    "/w/" + url.PathEscape(workspace) + "/workspace/delete",
+				BackURL:      "/w/" + url.PathEscape(workspace) + "/types",
+			}
+			s.renderTemplate(w, "workspace_delete.html", data)
+			return
+		}
+	}
 	if err := s.repo.DeleteWorkspace(workspace); err != nil {
 		s.redirectWithFlash(w, r, "/w/"+url.PathEscape(workspace)+"/types", err.Error(), true)
 		return
@@ -751,6 +1613,24 @@ func (s *webServer) handleWorkspaceDelete(w http.ResponseWriter, r *http.Request
 	s.redirectWithFlash(w, r, "/w/main/types", "Workspace deleted", false)
 }
 
+func (s *webServer) handleWorkspaceRename(w http.ResponseWriter, r *http.Request, workspace string) {
+	if workspace == "main" {
+		s.redirectWithFlash(w, r, "/w/main/config", "main cannot be renamed", true)
+		return
+	}
+	configURL := "/w/" + url.PathEscape(workspace) + "/config"
+	name := strings.TrimSpace(r.FormValue("name"))
+	if name == "" {
+		s.redirectWithFlash(w, r, configURL, "workspace name is required", true)
+		return
+	}
+	if err := s.repo.RenameWorkspace(workspace, name); err != nil {
+		s.redirectWithFlash(w, r, configURL, err.Error(), true)
+		return
+	}
+	s.redirectWithFlash(w, r, "/w/"+url.PathEscape(name)+"/types", "Workspace renamed to "+name, false)
+}
+
 func (s *webServer) handleWorkspaceSave(w http.ResponseWriter, r *http.Request, workspace string) {
 	if workspace == "main" {
 		s.redirectWithFlash(w, r, "/w/main/types", "main is read-only", true)
@@ -771,10 +1651,74 @@ func (s *webServer) handleWorkspacePromote(w http.ResponseWriter, r *http.Reques
 		return
 	}
 	returnPath := firstNonEmpty(r.FormValue("return"), "/w/"+url.PathEscape(workspace)+"/types")
-	if err := r.ParseForm(); err != nil {
+	resolutions, manual, err := parseConflictResolutions(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	opts := MergeOptions{Push: r.FormValue("push") == "on", Sync: r.FormValue("sync") == "on"}
+	result, err := s.repo.MergeWorkspace(workspace, resolutions, manual, opts)
+	if err != nil {
+		msg := err.Error()
+		if result.PushOutput != "" {
+			msg += " (" + result.PushOutput + ")"
+		}
+		s.redirectWithFlash(w, r, returnPath, msg, true)
+		return
+	}
+	if len(result.Conflicts) > 0 {
+		s.renderConflicts(w, r, workspace, returnPath, result.Conflicts, resolutions, manual, nil)
+		return
+	}
+	if !result.Merged {
+		s.redirectWithFlash(w, r, returnPath, "Nothing to promote: "+result.Message, false)
+		return
+	}
+	msg := "Workspace promoted to main"
+	if opts.Sync {
+		msg += " after syncing from origin"
+	}
+	if result.Pushed {
+		msg += " and pushed"
+		if result.PushOutput != "" {
+			msg += " (" + result.PushOutput + ")"
+		}
+	}
+	s.redirectWithFlash(w, r, "/w/main/types", msg, false)
+}
+
+func (s *webServer) handleWorkspacePromoteCheck(w http.ResponseWriter, r *http.Request, workspace string) {
+	if workspace == "main" {
+		s.redirectWithFlash(w, r, "/w/main/types", "main cannot be promoted", true)
+		return
+	}
+	returnPath := firstNonEmpty(r.FormValue("return"), "/w/"+url.PathEscape(workspace)+"/types")
+	resolutions, manual, err := parseConflictResolutions(r)
+	if err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
+	all, err := s.repo.ValidateMergePreview(workspace)
+	if err != nil {
+		s.redirectWithFlash(w, r, returnPath, err.Error(), true)
+		return
+	}
+	if len(all.Conflicts) == 0 {
+		s.redirectWithFlash(w, r, returnPath, "No conflicts to resolve", false)
+		return
+	}
+	check, err := s.repo.ValidateMergeResult(workspace, resolutions, manual)
+	if err != nil {
+		s.redirectWithFlash(w, r, returnPath, err.Error(), true)
+		return
+	}
+	s.renderConflicts(w, r, workspace, returnPath, all.Conflicts, resolutions, manual, &check)
+}
+
+func parseConflictResolutions(r *http.Request) (map[string]string, map[string]string, error) {
+	if err := r.ParseForm(); err != nil {
+		return nil, nil, err
+	}
 	resolutions := map[string]string{}
 	manual := map[string]string{}
 	for key, vals := range r.Form {
@@ -788,45 +1732,88 @@ func (s *webServer) handleWorkspacePromote(w http.ResponseWriter, r *http.Reques
 			manual[strings.TrimPrefix(key, "manual.")] = vals[0]
 		}
 	}
-	result, err := s.repo.MergeWorkspace(workspace, resolutions, manual)
+	return resolutions, manual, nil
+}
+
+func (s *webServer) renderConflicts(w http.ResponseWriter, r *http.Request, workspace, returnPath string, conflicts []FieldConflict, resolutions, manual map[string]string, check *MergePreview) {
+	ctx, err := s.loadContext(workspace)
 	if err != nil {
-		s.redirectWithFlash(w, r, returnPath, err.Error(), true)
+		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
-	if len(result.Conflicts) > 0 {
-		ctx, err := s.loadContext(workspace)
-		if err != nil {
-			http.Error(w, err.Error(), http.StatusBadRequest)
-			return
+	rows := make([]conflictRow, 0, len(conflicts))
+	for _, c := range conflicts {
+		rows = append(rows, conflictRow{
+			Key:            c.Key,
+			File:           c.File,
+			Field:          c.Field,
+			Base:           valueToText(c.Base),
+			Main:           valueToText(c.Main),
+			WorkspaceValue: valueToText(c.Workspace),
+			Choice:         resolutions[c.Key],
+			Manual:         manual[c.Key],
+			WholeFile:      c.Field == wholeFileField,
+			Elements:       arrayConflictElements(c.Base, c.Main, c.Workspace),
+		})
+	}
+	promoteURL := "/w/" + url.PathEscape(workspace) + "/promote"
+	data := conflictView{
+		pageBase: pageBase{
+			Top: s.topBar(ctx, r.URL.Path),
+			Crumbs: []breadcrumb{
+				{Label: "Types", URL: "/w/" + url.PathEscape(workspace) + "/types"},
+				{Label: "Promote", URL: promoteURL, Current: true},
+			},
+		},
+		Workspace: workspace,
+		Conflicts: rows,
+		PostURL:   promoteURL,
+		CheckURL:  promoteURL + "/check",
+		BackURL:   returnPath,
+		Push:      r.FormValue("push") == "on",
+		Sync:      r.FormValue("sync") == "on",
+	}
+	if check != nil {
+		data.Checked = true
+		data.Unresolved = len(check.Conflicts)
+		data.CheckIssues = check.Validation.Issues
+	}
+	s.renderTemplate(w, "promote_conflicts.html", data)
+}
+
+// arrayConflictElements lists the distinct items of a conflicting array field
+// in first-seen order across base, main, and workspace. It returns nil unless
+// every present version is an array.
+func arrayConflictElements(base, main, ws any) []conflictElement {
+	var elements []conflictElement
+	index := map[string]int{}
+	for side, v := range []any{base, main, ws} {
+		if v == nil {
+			continue
 		}
-		rows := make([]conflictRow, 0, len(result.Conflicts))
-		for _, c := range result.Conflicts {
-			rows = append(rows, conflictRow{
-				Key:            c.Key,
-				File:           c.File,
-				Field:          c.Field,
-				Base:           valueToText(c.Base),
-				Main:           valueToText(c.Main),
-				WorkspaceValue: valueToText(c.Workspace),
-			})
+		arr, ok := v.([]any)
+		if !ok {
+			return nil
 		}
-		data := conflictView{
-			pageBase: pageBase{
-				Top: s.topBar(ctx, r.URL.Path),
-				Crumbs: []breadcrumb{
-					{Label: "Types", URL: "/w/" + url.PathEscape(workspace) + "/types"},
-					{Label: "Promote", URL: r.URL.Path, Current: true},
-				},
-			},
-			Workspace: workspace,
-			Conflicts: rows,
-			PostURL:   "/w/" + url.PathEscape(workspace) + "/promote",
-			BackURL:   returnPath,
+		for _, item := range arr {
+			text := valueToText(item)
+			i, seen := index[text]
+			if !seen {
+				i = len(elements)
+				index[text] = i
+				elements = append(elements, conflictElement{Value: text})
+			}
+			switch side {
+			case 0:
+				elements[i].InBase = true
+			case 1:
+				elements[i].InMain = true
+			default:
+				elements[i].InWorkspace = true
+			}
 		}
-		s.renderTemplate(w, "promote_conflicts.html", data)
-		return
 	}
-	s.redirectWithFlash(w, r, "/w/main/types", "Workspace promoted to main", false)
+	return elements
 }
 
 func (s *webServer) handleWorkspaceValidate(w http.ResponseWriter, r *http.Request, workspace string) {
@@ -836,7 +1823,7 @@ func (s *webServer) handleWorkspaceValidate(w http.ResponseWriter, r *http.Reque
 		return
 	}
 	returnPath := firstNonEmpty(r.FormValue("return"), "/w/"+url.PathEscape(workspace)+"/types")
-	result, err := ValidateRepository(ctx.RepoPath)
+	result, err := ValidateRepository(ctx.RepoPath, s.repo.YAMLOptions)
 	if err != nil {
 		s.redirectWithFlash(w, r, returnPath, err.Error(), true)
 		return
@@ -848,17 +1835,42 @@ func (s *webServer) handleWorkspaceValidate(w http.ResponseWriter, r *http.Reque
 	s.redirectWithFlash(w, r, returnPath, "Validation passed", false)
 }
 
-func (s *webServer) handleConfigPage(w http.ResponseWriter, r *http.Request, workspace string) {
+func (s *webServer) handleWorkspaceCanonicalize(w http.ResponseWriter, r *http.Request, workspace string) {
 	ctx, err := s.loadContext(workspace)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
-	types := make([]string, 0, len(ctx.Schemas))
-	for t := range ctx.Schemas {
-		types = append(types, t)
+	returnPath := firstNonEmpty(r.FormValue("return"), "/w/"+url.PathEscape(workspace)+"/types")
+	if ctx.ReadOnly {
+		s.redirectWithFlash(w, r, returnPath, "main is read-only", true)
+		return
+	}
+	rewritten, err := s.repo.CanonicalizeDataFiles(ctx.RepoPath)
+	if err != nil {
+		s.redirectWithFlash(w, r, returnPath, err.Error(), true)
+		return
+	}
+	summary := fmt.Sprintf("Canonicalized %d file(s)", len(rewritten))
+	result, err := ValidateRepository(ctx.RepoPath, s.repo.YAMLOptions)
+	if err != nil {
+		s.redirectWithFlash(w, r, returnPath, summary+"; "+err.Error(), true)
+		return
+	}
+	if !result.OK() {
+		s.redirectWithFlash(w, r, returnPath, fmt.Sprintf("%s; %d validation issue(s), first: %s", summary, len(result.Issues), result.Issues[0].String()), true)
+		return
+	}
+	s.redirectWithFlash(w, r, returnPath, summary+"; validation passed", false)
+}
+
+func (s *webServer) handleConfigPage(w http.ResponseWriter, r *http.Request, workspace string) {
+	ctx, err := s.loadContext(workspace)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
 	}
-	sort.Strings(types)
+	types := sortedKeys(ctx.Schemas)
 	links := make([]typeSettingLink, 0, len(types))
 	for _, t := range types {
 		links = append(links, typeSettingLink{TypeName: t, URL: "/w/" + url.PathEscape(workspace) + "/config/types/" + url.PathEscape(t)})
@@ -876,6 +1888,8 @@ func (s *webServer) handleConfigPage(w http.ResponseWriter, r *http.Request, wor
 		ReadOnly:     ctx.ReadOnly,
 		RepoName:     ctx.UI.RepoName,
 		SaveURL:      "/w/" + url.PathEscape(workspace) + "/config",
+		Workspace:    workspace,
+		RenameURL:    "/w/" + url.PathEscape(workspace) + "/workspace/rename",
 		TypeSettings: links,
 	}
 	s.renderTemplate(w, "config.html", data)
@@ -897,7 +1911,7 @@ func (s *webServer) handleConfigSave(w http.ResponseWriter, r *http.Request, wor
 		s.redirectWithFlash(w, r, "/w/"+url.PathEscape(workspace)+"/config", issue.String(), true)
 		return
 	}
-	if err := SaveUIConfig(ctx.RepoPath, cfg); err != nil {
+	if err := s.repo.SaveUIConfig(ctx.RepoPath, cfg); err != nil {
 		s.redirectWithFlash(w, r, "/w/"+url.PathEscape(workspace)+"/config", err.Error(), true)
 		return
 	}
@@ -920,11 +1934,7 @@ func (s *webServer) handleTypeConfigPage(w http.ResponseWriter, r *http.Request,
 		tc.DisplayField = "_id"
 	}
 	displayOptions := []displayOption{{Name: "_id", Selected: tc.DisplayField == "_id"}}
-	required := make([]string, 0, len(schema.Required))
-	for req := range schema.Required {
-		required = append(required, req)
-	}
-	sort.Strings(required)
+	required := sortedKeys(schema.Required)
 	for _, req := range required {
 		displayOptions = append(displayOptions, displayOption{Name: req, Selected: tc.DisplayField == req})
 	}
@@ -954,13 +1964,17 @@ func (s *webServer) handleTypeConfigPage(w http.ResponseWriter, r *http.Request,
 			Flash:      r.URL.Query().Get("flash"),
 			FlashError: r.URL.Query().Get("error") == "1",
 		},
-		ReadOnly:        ctx.ReadOnly,
-		TypeName:        typeName,
-		DisplayOptions:  displayOptions,
-		ExtraOptions:    extraOptions,
-		SaveURL:         "/w/" + url.PathEscape(workspace) + "/config/types/" + url.PathEscape(typeName),
-		BackURL:         "/w/" + url.PathEscape(workspace) + "/types/" + url.PathEscape(typeName),
-		CurrentRepoName: ctx.UI.RepoName,
+		ReadOnly:         ctx.ReadOnly,
+		TypeName:         typeName,
+		DisplayOptions:   displayOptions,
+		ExtraOptions:     extraOptions,
+		SaveURL:          "/w/" + url.PathEscape(workspace) + "/config/types/" + url.PathEscape(typeName),
+		BackURL:          "/w/" + url.PathEscape(workspace) + "/types/" + url.PathEscape(typeName),
+		CurrentRepoName:  ctx.UI.RepoName,
+		Color:            tc.Color,
+		Icon:             tc.Icon,
+		DefaultSortField: tc.DefaultSortField,
+		DefaultSortDir:   tc.DefaultSortDir,
 	}
 	s.renderTemplate(w, "type_config.html", data)
 }
@@ -990,13 +2004,20 @@ func (s *webServer) handleTypeConfigSave(w http.ResponseWriter, r *http.Request,
 	}
 	selected := dedupeOrdered(r.Form["extraField"])
 	tc.Fields = sortSelectedFieldsByOrder(selected, r.Form)
+	tc.Color = strings.TrimSpace(r.FormValue("color"))
+	tc.Icon = strings.TrimSpace(r.FormValue("icon"))
+	tc.DefaultSortField = strings.TrimSpace(r.FormValue("defaultSortField"))
+	tc.DefaultSortDir = ""
+	if tc.DefaultSortField != "" {
+		tc.DefaultSortDir = firstNonEmpty(strings.TrimSpace(r.FormValue("defaultSortDir")), "asc")
+	}
 	cfg.Types[typeName] = tc
 
 	for _, issue := range ValidateUIConfig(cfg, ctx.Schemas) {
 		s.redirectWithFlash(w, r, "/w/"+url.PathEscape(workspace)+"/config/types/"+url.PathEscape(typeName), issue.String(), true)
 		return
 	}
-	if err := SaveUIConfig(ctx.RepoPath, cfg); err != nil {
+	if err := s.repo.SaveUIConfig(ctx.RepoPath, cfg); err != nil {
 		s.redirectWithFlash(w, r, "/w/"+url.PathEscape(workspace)+"/config/types/"+url.PathEscape(typeName), err.Error(), true)
 		return
 	}
@@ -1027,6 +2048,7 @@ func (s *webServer) redirectWithFlash(w http.ResponseWriter, r *http.Request, ra
 	}
 	q := u.Query()
 	q.Set("flash", message)
+	q.Del("warn")
 	if isError {
 		q.Set("error", "1")
 	} else {
@@ -1036,6 +2058,22 @@ func (s *webServer) redirectWithFlash(w http.ResponseWriter, r *http.Request, ra
 	http.Redirect(w, r, u.String(), http.StatusSeeOther)
 }
 
+// redirectWithWarning is redirectWithFlash for an action that succeeded but
+// has something the user should look at; pages that read warn=1 show the
+// message in the warning style.
+func (s *webServer) redirectWithWarning(w http.ResponseWriter, r *http.Request, rawURL, message string) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		u = &url.URL{Path: "/"}
+	}
+	q := u.Query()
+	q.Set("flash", message)
+	q.Del("error")
+	q.Set("warn", "1")
+	u.RawQuery = q.Encode()
+	http.Redirect(w, r, u.String(), http.StatusSeeOther)
+}
+
 func mapDirtyEntries(entries []ChangedEntry) map[string]map[string]string {
 	out := map[string]map[string]string{}
 	for _, entry := range entries {
@@ -1062,26 +2100,63 @@ func parseDataObjectPath(path string) (typeName, id string, ok bool) {
 	return parts[1], strings.TrimSuffix(parts[2], ".yaml"), true
 }
 
-func schemaToFieldData(schema Schema) []fieldData {
+func schemaToFieldData(schema Schema, hidden []string) []fieldData {
 	fields := make([]fieldData, 0, len(schema.Properties))
 	for name, prop := range schema.Properties {
+		if contains(hidden, name) {
+			continue
+		}
 		_, required := schema.Required[name]
-		fields = append(fields, fieldData{
+		field := fieldData{
 			Name:      name,
 			Type:      prop.Type,
 			ItemsType: prop.ItemsType,
 			Required:  required,
+			Integer:   prop.Type == "integer",
 			Enum:      prop.Enum,
 			MinLength: intPtrString(prop.MinLength),
 			MaxLength: intPtrString(prop.MaxLength),
+			Pattern:   stringPtrValue(prop.Pattern),
 			Minimum:   floatPtrString(prop.Minimum),
 			Maximum:   floatPtrString(prop.Maximum),
-		})
+		}
+		for _, n := range prop.EnumNumbers {
+			field.Enum = append(field.Enum, formatNumber(n))
+		}
+		if prop.Type == "object" {
+			// Nested inputs are named "<field>.<child>" so form values,
+			// issues, and the descriptor all share one flat namespace.
+			field.Children = schemaToFieldData(Schema{Required: prop.Required, Properties: prop.Properties}, nil)
+			for i := range field.Children {
+				field.Children[i].Name = name + "." + field.Children[i].Name
+			}
+		}
+		fields = append(fields, field)
 	}
 	sort.Slice(fields, func(i, j int) bool { return fields[i].Name < fields[j].Name })
 	return fields
 }
 
+func attachFieldIssues(fields []fieldData, issues []ValidationIssue) []ValidationIssue {
+	index := make(map[string]*fieldData, len(fields))
+	for i := range fields {
+		index[fields[i].Name] = &fields[i]
+		for j := range fields[i].Children {
+			index[fields[i].Children[j].Name] = &fields[i].Children[j]
+		}
+	}
+	unmatched := make([]ValidationIssue, 0)
+	for _, issue := range issues {
+		f, ok := index[issue.Field]
+		if !ok {
+			unmatched = append(unmatched, issue)
+			continue
+		}
+		f.Issues = append(f.Issues, issue)
+	}
+	return unmatched
+}
+
 func (s *webServer) enrichForeignKeys(ctx *workspaceContext, typeName string, fields []fieldData) {
 	if ctx == nil || len(fields) == 0 || len(ctx.Constraints.ForeignKeys) == 0 {
 		return
@@ -1104,7 +2179,7 @@ func (s *webServer) enrichForeignKeys(ctx *workspaceContext, typeName string, fi
 			displayField = constraint.ToField
 		}
 
-		targets, err := ListObjectsForType(ctx.RepoPath, constraint.ToType)
+		targets, err := ctx.objects(constraint.ToType)
 		if err != nil {
 			continue
 		}
@@ -1182,6 +2257,14 @@ func ensureForeignKeyCurrentOptions(fields []fieldData, values map[string]string
 	}
 }
 
+// isFractionalInteger reports a parsed form value that an integer field cannot
+// hold. parseFormField keeps it as a float so the draft still records what was
+// typed.
+func isFractionalInteger(v any, prop SchemaProperty) bool {
+	n, ok := v.(float64)
+	return ok && prop.Type == "integer" && n != math.Trunc(n)
+}
+
 func parseFormField(raw string, prop SchemaProperty) (any, error) {
 	switch prop.Type {
 	case "string":
@@ -1232,6 +2315,19 @@ func parseFormField(raw string, prop SchemaProperty) (any, error) {
 	}
 }
 
+func duplicateArrayItems(arr []any) []string {
+	seen := map[string]int{}
+	dups := make([]string, 0)
+	for _, item := range arr {
+		text := valueToText(item)
+		seen[text]++
+		if seen[text] == 2 {
+			dups = append(dups, text)
+		}
+	}
+	return dups
+}
+
 func computeDiffs(mainData, wsData map[string]any) []fieldDiff {
 	keys := map[string]struct{}{}
 	for k := range mainData {
@@ -1246,11 +2342,7 @@ func computeDiffs(mainData, wsData map[string]any) []fieldDiff {
 		}
 		keys[k] = struct{}{}
 	}
-	fields := make([]string, 0, len(keys))
-	for k := range keys {
-		fields = append(fields, k)
-	}
-	sort.Strings(fields)
+	fields := sortedKeys(keys)
 
 	diffs := make([]fieldDiff, 0)
 	for _, field := range fields {
@@ -1272,6 +2364,15 @@ func computeDiffs(mainData, wsData map[string]any) []fieldDiff {
 	return diffs
 }
 
+func listCell(field string, v any) namedValue {
+	cell := namedValue{Name: field, Value: valueToText(v)}
+	if b, ok := v.(bool); ok {
+		cell.Kind = "boolean"
+		cell.Bool = b
+	}
+	return cell
+}
+
 func valueToText(v any) string {
 	switch t := v.(type) {
 	case nil:
@@ -1291,6 +2392,12 @@ func valueToText(v any) string {
 			parts = append(parts, valueToText(item))
 		}
 		return strings.Join(parts, ", ")
+	case map[string]any:
+		parts := make([]string, 0, len(t))
+		for _, k := range sortedKeys(t) {
+			parts = append(parts, k+": "+valueToText(t[k]))
+		}
+		return strings.Join(parts, "; ")
 	default:
 		return fmt.Sprintf("%v", v)
 	}
@@ -1309,6 +2416,18 @@ func valueToForm(v any) string {
 	}
 }
 
+// setFormValue stores a field's form text, flattening an object field into
+// "<field>.<child>" entries to match the nested input names.
+func setFormValue(values map[string]string, field string, v any) {
+	if m, ok := v.(map[string]any); ok {
+		for k, child := range m {
+			values[field+"."+k] = valueToForm(child)
+		}
+		return
+	}
+	values[field] = valueToForm(v)
+}
+
 func displayValue(data map[string]any, field, fallbackID string) string {
 	if field == "" || field == "_id" {
 		return fallbackID
@@ -1392,9 +2511,9 @@ func buildCrumbs(workspace string, parts ...string) []breadcrumb {
 	return crumbs
 }
 
-func collectObjectIssues(repoPath string) (map[string]map[string][]ValidationIssue, error) {
+func (s *webServer) collectObjectIssues(repoPath string) (map[string]map[string][]ValidationIssue, error) {
 	result := map[string]map[string][]ValidationIssue{}
-	validation, err := ValidateRepository(repoPath)
+	validation, err := ValidateRepository(repoPath, s.repo.YAMLOptions)
 	if err != nil {
 		return result, err
 	}
@@ -1456,6 +2575,13 @@ func intPtrString(v *int) string {
 	return strconv.Itoa(*v)
 }
 
+func stringPtrValue(v *string) string {
+	if v == nil {
+		return ""
+	}
+	return *v
+}
+
 func floatPtrString(v *float64) string {
 	if v == nil {
 		return ""
@@ -0,0 +1,994 @@
+package app
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestRenderedPagesAreStable renders each page several times: output built
+// from map iteration must not change between requests.
+func TestRenderedPagesAreStable(t *testing.T) {
+	repo := newTestRepository(t)
+	ws := newTestWorkspace(t, repo, "draft")
+	addTestSchemaField(t, ws, "service", "owner")
+	addTestSchemaField(t, ws, "service", "region")
+	h := newTestHandler(t, repo)
+
+	paths := []string{
+		"/w/main/types",
+		"/w/main/types/service",
+		"/w/main/types/service/objects/22222222-2222-4222-8222-222222222222",
+		"/w/main/types/service/new",
+		"/w/main/config",
+		"/w/main/config/types/service",
+		"/w/main/search?q=e",
+		"/w/main/api/types",
+		"/w/main/api/types/service/form",
+		"/w/draft/types/service",
+		"/w/draft/types/service/objects/22222222-2222-4222-8222-222222222222",
+		"/w/draft/config/types/service",
+	}
+	for _, path := range paths {
+		first := getTestPage(t, h, path)
+		for i := 0; i < 5; i++ {
+			if got := getTestPage(t, h, path); !bytes.Equal(got, first) {
+				t.Errorf("GET %s: request %d rendered differently from the first", path, i+2)
+				break
+			}
+		}
+	}
+}
+
+func TestRecentPageOrdersChangedObjectsByRecency(t *testing.T) {
+	repo := newTestRepository(t)
+	ws := newTestWorkspace(t, repo, "draft")
+	older := time.Now().Add(-time.Hour)
+	for _, c := range []struct {
+		typeName, id, field string
+		modTime             time.Time
+	}{
+		{"team", testTeamID, "name", older},
+		{"service", testServiceID, "name", older.Add(30 * time.Minute)},
+	} {
+		obj, err := repo.ReadObject(ws, c.typeName, c.id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		obj.Data[c.field] = "renamed"
+		if err := repo.WriteObject(ws, obj); err != nil {
+			t.Fatal(err)
+		}
+		path := filepath.Join(ws, "data", c.typeName, c.id+".yaml")
+		if err := os.Chtimes(path, c.modTime, c.modTime); err != nil {
+			t.Fatal(err)
+		}
+	}
+	h := newTestHandler(t, repo)
+
+	page := string(getTestPage(t, h, "/w/draft/recent"))
+	service := strings.Index(page, "/w/draft/types/service/objects/"+testServiceID)
+	team := strings.Index(page, "/w/draft/types/team/objects/"+testTeamID)
+	if service < 0 || team < 0 {
+		t.Fatalf("recent page misses a changed object (service at %d, team at %d)", service, team)
+	}
+	if service > team {
+		t.Error("recent page lists the older team change before the newer service change")
+	}
+
+	// Touching the team makes it the most recent change.
+	now := time.Now()
+	if err := os.Chtimes(filepath.Join(ws, "data", "team", testTeamID+".yaml"), now, now); err != nil {
+		t.Fatal(err)
+	}
+	page = string(getTestPage(t, h, "/w/draft/recent"))
+	if strings.Index(page, "/w/draft/types/team/objects/"+testTeamID) > strings.Index(page, "/w/draft/types/service/objects/"+testServiceID) {
+		t.Error("recent page does not reorder after the team changes again")
+	}
+}
+
+func TestObjectPageShowsIssuesNextToTheirFields(t *testing.T) {
+	repo := newTestRepository(t)
+	ws := newTestWorkspace(t, repo, "draft")
+	writeTestFile(t, filepath.Join(ws, "data", "service", testServiceID+".yaml"),
+		"_id: "+testServiceID+"\n_type: service\nlegacy: x\nname: edge-gateway\nteamId: "+testTeamID+"\ntier: gold\n")
+	h := newTestHandler(t, repo)
+
+	page := string(getTestPage(t, h, "/w/draft/types/service/objects/"+testServiceID))
+	field := func(name string) string {
+		start := strings.Index(page, `data-field="`+name+`"`)
+		if start < 0 {
+			t.Fatalf("field %s not rendered", name)
+		}
+		rest := page[start:]
+		if end := strings.Index(rest[1:], `class="field-wrap`); end >= 0 {
+			rest = rest[:end+1]
+		}
+		return page[strings.LastIndex(page[:start], "<div"):start] + rest
+	}
+	if tier := field("tier"); !strings.Contains(tier, "has-issues") || !strings.Contains(tier, "must be one of enum values") {
+		t.Errorf("tier input does not carry its enum issue:\n%s", tier)
+	}
+	if name := field("name"); strings.Contains(name, "has-issues") || strings.Contains(name, "field-issues") {
+		t.Errorf("name input carries an issue it does not have:\n%s", name)
+	}
+
+	// The unknown field has no input, so its issue stays in the block.
+	block := page[:strings.Index(page, `data-field="`)]
+	if !strings.Contains(block, "<code>legacy</code> field is not defined in schema") {
+		t.Error("issue block misses the object-level issue")
+	}
+	if !strings.Contains(block, "1 issue(s) are shown next to their fields") || strings.Contains(block, "must be one of enum values") {
+		t.Error("issue block lists the field-specific issue instead of counting it")
+	}
+}
+
+func TestWorkspaceDeleteConfirmsOnlyWhenDirty(t *testing.T) {
+	repo := newTestRepository(t)
+	dirty := newTestWorkspace(t, repo, "dirty")
+	addTestSchemaField(t, dirty, "service", "owner")
+	newTestWorkspace(t, repo, "clean")
+	h := newTestHandler(t, repo)
+
+	rec := postTestForm(t, h, "/w/dirty/workspace/delete", nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("dirty delete: status %d, want the confirmation page", rec.Code)
+	}
+	if !strings.Contains(rec.Body.String(), "config/schemas/service.schema.json") {
+		t.Error("confirmation page does not list the changed file")
+	}
+	if !repo.WorkspaceExists("dirty") {
+		t.Fatal("dirty workspace deleted without confirmation")
+	}
+	rec = postTestForm(t, h, "/w/dirty/workspace/delete", url.Values{"confirm": {"1"}})
+	if rec.Code != http.StatusSeeOther || repo.WorkspaceExists("dirty") {
+		t.Errorf("confirmed delete: status %d, workspace exists %v", rec.Code, repo.WorkspaceExists("dirty"))
+	}
+
+	rec = postTestForm(t, h, "/w/clean/workspace/delete", nil)
+	if rec.Code != http.StatusSeeOther || repo.WorkspaceExists("clean") {
+		t.Errorf("clean delete: status %d, workspace exists %v", rec.Code, repo.WorkspaceExists("clean"))
+	}
+}
+
+func TestReadOnlyServerBlocksWritesInEveryWorkspace(t *testing.T) {
+	repo := newTestRepository(t)
+	ws := newTestWorkspace(t, repo, "draft")
+	server := newTestServer(t, repo)
+	server.readOnly = true
+	h := serveTestServer(server)
+
+	service := "/types/service/objects/" + testServiceID
+	posts := map[string]url.Values{
+		"/types/service/objects/write": {"id": {testServiceID}, "field.name": {"renamed"}, "field.teamId": {testTeamID}, "field.tier": {"edge"}},
+		service + "/delete":            nil,
+		"/save":                        {"message": {"edit"}},
+		"/promote":                     nil,
+		"/workspace/delete":            {"confirm": {"1"}},
+	}
+	for _, workspace := range []string{"main", "draft"} {
+		for path, form := range posts {
+			rec := postTestForm(t, h, "/w/"+workspace+path, form)
+			if rec.Code != http.StatusSeeOther || !strings.Contains(rec.Header().Get("Location"), "read-only") {
+				t.Errorf("POST /w/%s%s: status %d, location %q, want a read-only flash", workspace, path, rec.Code, rec.Header().Get("Location"))
+			}
+		}
+	}
+	if !repo.WorkspaceExists("draft") {
+		t.Fatal("read-only server deleted a workspace")
+	}
+	if changed, err := repo.ChangedFiles(ws); err != nil {
+		t.Fatal(err)
+	} else if len(changed) != 0 {
+		t.Errorf("read-only server changed the workspace: %v", changed)
+	}
+
+	// Browsing and validation stay available.
+	getTestPage(t, h, "/w/draft"+service)
+	if rec := postTestForm(t, h, "/w/draft/validate", nil); strings.Contains(rec.Header().Get("Location"), "read-only") {
+		t.Error("validation is blocked in read-only mode")
+	}
+}
+
+func TestObjectWriteIgnoresConflictingType(t *testing.T) {
+	repo := newTestRepository(t)
+	ws := newTestWorkspace(t, repo, "draft")
+	h := newTestHandler(t, repo)
+
+	rec := postTestForm(t, h, "/w/draft/types/service/objects/write", url.Values{
+		"id":           {testServiceID},
+		"field._type":  {"team"},
+		"field.name":   {"edge-gateway"},
+		"field.teamId": {testTeamID},
+		"field.tier":   {"core"},
+	})
+	if rec.Code != http.StatusSeeOther {
+		t.Fatalf("write: status %d\n%s", rec.Code, rec.Body.String())
+	}
+	loc, err := url.Parse(rec.Header().Get("Location"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if flash := loc.Query().Get("flash"); !strings.Contains(flash, `_type "team" ignored; must match path type "service"`) || loc.Query().Get("warn") != "1" || loc.Query().Get("error") != "" {
+		t.Errorf("flash = %q (%s), want a warning about the ignored _type", flash, loc.RawQuery)
+	}
+
+	saved, err := repo.ReadObject(ws, "service", testServiceID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if saved.Data["_type"] != "service" || saved.Data["tier"] != "core" {
+		t.Errorf("saved = %v, want _type service with the submitted tier", saved.Data)
+	}
+	if _, err := os.Stat(filepath.Join(ws, "data", "team", testServiceID+".yaml")); !os.IsNotExist(err) {
+		t.Errorf("write created a team object: %v", err)
+	}
+}
+
+func TestTypeListRendersBooleanCellsWithIndicator(t *testing.T) {
+	for _, c := range []struct {
+		value any
+		want  namedValue
+	}{
+		{true, namedValue{Name: "f", Value: "true", Kind: "boolean", Bool: true}},
+		{false, namedValue{Name: "f", Value: "false", Kind: "boolean"}},
+		{"true", namedValue{Name: "f", Value: "true"}},
+	} {
+		if got := listCell("f", c.value); got != c.want {
+			t.Errorf("listCell(%#v) = %+v, want %+v", c.value, got, c.want)
+		}
+	}
+
+	repo := newTestRepository(t)
+	editTestJSON(t, filepath.Join(repo.Root, "config", "schemas", "team.schema.json"), func(doc map[string]any) {
+		doc["properties"].(map[string]any)["active"] = map[string]any{"type": "boolean"}
+	})
+	writeTestFile(t, filepath.Join(repo.Root, "data", "team", testTeamID+".yaml"),
+		"_id: "+testTeamID+"\n_type: team\nactive: true\ncode: PLAT\nname: Platform\n")
+	editTestJSON(t, filepath.Join(repo.Root, "config", "ui.json"), func(doc map[string]any) {
+		doc["types"].(map[string]any)["team"] = map[string]any{"displayField": "name", "fields": []any{"active"}}
+	})
+	page := string(getTestPage(t, newTestHandler(t, repo), "/w/main/types/team"))
+	if !strings.Contains(page, `<span class="bool-mark yes" title="true">`) {
+		t.Error("boolean extra field is not rendered with a typed indicator")
+	}
+}
+
+func TestSearchMatchesObjectsAcrossTypes(t *testing.T) {
+	repo := newTestRepository(t)
+	other := testObjectID(3)
+	writeTestFile(t, filepath.Join(repo.Root, "data", "team", other+".yaml"),
+		"_id: "+other+"\n_type: team\ncode: BILL\nname: Billing\n")
+	editTestJSON(t, filepath.Join(repo.Root, "config", "ui.json"), func(doc map[string]any) {
+		types := doc["types"].(map[string]any)
+		types["team"] = map[string]any{"displayField": "name", "fields": []any{}}
+		types["service"] = map[string]any{"displayField": "name", "fields": []any{"tier"}}
+	})
+	h := newTestHandler(t, repo)
+
+	// "AT" is in Platform and edge-gateway, regardless of case.
+	page := string(getTestPage(t, h, "/w/main/search?q=AT"))
+	for _, link := range []string{"/w/main/types/team/objects/" + testTeamID, "/w/main/types/service/objects/" + testServiceID} {
+		if !strings.Contains(page, link) {
+			t.Errorf("search results miss %s", link)
+		}
+	}
+	if strings.Contains(page, other) {
+		t.Error("search results include a team that does not match")
+	}
+
+	// Extra fields from the UI config are searched too.
+	writeTestFile(t, filepath.Join(repo.Root, "data", "service", testServiceID+".yaml"),
+		"_id: "+testServiceID+"\n_type: service\nname: edge-gateway\nteamId: "+testTeamID+"\ntier: core\n")
+	page = string(getTestPage(t, h, "/w/main/search?q=core"))
+	if !strings.Contains(page, "/w/main/types/service/objects/"+testServiceID) || strings.Contains(page, "/w/main/types/team/objects/") {
+		t.Error("searching the configured tier field did not return only the service")
+	}
+}
+
+func TestObjectWriteWarnsAboutDuplicateArrayEntries(t *testing.T) {
+	repo := newTestRepository(t)
+	ws := newTestWorkspace(t, repo, "draft")
+	editTestJSON(t, filepath.Join(ws, "config", "ui.json"), func(doc map[string]any) {
+		doc["types"].(map[string]any)["service"] = map[string]any{"displayField": "name", "warnDuplicateItems": true}
+	})
+	h := newTestHandler(t, repo)
+
+	rec := postTestForm(t, h, "/w/draft/types/service/objects/write", url.Values{
+		"id":           {testServiceID},
+		"field.name":   {"edge-gateway"},
+		"field.teamId": {testTeamID},
+		"field.tier":   {"edge"},
+		"field.ports":  {"443,443,8443"},
+	})
+	if rec.Code != http.StatusSeeOther {
+		t.Fatalf("write: status %d\n%s", rec.Code, rec.Body.String())
+	}
+	loc, err := url.Parse(rec.Header().Get("Location"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if flash := loc.Query().Get("flash"); !strings.HasPrefix(flash, "Draft updated; ") || !strings.Contains(flash, "duplicate entries in ports: 443") {
+		t.Errorf("flash = %q, want a duplicate entries warning", flash)
+	}
+	if loc.Query().Get("warn") != "1" || loc.Query().Get("error") != "" {
+		t.Errorf("flash level = %s, want a warning, not an error", loc.RawQuery)
+	}
+	if page := string(getTestPage(t, h, loc.String())); !strings.Contains(page, `<section class="notice warn">Draft updated;`) {
+		t.Error("object page does not show the warning in the warning style")
+	}
+
+	// The warning does not block the write.
+	saved, err := repo.ReadObject(ws, "service", testServiceID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ports, _ := saved.Data["ports"].([]any); len(ports) != 3 {
+		t.Errorf("ports = %v, want all three submitted entries", saved.Data["ports"])
+	}
+}
+
+func TestObjectRevertFieldLeavesOtherChanges(t *testing.T) {
+	repo := newTestRepository(t)
+	ws := newTestWorkspace(t, repo, "draft")
+	writeTestFile(t, filepath.Join(ws, "data", "service", testServiceID+".yaml"),
+		"_id: "+testServiceID+"\n_type: service\nname: edge-v2\nports:\n  - 80\nteamId: "+testTeamID+"\ntier: core\n")
+	h := newTestHandler(t, repo)
+
+	rec := postTestForm(t, h, "/w/draft/types/service/objects/"+testServiceID+"/revert", url.Values{"field": {"tier"}})
+	if rec.Code != http.StatusSeeOther || strings.Contains(rec.Header().Get("Location"), "error=1") {
+		t.Fatalf("revert: status %d, location %q", rec.Code, rec.Header().Get("Location"))
+	}
+	saved, err := repo.ReadObject(ws, "service", testServiceID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if saved.Data["tier"] != "edge" {
+		t.Errorf("tier = %v, want main's edge", saved.Data["tier"])
+	}
+	if saved.Data["name"] != "edge-v2" || len(saved.Data["ports"].([]any)) != 1 {
+		t.Errorf("saved = %v, want name and ports still changed", saved.Data)
+	}
+
+	// Reverting a field main does not set removes it.
+	addTestSchemaField(t, ws, "service", "owner")
+	saved.Data["owner"] = "ops"
+	if err := repo.WriteObject(ws, saved); err != nil {
+		t.Fatal(err)
+	}
+	postTestForm(t, h, "/w/draft/types/service/objects/"+testServiceID+"/revert", url.Values{"field": {"owner"}})
+	if saved, err = repo.ReadObject(ws, "service", testServiceID); err != nil {
+		t.Fatal(err)
+	} else if _, ok := saved.Data["owner"]; ok || saved.Data["name"] != "edge-v2" {
+		t.Errorf("saved = %v, want owner removed and name kept", saved.Data)
+	}
+}
+
+func TestRootRedirectsToBaseWorkspace(t *testing.T) {
+	repo := newTestRepository(t)
+	newTestWorkspace(t, repo, "editing")
+	rootRedirect := func(base string) string {
+		t.Helper()
+		server := newTestServer(t, repo)
+		server.baseWorkspace = base
+		rec := httptest.NewRecorder()
+		serveTestServer(server).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+		if rec.Code != http.StatusSeeOther {
+			t.Fatalf("GET / with base %q: status %d", base, rec.Code)
+		}
+		return rec.Header().Get("Location")
+	}
+
+	for base, want := range map[string]string{
+		"":        "/w/main/types",
+		"editing": "/w/editing/types",
+		"missing": "/w/main/types",
+	} {
+		if got := rootRedirect(base); got != want {
+			t.Errorf("base %q: redirect to %s, want %s", base, got, want)
+		}
+	}
+}
+
+func TestCanonicalizeRewritesNonCanonicalFiles(t *testing.T) {
+	repo := newTestRepository(t)
+	ws := newTestWorkspace(t, repo, "draft")
+	teamPath := filepath.Join(ws, "data", "team", testTeamID+".yaml")
+	canonical, err := os.ReadFile(teamPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeTestFile(t, teamPath, "name: Platform\ncode: PLAT\n_type: team\n_id: "+testTeamID+"\n")
+	h := newTestHandler(t, repo)
+
+	rec := postTestForm(t, h, "/w/draft/maintenance/canonicalize", nil)
+	loc, err := url.Parse(rec.Header().Get("Location"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if flash := loc.Query().Get("flash"); flash != "Canonicalized 1 file(s); validation passed" || loc.Query().Get("error") == "1" {
+		t.Errorf("flash = %q, want one file reported", flash)
+	}
+	if got, err := os.ReadFile(teamPath); err != nil {
+		t.Fatal(err)
+	} else if string(got) != string(canonical) {
+		t.Errorf("team file =\n%s\nwant canonical\n%s", got, canonical)
+	}
+
+	rec = postTestForm(t, h, "/w/main/maintenance/canonicalize", nil)
+	if loc, _ := url.Parse(rec.Header().Get("Location")); loc.Query().Get("flash") != "main is read-only" {
+		t.Errorf("main: location %q, want the read-only flash", rec.Header().Get("Location"))
+	}
+}
+
+func TestTypesHomeCountsInvalidObjects(t *testing.T) {
+	repo := newTestRepository(t)
+	ws := newTestWorkspace(t, repo, "draft")
+	for i, tier := range []string{"gold", "batch"} {
+		id := testObjectID(3 + i)
+		writeTestFile(t, filepath.Join(ws, "data", "service", id+".yaml"),
+			"_id: "+id+"\n_type: service\nname: svc-"+tier+"\nteamId: "+testTeamID+"\ntier: "+tier+"\n")
+	}
+
+	page := string(getTestPage(t, newTestHandler(t, repo), "/w/draft/types"))
+	row := func(typeName string) string {
+		start := strings.Index(page, `<a href="/w/draft/types/`+typeName+`">`)
+		if start < 0 {
+			t.Fatalf("types home has no row for %s", typeName)
+		}
+		return page[start : start+strings.Index(page[start:], "</tr>")]
+	}
+	if got := row("service"); !strings.Contains(got, `title="Objects failing validation">1 invalid</a>`) {
+		t.Errorf("service row does not count its one invalid object:\n%s", got)
+	}
+	if got := row("team"); strings.Contains(got, "invalid") {
+		t.Errorf("team row reports invalid objects:\n%s", got)
+	}
+}
+
+func TestEnrichForeignKeysListsTargetTypeOnce(t *testing.T) {
+	repo := newTestRepository(t)
+	server := newTestServer(t, repo)
+	ctx := &workspaceContext{
+		RepoPath: repo.Root,
+		Constraints: Constraints{ForeignKeys: []ForeignKeyConstraint{
+			{FromType: "service", FromField: "teamId", ToType: "team", ToField: "_id", ToDisplayField: "name"},
+			{FromType: "service", FromField: "backupTeamId", ToType: "team", ToField: "_id", ToDisplayField: "name"},
+		}},
+		yaml: repo.YAMLOptions,
+	}
+	fields := []fieldData{{Name: "teamId"}, {Name: "backupTeamId"}}
+	server.enrichForeignKeys(ctx, "service", fields)
+	if len(ctx.objectCache) != 1 || len(ctx.objectCache["team"]) != 1 {
+		t.Fatalf("object cache = %v, want only the team type listed", ctx.objectCache)
+	}
+
+	// With the team files gone, the same request still builds the options
+	// from the objects it already listed.
+	if err := os.RemoveAll(filepath.Join(repo.Root, "data", "team")); err != nil {
+		t.Fatal(err)
+	}
+	fields = append(fields, fieldData{Name: "teamId"})
+	server.enrichForeignKeys(ctx, "service", fields[2:])
+	want := foreignKeyOption{Value: testTeamID, Display: "Platform"}
+	for _, field := range fields {
+		if field.ForeignKey == nil || len(field.ForeignKey.Options) != 1 || field.ForeignKey.Options[0] != want {
+			t.Errorf("%s foreign key = %+v, want the one cached team", field.Name, field.ForeignKey)
+		}
+	}
+}
+
+func TestObjectWriteChecksForeignKeyReferences(t *testing.T) {
+	repo := newTestRepository(t)
+	ws := newTestWorkspace(t, repo, "draft")
+	h := newTestHandler(t, repo)
+	dangling := testObjectID(9)
+
+	for _, tc := range []struct {
+		teamID string
+		want   string
+	}{
+		{testTeamID, ""},
+		{dangling, `teamId: reference "` + dangling + `" does not exist in team._id`},
+	} {
+		rec := postTestForm(t, h, "/w/draft/types/service/objects/write", url.Values{
+			"id":           {testServiceID},
+			"field.name":   {"edge-gateway"},
+			"field.teamId": {tc.teamID},
+			"field.tier":   {"edge"},
+		})
+		if rec.Code != http.StatusSeeOther {
+			t.Fatalf("write %s: status %d\n%s", tc.teamID, rec.Code, rec.Body.String())
+		}
+		loc, err := url.Parse(rec.Header().Get("Location"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		q := loc.Query()
+		flash := q.Get("flash")
+		if q.Get("error") != "" {
+			t.Errorf("write %s: error=%q, want the saved draft not reported as an error", tc.teamID, q.Get("error"))
+		}
+		if tc.want == "" && (strings.Contains(flash, "does not exist") || q.Get("warn") != "") {
+			t.Errorf("valid reference flash = %q warn=%q, want no reference warning", flash, q.Get("warn"))
+		}
+		if tc.want != "" && (!strings.Contains(flash, tc.want) || q.Get("warn") != "1") {
+			t.Errorf("dangling reference flash = %q warn=%q, want warning %q", flash, q.Get("warn"), tc.want)
+		}
+		if tc.want != "" {
+			page := string(getTestPage(t, h, loc.String()))
+			if !strings.Contains(page, `<section class="notice warn">Draft updated; `) {
+				t.Error("dangling reference page lacks the warning banner")
+			}
+		}
+
+		// The draft is saved either way; validation reports it later.
+		saved, err := repo.ReadObject(ws, "service", testServiceID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if saved.Data["teamId"] != tc.teamID {
+			t.Errorf("saved teamId = %v, want %s", saved.Data["teamId"], tc.teamID)
+		}
+	}
+}
+
+func TestObjectPageChangesOnlyShowsDiffedFields(t *testing.T) {
+	repo := newTestRepository(t)
+	ws := newTestWorkspace(t, repo, "draft")
+	replaceTestFile(t, filepath.Join(ws, "data", "service", testServiceID+".yaml"), "tier: edge", "tier: core")
+	h := newTestHandler(t, repo)
+	path := "/w/draft/types/service/objects/" + testServiceID
+
+	all := string(getTestPage(t, h, path))
+	changes := string(getTestPage(t, h, path+"?changes=1"))
+	for _, field := range []string{"name", "teamId", "tier", "ports"} {
+		marker := `data-field="` + field + `"`
+		if !strings.Contains(all, marker) {
+			t.Errorf("full form is missing the %s input", field)
+		}
+		if shown := strings.Contains(changes, marker); shown != (field == "tier") {
+			t.Errorf("changes-only view shows %s = %v, want only the changed tier", field, shown)
+		}
+	}
+	// Unchanged values still ride along so Update Draft keeps them.
+	if !strings.Contains(changes, `<input type="hidden" name="field.name" value="edge-gateway">`) {
+		t.Error("changes-only view does not carry the unchanged name")
+	}
+	if !strings.Contains(changes, `href="`+path+`">Show all fields`) {
+		t.Error("changes-only view has no link back to the full form")
+	}
+}
+
+func TestObjectWriteReportsFractionalInteger(t *testing.T) {
+	repo := newTestRepository(t)
+	ws := newTestWorkspace(t, repo, "draft")
+	editTestJSON(t, filepath.Join(ws, "config", "schemas", "service.schema.json"), func(doc map[string]any) {
+		doc["properties"].(map[string]any)["replicas"] = map[string]any{"type": "integer"}
+	})
+	h := newTestHandler(t, repo)
+
+	rec := postTestForm(t, h, "/w/draft/types/service/objects/write", url.Values{
+		"id":             {testServiceID},
+		"field.name":     {"edge-gateway"},
+		"field.teamId":   {testTeamID},
+		"field.tier":     {"edge"},
+		"field.replicas": {"3.5"},
+	})
+	if rec.Code != http.StatusSeeOther {
+		t.Fatalf("write: status %d\n%s", rec.Code, rec.Body.String())
+	}
+	loc, err := url.Parse(rec.Header().Get("Location"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if flash := loc.Query().Get("flash"); !strings.Contains(flash, "replicas must be a whole number (got 3.5)") {
+		t.Errorf("flash = %q, want the whole number message", flash)
+	}
+
+	page := string(getTestPage(t, h, "/w/draft/types/service/objects/"+testServiceID))
+	if input := testInputTag(page, "field.replicas"); !strings.Contains(input, `value="3.5"`) || !strings.Contains(input, `step="1"`) {
+		t.Errorf("integer input %s is not marked with step=\"1\"", input)
+	}
+}
+
+func TestObjectWriteCanonicalizesAlias(t *testing.T) {
+	repo := newTestRepository(t)
+	ws := newTestWorkspace(t, repo, "draft")
+	editTestJSON(t, filepath.Join(ws, "config", "schemas", "service.schema.json"), func(doc map[string]any) {
+		doc["properties"].(map[string]any)["name"].(map[string]any)["aliases"] = []any{"title"}
+	})
+	replaceTestFile(t, filepath.Join(ws, "data", "service", testServiceID+".yaml"), "name: edge-gateway", "title: edge-gateway")
+	h := newTestHandler(t, repo)
+
+	page := string(getTestPage(t, h, "/w/draft/types/service/objects/"+testServiceID))
+	if !strings.Contains(page, `name="field.name" value="edge-gateway"`) {
+		t.Error("the aliased value does not fill the name input")
+	}
+	rec := postTestForm(t, h, "/w/draft/types/service/objects/write", url.Values{
+		"id":           {testServiceID},
+		"field.name":   {"edge-gateway"},
+		"field.teamId": {testTeamID},
+		"field.tier":   {"edge"},
+	})
+	if rec.Code != http.StatusSeeOther {
+		t.Fatalf("write: status %d\n%s", rec.Code, rec.Body.String())
+	}
+	saved, err := repo.ReadObject(ws, "service", testServiceID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := saved.Data["title"]; ok || saved.Data["name"] != "edge-gateway" {
+		t.Errorf("written data = %v, want the value under name only", saved.Data)
+	}
+}
+
+func TestObjectPageRendersConstraintAttributesAndDescriptor(t *testing.T) {
+	repo := newTestRepository(t)
+	ws := newTestWorkspace(t, repo, "draft")
+	editTestJSON(t, filepath.Join(ws, "config", "schemas", "service.schema.json"), func(doc map[string]any) {
+		props := doc["properties"].(map[string]any)
+		props["name"].(map[string]any)["minLength"] = 2
+		props["name"].(map[string]any)["maxLength"] = 40
+		props["replicas"] = map[string]any{"type": "integer", "minimum": 1, "maximum": 9, "multipleOf": 1}
+	})
+	page := string(getTestPage(t, newTestHandler(t, repo), "/w/draft/types/service/objects/"+testServiceID))
+
+	for name, attrs := range map[string][]string{
+		"field.name":     {`data-type="string"`, `data-required="true"`, `data-minlen="2"`, `data-maxlen="40"`},
+		"field.tier":     {`data-type="string"`, `data-enum="batch|core|edge"`},
+		"field.ports":    {`data-type="array"`, `data-item-type="integer"`},
+		"field.replicas": {`data-type="integer"`, `data-required="false"`, `data-min="1"`, `data-max="9"`},
+	} {
+		input := testInputTag(page, name)
+		for _, attr := range attrs {
+			if !strings.Contains(input, attr) {
+				t.Errorf("%s input %s lacks %s", name, input, attr)
+			}
+		}
+	}
+	// The descriptor still carries the rules attributes do not.
+	if !strings.Contains(page, `id="form-descriptor"`) || !strings.Contains(page, `"multipleOf":1`) {
+		t.Error("object page does not embed the form descriptor")
+	}
+}
+
+func TestObjectWriteNestedObjectFields(t *testing.T) {
+	repo := newTestRepository(t)
+	ws := newTestWorkspace(t, repo, "draft")
+	editTestJSON(t, filepath.Join(ws, "config", "schemas", "service.schema.json"), func(doc map[string]any) {
+		doc["properties"].(map[string]any)["endpoint"] = map[string]any{
+			"type":     "object",
+			"required": []any{"host"},
+			"properties": map[string]any{
+				"host": map[string]any{"type": "string"},
+				"port": map[string]any{"type": "integer"},
+				"tags": map[string]any{"type": "array", "items": map[string]any{"type": "string"}},
+			},
+		}
+	})
+	h := newTestHandler(t, repo)
+	servicePath := filepath.Join(ws, "data", "service", testServiceID+".yaml")
+	write := func(extra url.Values) *url.URL {
+		t.Helper()
+		form := url.Values{
+			"id":           {testServiceID},
+			"field.name":   {"edge-gateway"},
+			"field.teamId": {testTeamID},
+			"field.tier":   {"edge"},
+		}
+		for k, v := range extra {
+			form[k] = v
+		}
+		rec := postTestForm(t, h, "/w/draft/types/service/objects/write", form)
+		if rec.Code != http.StatusSeeOther {
+			t.Fatalf("write: status %d\n%s", rec.Code, rec.Body.String())
+		}
+		loc, err := url.Parse(rec.Header().Get("Location"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return loc
+	}
+
+	write(url.Values{"field.endpoint.host": {"edge.local"}, "field.endpoint.port": {"8443"}, "field.endpoint.tags": {"public, tls"}})
+	want := "_id: " + testServiceID + "\n_type: service\nendpoint:\n  host: edge.local\n  port: 8443\n  tags:\n    - public\n    - tls\nname: edge-gateway\nteamId: " + testTeamID + "\ntier: edge\n"
+	if b, err := os.ReadFile(servicePath); err != nil {
+		t.Fatal(err)
+	} else if string(b) != want {
+		t.Errorf("written file =\n%s\nwant\n%s", b, want)
+	}
+	page := string(getTestPage(t, h, "/w/draft/types/service/objects/"+testServiceID))
+	if input := testInputTag(page, "field.endpoint.port"); !strings.Contains(input, `value="8443"`) {
+		t.Errorf("nested port input = %s, want the saved value", input)
+	}
+
+	// Leaving every child empty drops the object.
+	write(nil)
+	if obj, err := repo.ReadObject(ws, "service", testServiceID); err != nil {
+		t.Fatal(err)
+	} else if _, ok := obj.Data["endpoint"]; ok {
+		t.Errorf("endpoint = %v, want it dropped", obj.Data["endpoint"])
+	}
+
+	// A child that does not parse is kept as typed and reported on its input.
+	write(url.Values{"field.endpoint.host": {"edge.local"}, "field.endpoint.port": {"high"}})
+	if obj, err := repo.ReadObject(ws, "service", testServiceID); err != nil {
+		t.Fatal(err)
+	} else if endpoint, _ := obj.Data["endpoint"].(map[string]any); endpoint["port"] != "high" {
+		t.Errorf("endpoint = %v, want the typed port kept", obj.Data["endpoint"])
+	}
+	page = string(getTestPage(t, h, "/w/draft/types/service/objects/"+testServiceID))
+	if !strings.Contains(page, `class="field-wrap has-issues" data-field="endpoint.port"`) {
+		t.Error("the invalid nested port is not flagged on its input")
+	}
+}
+
+func TestTypeListPaginatesAndFilters(t *testing.T) {
+	repo := newTestRepository(t)
+	ws := newTestWorkspace(t, repo, "draft")
+	writeTestServices(t, ws, 5)
+	h := newTestHandler(t, repo)
+
+	tests := []struct {
+		name string
+		path string
+		want []string
+		omit []string
+	}{
+		{
+			name: "middle page",
+			path: "/w/draft/types/service?pageSize=2&page=2",
+			want: []string{
+				"Showing 3&ndash;4 of 6", "Page 2 of 3",
+				`href="/w/draft/types/service?pageSize=2">Previous`,
+				`href="/w/draft/types/service?page=3&amp;pageSize=2">Next`,
+			},
+		},
+		{
+			name: "page past the end",
+			path: "/w/draft/types/service?pageSize=2&page=99",
+			want: []string{"Showing 5&ndash;6 of 6", "Page 3 of 3", ">Previous"},
+			omit: []string{">Next"},
+		},
+		{
+			name: "page before the start",
+			path: "/w/draft/types/service?pageSize=2&page=-4",
+			want: []string{"Showing 1&ndash;2 of 6", "Page 1 of 3", ">Next"},
+			omit: []string{">Previous"},
+		},
+		{
+			name: "oversized page size",
+			path: "/w/draft/types/service?pageSize=100000",
+			want: []string{"Showing 1&ndash;6 of 6"},
+			omit: []string{"Page 1 of"},
+		},
+		{
+			name: "filter",
+			path: "/w/draft/types/service?q=2222-4222&pageSize=2",
+			want: []string{"Showing 1&ndash;1 of 1", "/objects/" + testServiceID},
+			omit: []string{"Page 1 of"},
+		},
+		{
+			name: "filter without matches",
+			path: "/w/draft/types/service?q=nothing-matches",
+			omit: []string{"Showing", "/objects/" + testServiceID},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			page := string(getTestPage(t, h, tt.path))
+			for _, want := range tt.want {
+				if !strings.Contains(page, want) {
+					t.Errorf("page lacks %q", want)
+				}
+			}
+			for _, omit := range tt.omit {
+				if strings.Contains(page, omit) {
+					t.Errorf("page contains %q", omit)
+				}
+			}
+		})
+	}
+}
+
+func TestFilterObjectListItems(t *testing.T) {
+	items := []objectListItem{
+		{ID: "a", Display: "Edge Gateway"},
+		{ID: "b", Display: "billing", Fields: []namedValue{{Name: "tier", Value: "EDGE"}}},
+		{ID: "c", Display: "ingest", Fields: []namedValue{{Name: "tier", Value: "batch"}}},
+		{ID: "d", Display: "archived", Deleted: true, Fields: []namedValue{{Name: "tier", Value: "edge"}}},
+	}
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		{"edge", []string{"a", "b", "d"}},
+		{"GATE", []string{"a"}},
+		{"batch", []string{"c"}},
+		{"none", []string{}},
+	}
+	for _, tt := range tests {
+		got := []string{}
+		for _, item := range filterObjectListItems(items, tt.query) {
+			got = append(got, item.ID)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("filter %q = %v, want %v", tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestTypeListQueryURLOmitsDefaults(t *testing.T) {
+	tests := []struct {
+		query typeListQuery
+		page  int
+		want  string
+	}{
+		{typeListQuery{PageSize: defaultTypePageSize}, 1, "/t"},
+		{typeListQuery{PageSize: defaultTypePageSize}, 2, "/t?page=2"},
+		{typeListQuery{Query: "a b", Sort: "tier", Dir: "desc", PageSize: 10}, 3, "/t?dir=desc&page=3&pageSize=10&q=a+b&sort=tier"},
+	}
+	for _, tt := range tests {
+		if got := tt.query.url("/t", tt.page); got != tt.want {
+			t.Errorf("%+v page %d: url = %q, want %q", tt.query, tt.page, got, tt.want)
+		}
+	}
+}
+
+func TestSortObjectListItems(t *testing.T) {
+	item := func(id, value string, deleted bool) objectListItem {
+		it := objectListItem{ID: id, Deleted: deleted}
+		if value != "" {
+			it.Fields = []namedValue{{Name: "size", Value: value}}
+		}
+		return it
+	}
+	items := []objectListItem{
+		item("nine", "9", false),
+		item("blank", "", false),
+		item("ten", "10", false),
+		item("gone", "1", true),
+		item("word", "x", false),
+		item("two", "2", false),
+		item("also-nine", "9", false),
+	}
+	tests := []struct {
+		name string
+		prop SchemaProperty
+		desc bool
+		want []string
+	}{
+		{"numeric ascending", SchemaProperty{Type: "integer"}, false, []string{"two", "nine", "also-nine", "ten", "word", "blank", "gone"}},
+		{"numeric descending", SchemaProperty{Type: "number"}, true, []string{"word", "ten", "nine", "also-nine", "two", "blank", "gone"}},
+		{"text ascending", SchemaProperty{Type: "string"}, false, []string{"ten", "two", "nine", "also-nine", "word", "blank", "gone"}},
+		{"text descending", SchemaProperty{Type: "string"}, true, []string{"word", "nine", "also-nine", "two", "ten", "blank", "gone"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sorted := append([]objectListItem(nil), items...)
+			sortObjectListItems(sorted, "size", tt.prop, tt.desc)
+			got := make([]string, 0, len(sorted))
+			for _, it := range sorted {
+				got = append(got, it.ID)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("order = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestObjectBulkDeleteAndRestore(t *testing.T) {
+	repo := newTestRepository(t)
+	ids := writeTestServices(t, repo.Root, 3)
+	commitTestMain(t, repo, "add services")
+	ws := newTestWorkspace(t, repo, "draft")
+	h := newTestHandler(t, repo)
+	exists := func(id string) bool {
+		_, err := os.Stat(filepath.Join(ws, "data", "service", id+".yaml"))
+		return err == nil
+	}
+	bulk := func(form url.Values) url.Values {
+		t.Helper()
+		rec := postTestForm(t, h, "/w/draft/types/service/objects/bulk", form)
+		if rec.Code != http.StatusSeeOther {
+			t.Fatalf("bulk %v: status %d", form, rec.Code)
+		}
+		loc, err := url.Parse(rec.Header().Get("Location"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return loc.Query()
+	}
+
+	tests := []struct {
+		name    string
+		form    url.Values
+		flash   string
+		isError bool
+	}{
+		{"delete several", url.Values{"action": {"delete"}, "id": {ids[0], ids[1]}}, "2 deleted.", false},
+		{"delete again", url.Values{"action": {"delete"}, "id": {ids[0], ids[2]}}, "1 deleted, 1 skipped (already deleted).", false},
+		{"restore", url.Values{"action": {"restore"}, "id": {ids[0], ids[1], testServiceID}}, "2 restored, 1 skipped (not deleted).", false},
+		{"unknown action", url.Values{"action": {"archive"}, "id": {ids[0]}}, `unknown bulk action "archive"`, true},
+		{"empty selection", url.Values{"action": {"delete"}}, "No objects selected", true},
+	}
+	for _, tt := range tests {
+		q := bulk(tt.form)
+		if q.Get("flash") != tt.flash || (q.Get("error") == "1") != tt.isError {
+			t.Errorf("%s: flash %q error=%q, want %q error %v", tt.name, q.Get("flash"), q.Get("error"), tt.flash, tt.isError)
+		}
+	}
+	for id, want := range map[string]bool{ids[0]: true, ids[1]: true, ids[2]: false, testServiceID: true} {
+		if exists(id) != want {
+			t.Errorf("service %s exists = %v, want %v", id, !want, want)
+		}
+	}
+
+	rec := postTestForm(t, h, "/w/main/types/service/objects/bulk", url.Values{"action": {"delete"}, "id": {ids[0]}})
+	if loc := rec.Header().Get("Location"); !strings.Contains(loc, "error=1") {
+		t.Errorf("bulk delete on main redirected to %q, want an error", loc)
+	}
+	if _, err := os.Stat(filepath.Join(repo.Root, "data", "service", ids[0]+".yaml")); err != nil {
+		t.Errorf("bulk delete on main removed the file: %v", err)
+	}
+}
+
+func TestObjectWriteAppliesSchemaDefaultsOnCreate(t *testing.T) {
+	repo := newTestRepository(t)
+	ws := newTestWorkspace(t, repo, "draft")
+	editTestJSON(t, filepath.Join(ws, "config", "schemas", "service.schema.json"), func(doc map[string]any) {
+		props := doc["properties"].(map[string]any)
+		props["owner"] = map[string]any{"type": "string", "default": "platform"}
+		props["replicas"] = map[string]any{"type": "integer", "default": 2}
+	})
+	h := newTestHandler(t, repo)
+	write := func(form url.Values) Object {
+		t.Helper()
+		rec := postTestForm(t, h, "/w/draft/types/service/objects/write", form)
+		loc := rec.Header().Get("Location")
+		if rec.Code != http.StatusSeeOther || strings.Contains(loc, "error=1") {
+			t.Fatalf("write: status %d, location %q", rec.Code, loc)
+		}
+		id := form.Get("id")
+		if id == "" {
+			u, err := url.Parse(loc)
+			if err != nil {
+				t.Fatal(err)
+			}
+			id = filepath.Base(u.Path)
+		}
+		obj, err := repo.ReadObject(ws, "service", id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return obj
+	}
+
+	created := write(url.Values{"field.name": {"ingest"}, "field.teamId": {testTeamID}, "field.tier": {"batch"}})
+	if created.Data["owner"] != "platform" || created.Data["replicas"] != 2.0 {
+		t.Errorf("created data = %v, want owner and replicas defaulted", created.Data)
+	}
+
+	submitted := write(url.Values{"field.name": {"billing"}, "field.teamId": {testTeamID}, "field.tier": {"core"},
+		"field.owner": {""}, "field.replicas": {"5"}})
+	if _, ok := submitted.Data["owner"]; ok || submitted.Data["replicas"] != 5.0 {
+		t.Errorf("created data = %v, want the cleared owner left out and replicas kept", submitted.Data)
+	}
+
+	edited := write(url.Values{"id": {testServiceID}, "field.name": {"edge-gateway"}, "field.teamId": {testTeamID}, "field.tier": {"edge"}})
+	if _, ok := edited.Data["owner"]; ok {
+		t.Errorf("edited data = %v, want no defaults applied to an existing object", edited.Data)
+	}
+	if _, ok := edited.Data["replicas"]; ok {
+		t.Errorf("edited data = %v, want no defaults applied to an existing object", edited.Data)
+	}
+}
@@ -0,0 +1,58 @@
+package app
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const mergeWebhookTimeout = 5 * time.Second
+
+type mergeWebhookPayload struct {
+	Workspace string   `json:"workspace"`
+	Changed   []string `json:"changed"`
+	Commit    string   `json:"commit"`
+}
+
+func notifyMergeWebhook(webhookURL string, payload mergeWebhookPayload) error {
+	b, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	client := &http.Client{Timeout: mergeWebhookTimeout}
+	resp, err := client.Post(webhookURL, "application/json", bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// mergeWebhook returns the payload announcing the merge of workspace name,
+// or nil without a webhook. Callers hold r.mu, so Commit is the merge commit.
+func (r *Repository) mergeWebhook(name string, changed []string) *mergeWebhookPayload {
+	if r.MergeWebhook == "" {
+		return nil
+	}
+	sha, err := r.runGit(r.Root, "rev-parse", "HEAD")
+	if err != nil {
+		log.Printf("merge webhook: %v", err)
+		return nil
+	}
+	return &mergeWebhookPayload{Workspace: name, Changed: changed, Commit: strings.TrimSpace(sha)}
+}
+
+// notifyMerge sends payload to the merge webhook. It must not be called with
+// r.mu held: the request can take up to mergeWebhookTimeout.
+func (r *Repository) notifyMerge(payload mergeWebhookPayload) {
+	if err := notifyMergeWebhook(r.MergeWebhook, payload); err != nil {
+		log.Printf("merge webhook: %v", err)
+	}
+}
@@ -0,0 +1,62 @@
+package app
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestMergeWorkspaceSendsWebhookAfterReleasingLock(t *testing.T) {
+	repo := newTestRepository(t)
+	ws := newTestWorkspace(t, repo, "draft")
+	const id = "33333333-3333-4333-8333-333333333333"
+	if err := repo.WriteObject(ws, Object{ID: id, Type: "team", Data: map[string]any{"name": "Data", "code": "DATA"}}); err != nil {
+		t.Fatal(err)
+	}
+	saveTestWorkspace(t, repo, "draft")
+
+	var payloads []mergeWebhookPayload
+	var locked bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if repo.mu.TryLock() {
+			repo.mu.Unlock()
+		} else {
+			locked = true
+		}
+		var p mergeWebhookPayload
+		if err := json.NewDecoder(req.Body).Decode(&p); err != nil {
+			t.Errorf("decode webhook payload: %v", err)
+		}
+		payloads = append(payloads, p)
+	}))
+	defer server.Close()
+	repo.MergeWebhook = server.URL
+
+	result, err := repo.MergeWorkspace("draft", nil, nil, MergeOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.Merged {
+		t.Fatalf("merge result = %+v, want merged", result)
+	}
+	head, err := repo.runGit(repo.Root, "rev-parse", "HEAD")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(payloads) != 1 {
+		t.Fatalf("webhook received %d requests, want 1", len(payloads))
+	}
+	p := payloads[0]
+	if p.Workspace != "draft" || p.Commit != strings.TrimSpace(head) {
+		t.Errorf("payload = %+v, want workspace draft at commit %s", p, head)
+	}
+	if len(p.Changed) != 1 || p.Changed[0] != "data/team/"+id+".yaml" {
+		t.Errorf("changed = %v, want the new team file", p.Changed)
+	}
+	if locked {
+		t.Error("webhook was sent while the repository lock was held")
+	}
+}
@@ -3,8 +3,8 @@ package app
 import (
 	"errors"
 	"fmt"
+	"os"
 	"regexp"
-	"sort"
 	"strconv"
 	"strings"
 )
@@ -13,78 +13,237 @@ var numberLiteralPattern = regexp.MustCompile(`^[+-]?(?:\d+\.?\d*|\.\d+)(?:[eE][
 var safeStringPattern = regexp.MustCompile(`^[A-Za-z0-9_./-]+$`)
 
 func ParseSimpleYAMLObject(input []byte) (map[string]any, error) {
+	p := newYAMLParser(input, nil)
+	out, _, err := p.parseMapping(0, "", "")
+	return out, err
+}
+
+// YAMLComments holds the comments of an object file parsed with
+// ParseSimpleYAMLObjectWithComments. Keys are field paths: "field",
+// "parent.child" for object fields, and "field[i]" for array items. Comment
+// text keeps its leading "#".
+type YAMLComments struct {
+	Keys   map[string]YAMLComment
+	Footer []string
+}
+
+// YAMLComment is the full-line comments directly above a key or array item,
+// and the inline comment after its value.
+type YAMLComment struct {
+	Leading  []string
+	Trailing string
+}
+
+// ParseSimpleYAMLObjectWithComments parses like ParseSimpleYAMLObject but
+// accepts "#" comments instead of rejecting them. Each run of comment lines is
+// attached to the key or array item that follows it; comments after the last
+// key are kept as the footer.
+func ParseSimpleYAMLObjectWithComments(input []byte) (map[string]any, *YAMLComments, error) {
+	comments := &YAMLComments{Keys: map[string]YAMLComment{}}
+	p := newYAMLParser(input, comments)
+	out, _, err := p.parseMapping(0, "", "")
+	if err != nil {
+		return nil, nil, err
+	}
+	comments.Footer = p.pending
+	return out, comments, nil
+}
+
+// yamlParser reads object YAML line by line. comments is nil in strict mode,
+// where any comment is an error.
+type yamlParser struct {
+	lines    []string
+	comments *YAMLComments
+	pending  []string
+}
+
+func newYAMLParser(input []byte, comments *YAMLComments) *yamlParser {
 	text := strings.ReplaceAll(string(input), "\r\n", "\n")
-	lines := strings.Split(text, "\n")
+	return &yamlParser{lines: strings.Split(text, "\n"), comments: comments}
+}
+
+// skip consumes a blank line or, when comments are allowed, a full-line
+// comment, which is held until the next key or array item.
+func (p *yamlParser) skip(line string) (bool, error) {
+	trimmed := strings.TrimSpace(line)
+	if trimmed == "" {
+		return true, nil
+	}
+	if strings.HasPrefix(trimmed, "#") {
+		if p.comments == nil {
+			return false, errors.New("comments are not allowed")
+		}
+		p.pending = append(p.pending, trimmed)
+		return true, nil
+	}
+	if p.comments == nil && strings.Contains(line, " #") {
+		return false, errors.New("comments are not allowed")
+	}
+	return false, nil
+}
+
+// skippable reports whether line would be consumed by skip.
+func (p *yamlParser) skippable(line string) bool {
+	trimmed := strings.TrimSpace(line)
+	return trimmed == "" || (p.comments != nil && strings.HasPrefix(trimmed, "#"))
+}
+
+// attach records the pending comments and trailing comment for path.
+func (p *yamlParser) attach(path, trailing string) {
+	if p.comments == nil || (len(p.pending) == 0 && trailing == "") {
+		return
+	}
+	p.comments.Keys[path] = YAMLComment{Leading: p.pending, Trailing: trailing}
+	p.pending = nil
+}
+
+// splitValue separates an inline "# comment" from a raw value. Quoted values
+// may contain "#". In strict mode the value is returned unchanged.
+func (p *yamlParser) splitValue(raw string) (string, string) {
+	if p.comments == nil {
+		return raw, ""
+	}
+	if strings.HasPrefix(raw, "#") {
+		return "", raw
+	}
+	end := 0
+	switch {
+	case strings.HasPrefix(raw, `"`):
+		for end = 1; end < len(raw); end++ {
+			if raw[end] == '\\' {
+				end++
+				continue
+			}
+			if raw[end] == '"' {
+				end++
+				break
+			}
+		}
+	case strings.HasPrefix(raw, "'"):
+		if i := strings.IndexByte(raw[1:], '\''); i >= 0 {
+			end = i + 2
+		}
+	}
+	if end > len(raw) {
+		end = len(raw)
+	}
+	for i := end; i < len(raw); i++ {
+		if raw[i] == '#' && (raw[i-1] == ' ' || raw[i-1] == '\t') {
+			return strings.TrimSpace(raw[:i]), raw[i:]
+		}
+	}
+	return raw, ""
+}
+
+// parseMapping reads "key: value" lines at the given indent starting at line
+// i and returns the index of the first line that belongs to an outer mapping.
+// A key with no inline value holds either an array ("- " items one level
+// deeper) or, at the top level only, a nested mapping. prefix is prepended to
+// keys to form comment paths.
+func (p *yamlParser) parseMapping(i int, indent, prefix string) (map[string]any, int, error) {
+	lines := p.lines
 	out := make(map[string]any)
+	nested := indent != ""
 
-	for i := 0; i < len(lines); {
+	for i < len(lines) {
 		line := strings.TrimRight(lines[i], " \t")
-		if strings.TrimSpace(line) == "" {
+		skip, err := p.skip(line)
+		if err != nil {
+			return nil, i, err
+		}
+		if skip {
 			i++
 			continue
 		}
-		if strings.HasPrefix(strings.TrimSpace(line), "#") || strings.Contains(line, " #") {
-			return nil, errors.New("comments are not allowed")
+		if nested && !strings.HasPrefix(line, indent) {
+			break
 		}
+		line = strings.TrimPrefix(line, indent)
 		if strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t") {
-			return nil, fmt.Errorf("unexpected indentation at line %d", i+1)
+			return nil, i, fmt.Errorf("unexpected indentation at line %d", i+1)
 		}
 
 		colon := strings.IndexRune(line, ':')
 		if colon <= 0 {
-			return nil, fmt.Errorf("line %d is not key: value", i+1)
+			return nil, i, fmt.Errorf("line %d is not key: value", i+1)
 		}
 		key := strings.TrimSpace(line[:colon])
-		rest := strings.TrimSpace(line[colon+1:])
+		rest, trailing := p.splitValue(strings.TrimSpace(line[colon+1:]))
 		if key == "" {
-			return nil, fmt.Errorf("line %d has empty key", i+1)
+			return nil, i, fmt.Errorf("line %d has empty key", i+1)
 		}
 		if _, exists := out[key]; exists {
-			return nil, fmt.Errorf("duplicate key %q", key)
+			return nil, i, fmt.Errorf("duplicate key %q", key)
 		}
+		p.attach(prefix+key, trailing)
 
-		if rest == "" {
-			arr := make([]any, 0)
+		if rest != "" {
+			value, err := parseYAMLScalar(rest)
+			if err != nil {
+				return nil, i, fmt.Errorf("line %d: %w", i+1, err)
+			}
+			out[key] = value
 			i++
-			for i < len(lines) {
-				arrLine := strings.TrimRight(lines[i], " \t")
-				if strings.TrimSpace(arrLine) == "" {
-					i++
-					continue
-				}
-				if strings.HasPrefix(strings.TrimSpace(arrLine), "#") || strings.Contains(arrLine, " #") {
-					return nil, errors.New("comments are not allowed")
-				}
-				if !strings.HasPrefix(arrLine, "  - ") {
-					break
-				}
-				itemRaw := strings.TrimSpace(strings.TrimPrefix(arrLine, "  - "))
-				item, err := parseYAMLScalar(itemRaw)
-				if err != nil {
-					return nil, fmt.Errorf("line %d: %w", i+1, err)
-				}
-				arr = append(arr, item)
-				i++
+			continue
+		}
+
+		child := indent + "  "
+		i++
+		next := i
+		for next < len(lines) && p.skippable(lines[next]) {
+			next++
+		}
+		if next < len(lines) && strings.HasPrefix(lines[next], child) && !strings.HasPrefix(lines[next], child+"- ") {
+			if nested {
+				return nil, next, fmt.Errorf("line %d: nested objects deeper than one level are not supported", next+1)
+			}
+			m, end, err := p.parseMapping(i, child, prefix+key+".")
+			if err != nil {
+				return nil, end, err
 			}
-			out[key] = arr
+			out[key] = m
+			i = end
 			continue
 		}
 
-		value, err := parseYAMLScalar(rest)
-		if err != nil {
-			return nil, fmt.Errorf("line %d: %w", i+1, err)
+		arr := make([]any, 0)
+		for i < len(lines) {
+			arrLine := strings.TrimRight(lines[i], " \t")
+			if p.skippable(arrLine) {
+				if _, err := p.skip(arrLine); err != nil {
+					return nil, i, err
+				}
+				i++
+				continue
+			}
+			if p.comments == nil && strings.Contains(arrLine, " #") {
+				return nil, i, errors.New("comments are not allowed")
+			}
+			if !strings.HasPrefix(arrLine, child+"- ") {
+				break
+			}
+			itemRaw, itemTrailing := p.splitValue(strings.TrimSpace(strings.TrimPrefix(arrLine, child+"- ")))
+			item, err := parseYAMLScalar(itemRaw)
+			if err != nil {
+				return nil, i, fmt.Errorf("line %d: %w", i+1, err)
+			}
+			p.attach(fmt.Sprintf("%s%s[%d]", prefix, key, len(arr)), itemTrailing)
+			arr = append(arr, item)
+			i++
 		}
-		out[key] = value
-		i++
+		out[key] = arr
 	}
 
-	return out, nil
+	return out, i, nil
 }
 
 func parseYAMLScalar(raw string) (any, error) {
 	if raw == "[]" {
 		return []any{}, nil
 	}
+	if raw == "{}" {
+		return map[string]any{}, nil
+	}
 	if len(raw) >= 2 && raw[0] == '"' && raw[len(raw)-1] == '"' {
 		s, err := strconv.Unquote(raw)
 		if err != nil {
@@ -113,53 +272,106 @@ func parseYAMLScalar(raw string) (any, error) {
 	return raw, nil
 }
 
+// MarshalSimpleYAMLObject writes data in canonical form with the default
+// options.
 func MarshalSimpleYAMLObject(data map[string]any) ([]byte, error) {
-	keys := make([]string, 0, len(data))
-	for k := range data {
-		keys = append(keys, k)
-	}
-	sort.Strings(keys)
+	return YAMLOptions{}.MarshalObject(data, nil)
+}
 
+// MarshalObject writes data in canonical form, quoting strings in the
+// YAMLQuotes style, and re-emits comments for the paths still present.
+// Comments attached to removed keys or array items are dropped.
+func (y YAMLOptions) MarshalObject(data map[string]any, comments *YAMLComments) ([]byte, error) {
 	var b strings.Builder
+	w := yamlWriter{b: &b, comments: comments, quotes: y.YAMLQuotes}
+	if err := w.writeMapping(data, "", ""); err != nil {
+		return nil, err
+	}
+	if comments != nil {
+		for _, line := range comments.Footer {
+			fmt.Fprintln(&b, line)
+		}
+	}
+	return []byte(b.String()), nil
+}
+
+type yamlWriter struct {
+	b        *strings.Builder
+	comments *YAMLComments
+	quotes   string
+}
+
+// line writes one line for path, preceded by its leading comments and
+// followed by its trailing comment.
+func (w yamlWriter) line(indent, path, text string) {
+	var c YAMLComment
+	if w.comments != nil {
+		c = w.comments.Keys[path]
+	}
+	for _, comment := range c.Leading {
+		fmt.Fprintf(w.b, "%s%s\n", indent, comment)
+	}
+	if c.Trailing != "" {
+		text += " " + c.Trailing
+	}
+	fmt.Fprintf(w.b, "%s%s\n", indent, text)
+}
+
+func (w yamlWriter) writeMapping(data map[string]any, indent, prefix string) error {
+	keys := sortedKeys(data)
+
 	for _, key := range keys {
+		path := prefix + key
 		v := data[key]
 		switch t := v.(type) {
 		case nil:
-			fmt.Fprintf(&b, "%s: null\n", key)
+			w.line(indent, path, key+": null")
 		case string:
-			fmt.Fprintf(&b, "%s: %s\n", key, renderYAMLString(t))
+			w.line(indent, path, key+": "+renderYAMLString(t, w.quotes))
 		case bool:
 			if t {
-				fmt.Fprintf(&b, "%s: true\n", key)
+				w.line(indent, path, key+": true")
 			} else {
-				fmt.Fprintf(&b, "%s: false\n", key)
+				w.line(indent, path, key+": false")
 			}
 		case float64:
-			fmt.Fprintf(&b, "%s: %s\n", key, formatNumber(t))
+			w.line(indent, path, key+": "+formatNumber(t))
 		case []any:
 			if len(t) == 0 {
-				fmt.Fprintf(&b, "%s: []\n", key)
+				w.line(indent, path, key+": []")
 				continue
 			}
-			fmt.Fprintf(&b, "%s:\n", key)
-			for _, item := range t {
-				s, err := renderYAMLScalar(item)
+			w.line(indent, path, key+":")
+			for i, item := range t {
+				s, err := renderYAMLScalar(item, w.quotes)
 				if err != nil {
-					return nil, fmt.Errorf("field %s: %w", key, err)
+					return fmt.Errorf("field %s: %w", key, err)
 				}
-				fmt.Fprintf(&b, "  - %s\n", s)
+				w.line(indent+"  ", fmt.Sprintf("%s[%d]", path, i), "- "+s)
+			}
+		case map[string]any:
+			if indent != "" {
+				return fmt.Errorf("field %s: nested objects deeper than one level are not supported", key)
+			}
+			if len(t) == 0 {
+				w.line(indent, path, key+": {}")
+				continue
+			}
+			w.line(indent, path, key+":")
+			if err := w.writeMapping(t, "  ", path+"."); err != nil {
+				return fmt.Errorf("field %s: %w", key, err)
 			}
 		default:
-			return nil, fmt.Errorf("unsupported field %q type %T", key, v)
+			return fmt.Errorf("unsupported field %q type %T", key, v)
 		}
 	}
-	return []byte(b.String()), nil
+	return nil
 }
 
-func renderYAMLScalar(v any) (string, error) {
+func renderYAMLScalar(v any, quotes string) (string, error) {
 	switch t := v.(type) {
 	case string:
-		return renderYAMLString(t), nil
+		return renderYAMLString(t, quotes), nil
 	case float64:
 		return formatNumber(t), nil
 	case int:
@@ -173,16 +385,83 @@ func renderYAMLScalar(v any) (string, error) {
 	}
 }
 
-func renderYAMLString(s string) string {
-	if s == "" {
-		return `""`
+// YAML string quoting styles for object files. Auto leaves only simple
+// identifiers, paths, and slugs bare; always double-quotes every string;
+// minimal quotes only strings that would not read back unchanged as a plain
+// YAML scalar.
+const (
+	YAMLQuoteAuto    = "auto"
+	YAMLQuoteAlways  = "always"
+	YAMLQuoteMinimal = "minimal"
+)
+
+// YAMLOptions control how object files are read and written. The zero value
+// is the default: strict parsing and auto quoting.
+type YAMLOptions struct {
+	// YAMLQuotes is the quoting style of written strings; empty means
+	// YAMLQuoteAuto.
+	YAMLQuotes string
+	// YAMLComments makes object files accept "#" comments and keeps them
+	// when files are rewritten. Without it comments fail parsing, so data
+	// files stay canonical.
+	YAMLComments bool
+}
+
+// yamlOptionsFromEnv returns base overridden by WORKTREEFOUNDRY_YAML_COMMENTS
+// and WORKTREEFOUNDRY_YAML_QUOTES where they are set.
+func yamlOptionsFromEnv(base YAMLOptions) (YAMLOptions, error) {
+	if os.Getenv("WORKTREEFOUNDRY_YAML_COMMENTS") != "" {
+		base.YAMLComments = envBool("WORKTREEFOUNDRY_YAML_COMMENTS")
+	}
+	if raw := strings.TrimSpace(os.Getenv("WORKTREEFOUNDRY_YAML_QUOTES")); raw != "" {
+		if err := checkYAMLQuoteStyle(raw); err != nil {
+			return YAMLOptions{}, fmt.Errorf("WORKTREEFOUNDRY_YAML_QUOTES: %w", err)
+		}
+		base.YAMLQuotes = raw
+	}
+	return base, nil
+}
+
+func checkYAMLQuoteStyle(style string) error {
+	switch style {
+	case "", YAMLQuoteAuto, YAMLQuoteAlways, YAMLQuoteMinimal:
+		return nil
+	default:
+		return fmt.Errorf("unknown YAML quote style %q (expected auto, always, or minimal)", style)
+	}
+}
+
+func renderYAMLString(s, quotes string) string {
+	if s == "" || quotes == YAMLQuoteAlways {
+		return strconv.Quote(s)
 	}
 	lower := strings.ToLower(s)
-	if lower == "true" || lower == "false" || lower == "null" || numberLiteralPattern.MatchString(s) {
+	if lower == "true" || lower == "false" || lower == "null" || lower == "~" || numberLiteralPattern.MatchString(s) {
 		return strconv.Quote(s)
 	}
 	if safeStringPattern.MatchString(s) {
 		return s
 	}
+	if quotes == YAMLQuoteMinimal && plainYAMLString(s) {
+		return s
+	}
 	return strconv.Quote(s)
 }
+
+// plainYAMLString reports whether s can be written as a plain (unquoted)
+// scalar: no surrounding or control whitespace, no leading indicator
+// character, and nothing a parser would take for a mapping or a comment.
+func plainYAMLString(s string) bool {
+	if s != strings.TrimSpace(s) || strings.ContainsAny(s[:1], "-?:,[]{}#&*!|>'\"%@`") {
+		return false
+	}
+	if strings.Contains(s, ": ") || strings.Contains(s, " #") || strings.HasSuffix(s, ":") {
+		return false
+	}
+	for _, r := range s {
+		if !strconv.IsPrint(r) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,138 @@
+package app
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestYAMLQuoteStylesRenderAndRoundTrip(t *testing.T) {
+	values := []string{"edge", "Data team", "true", "42", "", "a: b", " padded", "#tag", "line\nbreak"}
+	tests := []struct {
+		quotes string
+		want   []string
+	}{
+		{YAMLQuoteAuto, []string{`edge`, `"Data team"`, `"true"`, `"42"`, `""`, `"a: b"`, `" padded"`, `"#tag"`, `"line\nbreak"`}},
+		{YAMLQuoteAlways, []string{`"edge"`, `"Data team"`, `"true"`, `"42"`, `""`, `"a: b"`, `" padded"`, `"#tag"`, `"line\nbreak"`}},
+		{YAMLQuoteMinimal, []string{`edge`, `Data team`, `"true"`, `"42"`, `""`, `"a: b"`, `" padded"`, `"#tag"`, `"line\nbreak"`}},
+	}
+	for _, tc := range tests {
+		t.Run(tc.quotes, func(t *testing.T) {
+			for i, v := range values {
+				if got := renderYAMLString(v, tc.quotes); got != tc.want[i] {
+					t.Errorf("renderYAMLString(%q) = %s, want %s", v, got, tc.want[i])
+				}
+			}
+
+			y := YAMLOptions{YAMLQuotes: tc.quotes}
+			items := make([]any, 0, len(values))
+			for _, v := range values {
+				items = append(items, v)
+			}
+			data := map[string]any{"_id": testTeamID, "_type": "team", "tags": items}
+			b, err := y.MarshalObject(data, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			obj, err := y.parseObjectContent(b, "team", testTeamID)
+			if err != nil {
+				t.Fatalf("parse rendered object: %v\n%s", err, b)
+			}
+			got, _ := obj.Data["tags"].([]any)
+			if len(got) != len(values) {
+				t.Fatalf("round trip = %#v, want %d strings\n%s", obj.Data["tags"], len(values), b)
+			}
+			for i, v := range values {
+				if got[i] != v {
+					t.Errorf("round trip of %q = %#v\n%s", v, got[i], b)
+				}
+			}
+			if tc.quotes == YAMLQuoteAlways && strings.Contains(string(b), "- edge\n") {
+				t.Errorf("always style left a bare string:\n%s", b)
+			}
+		})
+	}
+}
+
+func TestYAMLNestedMappingRoundTrip(t *testing.T) {
+	y := YAMLOptions{}
+	data := map[string]any{
+		"_id":   testServiceID,
+		"_type": "service",
+		"endpoint": map[string]any{
+			"host":   "edge.local",
+			"port":   443.0,
+			"secure": true,
+			"tags":   []any{"public", "tls"},
+		},
+		"empty": map[string]any{},
+		"name":  "edge-gateway",
+	}
+	b, err := y.MarshalObject(data, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "_id: " + testServiceID + "\n_type: service\nempty: {}\nendpoint:\n  host: edge.local\n  port: 443\n  secure: true\n  tags:\n    - public\n    - tls\nname: edge-gateway\n"
+	if string(b) != want {
+		t.Errorf("marshalled =\n%s\nwant\n%s", b, want)
+	}
+	obj, err := y.parseObjectContent(b, "service", testServiceID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(obj.Data, data) {
+		t.Errorf("round trip = %#v, want %#v", obj.Data, data)
+	}
+
+	deep := map[string]any{"_id": testServiceID, "_type": "service", "a": map[string]any{"b": map[string]any{"c": "d"}}}
+	if _, err := y.MarshalObject(deep, nil); err == nil || !strings.Contains(err.Error(), "deeper than one level") {
+		t.Errorf("marshal of a two-level map: error = %v, want it rejected", err)
+	}
+	if _, err := ParseSimpleYAMLObject([]byte("a:\n  b:\n    c: d\n")); err == nil || !strings.Contains(err.Error(), "deeper than one level") {
+		t.Errorf("parse of a two-level map: error = %v, want it rejected", err)
+	}
+	if _, err := ParseSimpleYAMLObject([]byte("a:\n  b: c\n   d: e\n")); err == nil {
+		t.Error("parse accepted a misindented nested key")
+	}
+}
+
+func TestYAMLCommentsRoundTripThroughEdit(t *testing.T) {
+	repo := newTestRepository(t)
+	path := filepath.Join(repo.Root, "data", "team", testTeamID+".yaml")
+	commented := "# owned by the platform group\n_id: " + testTeamID + "\n_type: team\ncode: PLAT # short code\nname: Platform\n# reviewed quarterly\n"
+	writeTestFile(t, path, commented)
+
+	if _, err := repo.ReadObject(repo.Root, "team", testTeamID); err == nil || !strings.Contains(err.Error(), "comments are not allowed") {
+		t.Errorf("strict read of a commented file: error = %v", err)
+	}
+
+	repo.YAMLComments = true
+	obj, err := repo.ReadObject(repo.Root, "team", testTeamID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	obj.Data["name"] = "platform-core"
+	if err := repo.WriteObject(repo.Root, obj); err != nil {
+		t.Fatal(err)
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := strings.Replace(commented, "name: Platform\n", "name: platform-core\n", 1)
+	if string(b) != want {
+		t.Errorf("rewritten file =\n%s\nwant\n%s", b, want)
+	}
+
+	delete(obj.Data, "code")
+	if err := repo.WriteObject(repo.Root, obj); err != nil {
+		t.Fatal(err)
+	}
+	if b, err := os.ReadFile(path); err != nil {
+		t.Fatal(err)
+	} else if strings.Contains(string(b), "short code") {
+		t.Errorf("comment of a removed key kept:\n%s", b)
+	}
+}